@@ -0,0 +1,43 @@
+package bark
+
+// MaxVolume 重要警告 (critical) 的最大音量
+const MaxVolume = 10
+
+// Critical 设置为重要警告: level=critical 并设置 0-10 的音量, 返回 o 以便链式调用
+// 音量按原值保存, 超出 0-MaxVolume 的值在 Validate (及推送前的校验) 时返回错误
+//
+// 重要警告会忽略静音和专注模式并按指定音量响铃, 必须同时设置级别和音量才能生效.
+// 需要在 Bark App 中授予重要警告权限; 只想持续响铃可使用 Ring
+func (o *Options) Critical(volume int) *Options {
+	o.Level = "critical"
+	o.Volume = &volume
+	return o
+}
+
+// Critical 创建重要警告推送, 见 Options.Critical
+func Critical(title, body string, volume int) *Options {
+	return (&Options{Title: title, Body: body}).Critical(volume)
+}
+
+// DefaultRingSound Ring 默认使用的铃声, 接近来电铃声
+const DefaultRingSound = "multiwayinvitation"
+
+// Ring 设置为来电式提醒: call=1 使铃声持续重复约 30 秒, 未设置铃声时使用 DefaultRingSound,
+// 未设置级别时使用 timeSensitive 以穿透专注模式. 适合值班系统 "把我叫醒" 的告警, 返回 o 以便链式调用
+//
+// 与 Critical 不同, Ring 遵循设备的静音开关; 两者可以同时使用
+func (o *Options) Ring() *Options {
+	o.Call = "1"
+	if o.Sound == "" {
+		o.Sound = DefaultRingSound
+	}
+	if o.Level == "" {
+		o.Level = "timeSensitive"
+	}
+	return o
+}
+
+// Ring 创建来电式提醒推送, 见 Options.Ring
+func Ring(title, body string) *Options {
+	return (&Options{Title: title, Body: body}).Ring()
+}
@@ -0,0 +1,46 @@
+package bark_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+func TestCriticalVolume(t *testing.T) {
+	tests := []struct {
+		volume int
+		valid  bool
+	}{
+		{-1, false},
+		{0, true},
+		{5, true},
+		{bark.MaxVolume, true},
+		{bark.MaxVolume + 1, false},
+		{50, false},
+	}
+	for _, tt := range tests {
+		o := bark.Critical("title", "body", tt.volume)
+		o.DeviceKey = "key"
+		if o.Level != "critical" || o.Volume == nil || *o.Volume != tt.volume {
+			t.Errorf("Critical(%d): level=%q volume=%v, want the volume kept as given", tt.volume, o.Level, o.Volume)
+		}
+		err := o.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("Critical(%d).Validate() = %v", tt.volume, err)
+		}
+		if !tt.valid && !errors.Is(err, bark.ErrInvalidOptions) {
+			t.Errorf("Critical(%d).Validate() = %v, want ErrInvalidOptions", tt.volume, err)
+		}
+	}
+}
+
+func TestValidateStrictVolumeLevel(t *testing.T) {
+	o := &bark.Options{DeviceKey: "key", Body: "body", Volume: bark.IntPtr(5)}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if err := o.ValidateStrict(); !errors.Is(err, bark.ErrInvalidOptions) {
+		t.Errorf("ValidateStrict() = %v, want an error for volume without the critical level", err)
+	}
+}
@@ -0,0 +1,156 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnknownAlias 推送目标不在地址簿中
+var ErrUnknownAlias = errors.New("bark: unknown recipient alias")
+
+// ErrUnknownGroup 收件人分组不存在
+var ErrUnknownGroup = errors.New("bark: unknown recipient group")
+
+// WithAliases 设置设备别名地址簿, 别名 -> 设备 Key
+// 通过 Options.To 指定的收件人在推送时解析为设备 Key
+func WithAliases(aliases map[string]string) ClientOption {
+	return func(c *Client) {
+		if c.aliases == nil {
+			c.aliases = make(map[string]string, len(aliases))
+		}
+		for name, key := range aliases {
+			c.aliases[name] = key
+		}
+	}
+}
+
+// WithGroups 设置收件人分组, 分组名 -> 成员别名列表
+// 通过 Options.To 指定分组名时展开为全部成员
+func WithGroups(groups map[string][]string) ClientOption {
+	return func(c *Client) {
+		if c.groups == nil {
+			c.groups = make(map[string][]string, len(groups))
+		}
+		for name, members := range groups {
+			c.groups[name] = slices.Clone(members)
+		}
+	}
+}
+
+// To 按别名或分组名追加收件人, 推送时由客户端地址簿解析为设备 Key
+func (o *Options) To(names ...string) *Options {
+	o.Recipients = append(o.Recipients, names...)
+	return o
+}
+
+// Resolve 将别名或分组名解析为去重后的设备 Key, 任一别名不存在时返回 ErrUnknownAlias
+func (c *Client) Resolve(names ...string) ([]string, error) {
+	keys := make([]string, 0, len(names))
+	add := func(key string) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, name := range names {
+		if members, ok := c.groups[name]; ok {
+			for _, member := range members {
+				key, err := c.resolveAlias(member)
+				if err != nil {
+					return nil, fmt.Errorf("group %s: %w", name, err)
+				}
+				add(key)
+			}
+			continue
+		}
+		key, err := c.resolveAlias(name)
+		if err != nil {
+			return nil, err
+		}
+		add(key)
+	}
+	return keys, nil
+}
+
+func (c *Client) resolveAlias(name string) (string, error) {
+	key, ok := c.aliases[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownAlias, name)
+	}
+	return key, nil
+}
+
+// Result 单个收件人的推送结果
+type Result struct {
+	// Name 收件人别名
+	Name string
+	// DeviceKey 解析后的设备 Key
+	DeviceKey string
+	// Err 推送错误, 成功时为 nil
+	Err error
+}
+
+// PushGroup 向分组内每个成员单独推送, 返回每个成员的推送结果
+// 任一成员失败时返回汇总全部失败的 *MultiError, 结果列表始终完整
+func (c *Client) PushGroup(ctx context.Context, group string, o *Options) ([]Result, error) {
+	members, ok := c.groups[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGroup, group)
+	}
+
+	results := make([]Result, 0, len(members))
+	var errs MultiError
+	for _, member := range members {
+		res := Result{Name: member}
+		res.DeviceKey, res.Err = c.resolveAlias(member)
+		if res.Err == nil {
+			single := o.Clone()
+			single.DeviceKey = res.DeviceKey
+			single.DeviceKeys = nil
+			single.Recipients = nil
+			single.DeviceEnc = deviceEncFor(o.DeviceEnc, []string{res.DeviceKey})
+			res.Err = c.Push(ctx, single)
+		}
+		errs.Add(member, res.Err)
+		results = append(results, res)
+	}
+	return results, errs.Err()
+}
+
+// resolveRecipients 返回将 Recipients 解析并合并到 DeviceKeys 后的副本
+// DeviceEnc 只保留实际收件人的加密参数, 同一份 DeviceEnc (如 Recipients.DeviceEnc) 可以用于任意分组
+func (c *Client) resolveRecipients(o *Options) (*Options, error) {
+	if len(o.Recipients) == 0 {
+		return o, nil
+	}
+	keys, err := c.Resolve(o.Recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *o
+	resolved.Recipients = nil
+	resolved.DeviceKeys = slices.Clone(o.DeviceKeys)
+	for _, key := range keys {
+		if key != o.DeviceKey && !slices.Contains(resolved.DeviceKeys, key) {
+			resolved.DeviceKeys = append(resolved.DeviceKeys, key)
+		}
+	}
+	resolved.DeviceEnc = deviceEncFor(o.DeviceEnc, resolved.routingKeys())
+	return &resolved, nil
+}
+
+// deviceEncFor 返回 deviceEnc 中属于 keys 的部分
+func deviceEncFor(deviceEnc map[string]*EncOpt, keys []string) map[string]*EncOpt {
+	if len(deviceEnc) == 0 {
+		return deviceEnc
+	}
+	filtered := make(map[string]*EncOpt, len(keys))
+	for _, key := range keys {
+		if enc, ok := deviceEnc[key]; ok {
+			filtered[key] = enc
+		}
+	}
+	return filtered
+}
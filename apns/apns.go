@@ -0,0 +1,338 @@
+// Package apns 实现基于令牌认证 (.p8 密钥) 的 Apple Push Notification service HTTP/2 客户端,
+// 并按 bark-server 的格式将推送参数转换为 APNs payload, 供内嵌服务端和直连 APNs 模式使用
+package apns
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+const (
+	// ProductionURL APNs 生产环境地址, App Store 和 TestFlight 安装的 Bark App 使用该环境
+	ProductionURL = "https://api.push.apple.com"
+	// DevelopmentURL APNs 开发环境地址
+	DevelopmentURL = "https://api.sandbox.push.apple.com"
+	// BarkTopic Bark App 的 Bundle ID, 作为 apns-topic
+	BarkTopic = "me.fin.bark"
+	// Category Bark App 注册的通知类别
+	Category = "myNotificationCategory"
+)
+
+// tokenLifetime 认证令牌的复用时间, APNs 要求令牌在 20 分钟到 1 小时之间刷新
+const tokenLifetime = 50 * time.Minute
+
+// Notification 一条 APNs 通知
+type Notification struct {
+	// DeviceToken 设备令牌 (十六进制)
+	DeviceToken string
+	// Payload JSON payload, 见 Payload
+	Payload []byte
+	// PushType apns-push-type, 默认 alert
+	PushType string
+	// Priority apns-priority, 默认 10 (立即送达)
+	Priority int
+	// Expiration 送达失败时 APNs 保留通知的截止时间, 零值表示只尝试一次
+	Expiration time.Time
+	// CollapseID 相同 CollapseID 的通知会替换旧通知
+	CollapseID string
+}
+
+// Error APNs 返回的错误
+type Error struct {
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Reason 错误原因, 如 BadDeviceToken, Unregistered, ExpiredProviderToken
+	Reason string
+	// Timestamp 设备令牌失效的时间 (仅 410 Unregistered)
+	Timestamp time.Time
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("apns error (%d): %s", e.StatusCode, e.Reason)
+}
+
+// Is 设备令牌已失效时与 bark.ErrDeviceKeyNotFound 匹配 (直连 APNs 时设备 Key 即设备令牌)
+func (e *Error) Is(target error) bool {
+	return target == bark.ErrDeviceKeyNotFound && e.Unregistered()
+}
+
+// Unregistered 设备令牌已失效 (App 被卸载或令牌错误), 不应再向该令牌推送
+func (e *Error) Unregistered() bool {
+	return e.StatusCode == http.StatusGone || e.Reason == "BadDeviceToken" || e.Reason == "Unregistered"
+}
+
+// Client APNs 客户端, 可并发使用
+type Client struct {
+	// Endpoint APNs 地址, 默认 ProductionURL
+	Endpoint string
+	// Topic apns-topic, 默认 BarkTopic
+	Topic string
+	// HTTPClient 发送请求的 HTTP 客户端, 需支持 HTTP/2 (默认的 http.Transport 通过 TLS 自动协商)
+	HTTPClient *http.Client
+
+	keyID  string
+	teamID string
+	key    *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	issued time.Time
+}
+
+// New 使用 .p8 私钥 (PEM 格式), Key ID 和 Team ID 创建客户端
+func New(p8 []byte, keyID, teamID string) (*Client, error) {
+	key, err := ParseKey(p8)
+	if err != nil {
+		return nil, err
+	}
+	if keyID == "" || teamID == "" {
+		return nil, errors.New("apns: key id and team id are required")
+	}
+	return &Client{
+		Endpoint:   ProductionURL,
+		Topic:      BarkTopic,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		keyID:      keyID,
+		teamID:     teamID,
+		key:        key,
+	}, nil
+}
+
+// NewFromFile 读取 .p8 私钥文件并创建客户端
+func NewFromFile(path, keyID, teamID string) (*Client, error) {
+	p8, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("apns: read key file: %w", err)
+	}
+	return New(p8, keyID, teamID)
+}
+
+// ParseKey 解析 PEM 格式的 .p8 私钥
+func ParseKey(p8 []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(p8)
+	if block == nil {
+		return nil, errors.New("apns: invalid key: no PEM block found")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("apns: invalid key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apns: invalid key: not an ECDSA private key")
+	}
+	return key, nil
+}
+
+// Send 发送通知, APNs 拒绝时返回 *Error
+func (c *Client) Send(ctx context.Context, n *Notification) error {
+	if n.DeviceToken == "" {
+		return errors.New("apns: device token is required")
+	}
+	token, err := c.bearer()
+	if err != nil {
+		return err
+	}
+
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = ProductionURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/3/device/"+n.DeviceToken, bytes.NewReader(n.Payload))
+	if err != nil {
+		return err
+	}
+	topic := c.Topic
+	if topic == "" {
+		topic = BarkTopic
+	}
+	pushType := n.PushType
+	if pushType == "" {
+		pushType = "alert"
+	}
+	priority := n.Priority
+	if priority == 0 {
+		priority = 10
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", topic)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", strconv.Itoa(priority))
+	if !n.Expiration.IsZero() {
+		req.Header.Set("apns-expiration", strconv.FormatInt(n.Expiration.Unix(), 10))
+	}
+	if n.CollapseID != "" {
+		req.Header.Set("apns-collapse-id", n.CollapseID)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var res struct {
+		Reason    string `json:"reason"`
+		Timestamp int64  `json:"timestamp"`
+	}
+	_ = json.Unmarshal(body, &res)
+	apnsErr := &Error{StatusCode: resp.StatusCode, Reason: res.Reason}
+	if apnsErr.Reason == "" {
+		apnsErr.Reason = strings.TrimSpace(string(body))
+	}
+	if res.Timestamp > 0 {
+		apnsErr.Timestamp = time.UnixMilli(res.Timestamp)
+	}
+	if res.Reason == "ExpiredProviderToken" {
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	return apnsErr
+}
+
+// bearer 返回缓存的认证令牌, 过期时重新签发
+func (c *Client) bearer() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Since(c.issued) < tokenLifetime {
+		return c.token, nil
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": c.keyID})
+	claims, _ := json.Marshal(map[string]interface{}{"iss": c.teamID, "iat": now.Unix()})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("apns: sign token: %w", err)
+	}
+	// JWS ES256 签名为定长的 R || S
+	size := (c.key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	fillBig(sig[:size], r)
+	fillBig(sig[size:], s)
+
+	c.token = unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+	c.issued = now
+	return c.token, nil
+}
+
+func fillBig(dst []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(dst[len(dst)-len(b):], b)
+}
+
+// levels Bark 级别到 interruption-level 的映射
+var levels = map[string]string{
+	"passive":       "passive",
+	"active":        "active",
+	"timeSensitive": "time-sensitive",
+	"critical":      "critical",
+}
+
+// Payload 按 bark-server 的格式将推送参数转换为 APNs payload
+//
+// 标题, 副标题, 正文写入 aps.alert, group 作为 thread-id, sound 未带扩展名时补充 .caf;
+// critical 级别使用 critical sound (volume 0-10 换算为 0-1). 其余参数 (url, icon, copy, level, ciphertext ...)
+// 以小写参数名作为自定义字段, 由 Bark App 的通知扩展处理. extra 为额外的自定义字段, 如 ciphertext, iv
+func Payload(o *bark.Options, extra map[string]string) ([]byte, error) {
+	alert := map[string]string{}
+	if o.Title != "" {
+		alert["title"] = o.Title
+	}
+	if o.Subtitle != "" {
+		alert["subtitle"] = o.Subtitle
+	}
+	body := o.Body
+	if body == "" {
+		body = o.Markdown
+	}
+	if body == "" && extra["ciphertext"] != "" {
+		body = "Encrypted notification"
+	}
+	alert["body"] = body
+
+	aps := map[string]interface{}{
+		"alert":           alert,
+		"category":        Category,
+		"mutable-content": 1,
+	}
+	sound := o.Sound
+	if sound == "" {
+		sound = "default"
+	} else if !strings.Contains(sound, ".") {
+		sound += ".caf"
+	}
+	if o.Level == "critical" {
+		volume := 5
+		if o.Volume != nil {
+			volume = *o.Volume
+		}
+		aps["sound"] = map[string]interface{}{"critical": 1, "name": sound, "volume": float64(volume) / 10}
+	} else {
+		aps["sound"] = sound
+	}
+	if o.Badge != nil {
+		aps["badge"] = *o.Badge
+	}
+	if o.Group != "" {
+		aps["thread-id"] = o.Group
+	}
+	if level, ok := levels[o.Level]; ok {
+		aps["interruption-level"] = level
+	}
+
+	payload := map[string]interface{}{"aps": aps}
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		switch k {
+		case "device_key", "device_keys", "title", "subtitle", "body", "badge", "sound":
+			continue
+		}
+		payload[strings.ToLower(k)] = fmt.Sprint(v)
+	}
+	for k, v := range extra {
+		if v != "" {
+			payload[strings.ToLower(k)] = v
+		}
+	}
+	return json.Marshal(payload)
+}
@@ -0,0 +1,66 @@
+package apns
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// Backend 直接发送到 APNs 的 bark.Backend, 不经过任何 bark-server
+//
+// 设备 Key (DeviceKey, DeviceKeys 及地址簿别名解析的结果) 即 Bark App 的 APNs 设备令牌:
+//
+//	c, _ := apns.NewFromFile("AuthKey.p8", "KEY_ID", "TEAM_ID")
+//	client := bark.New("", bark.WithBackend(apns.NewBackend(c)))
+//	client.Push(ctx, &bark.Options{DeviceKey: "DEVICE_TOKEN", Body: "hello"})
+//
+// 加密推送只发送 ciphertext, 由 Bark App 使用其中配置的密钥解密
+type Backend struct {
+	Client *Client
+}
+
+// NewBackend 创建 Backend
+func NewBackend(c *Client) *Backend {
+	return &Backend{Client: c}
+}
+
+// Deliver 实现 bark.Backend, 依次向每个设备令牌发送
+func (b *Backend) Deliver(ctx context.Context, o *bark.Options) error {
+	n := &Notification{}
+	if o.Enc != nil {
+		ciphertext, err := o.Ciphertext()
+		if err != nil {
+			return err
+		}
+		if n.Payload, err = Payload(&bark.Options{}, map[string]string{"ciphertext": ciphertext}); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if n.Payload, err = Payload(o, nil); err != nil {
+			return err
+		}
+		n.CollapseID = o.ID
+	}
+
+	tokens := make([]string, 0, len(o.DeviceKeys)+1)
+	for _, t := range append([]string{o.DeviceKey}, o.DeviceKeys...) {
+		if t != "" && !slices.Contains(tokens, t) {
+			tokens = append(tokens, t)
+		}
+	}
+	var errs bark.MultiError
+	for _, token := range tokens {
+		single := *n
+		single.DeviceToken = token
+		err := b.Client.Send(ctx, &single)
+		if err != nil && len(tokens) == 1 {
+			return err
+		}
+		errs.Add("device "+bark.Redact(token), err)
+	}
+	return errs.Err()
+}
+
+var _ bark.Backend = (*Backend)(nil)
@@ -0,0 +1,100 @@
+package bark
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// AuditRecord 一次推送尝试的审计记录, 在推送结束后生成, 设备 Key 已脱敏, 不包含加密参数
+type AuditRecord struct {
+	HistoryEntry
+	// Actor 发起推送的一方, 见 Actor
+	Actor string `json:"actor,omitempty"`
+	// Server 推送的 Bark 服务地址
+	Server string `json:"server,omitempty"`
+}
+
+// AuditSink 接收审计记录, 实现需可并发使用
+// 返回的错误只输出到日志, 不影响推送结果
+type AuditSink interface {
+	Audit(r AuditRecord) error
+}
+
+// AuditSinkFunc 将函数适配为 AuditSink
+type AuditSinkFunc func(r AuditRecord) error
+
+func (f AuditSinkFunc) Audit(r AuditRecord) error {
+	return f(r)
+}
+
+// WithAuditSink 为该客户端的每次推送尝试 (包括校验失败的推送) 生成一条审计记录
+func WithAuditSink(sink AuditSink) ClientOption {
+	return func(c *Client) {
+		c.audit = sink
+	}
+}
+
+// Actor 设置本次推送的发起方 (如用户名, 服务名), 记录在审计记录中
+func Actor(name string) PushOption {
+	return func(s *pushSettings) {
+		s.actor = name
+	}
+}
+
+// JSONLinesSink 以 JSON Lines 格式 (每行一条记录) 写入审计记录, 可并发使用
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink 创建写入 w 的 JSONLinesSink
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+// OpenAuditFile 以追加方式打开 (不存在时创建, 权限 0600) 审计日志文件
+func OpenAuditFile(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesSink(f), nil
+}
+
+// Audit 写入一行记录, 每条记录一次 Write 调用, 并发写入时记录之间不会交错
+func (s *JSONLinesSink) Audit(r AuditRecord) error {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, r); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// Close 关闭底层的 Writer (实现了 io.Closer 时)
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// auditRecord 生成并写入审计记录
+func (c *Client) auditRecord(ctx context.Context, e HistoryEntry) {
+	r := AuditRecord{HistoryEntry: e, Actor: settings(ctx).actor, Server: c.ServerURL}
+	r.DeviceKeys = append([]string(nil), e.DeviceKeys...)
+	r.Recipients = append([]string(nil), e.Recipients...)
+	if err := c.audit.Audit(r); err != nil {
+		c.log().Warn("bark: write audit record failed", "err", err)
+	}
+}
+
+var _ AuditSink = (*JSONLinesSink)(nil)
@@ -0,0 +1,18 @@
+package bark
+
+import "context"
+
+// Backend 推送的实际发送方式, 默认通过 HTTP 发送到 ServerURL 的 /push 接口
+//
+// 设置 Backend 后, 收件人解析, 参数校验, 外部密钥获取和按设备拆分仍由 Client 完成,
+// Deliver 收到的 o 已合并客户端默认加密设置, o.Enc 中的密钥已就绪; 例如 apns.Backend 直接发送到 APNs
+type Backend interface {
+	Deliver(ctx context.Context, o *Options) error
+}
+
+// WithBackend 设置推送的发送方式, 替代默认的 bark-server HTTP 接口
+func WithBackend(b Backend) ClientOption {
+	return func(c *Client) {
+		c.backend = b
+	}
+}
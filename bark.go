@@ -2,16 +2,19 @@ package bark
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,18 +29,150 @@ const (
 	EncModeGCM EncMode = "GCM"
 )
 
+// CipherEncoding 密文编码方式
+type CipherEncoding string
+
+const (
+	// EncodingStdBase64 标准 base64 (默认)
+	EncodingStdBase64 CipherEncoding = "base64"
+	// EncodingURLBase64 URL 安全的 base64, 避免代理篡改 + 和 /
+	EncodingURLBase64 CipherEncoding = "base64url"
+	// EncodingHex 十六进制
+	EncodingHex CipherEncoding = "hex"
+)
+
 // EncOpt 加密选项
 type EncOpt struct {
 	Mode EncMode
-	Key  string
+	// Key 原始密钥字符串
+	Key string
+	// KeyHex 十六进制编码的密钥 (如 openssl rand -hex 32 的输出)
+	KeyHex string
+	// KeyBase64 标准 base64 编码的密钥 (如 openssl rand -base64 32 的输出)
+	KeyBase64 string
+	// KeyFile 密钥文件路径, 文件内容为原始密钥
+	KeyFile string
+	// KeyProvider 外部密钥来源 (如 Vault, AWS KMS), 推送时按设备获取密钥
+	// 设置后每个设备单独加密推送
+	KeyProvider KeyProvider
 	// CBC 模式为 IV (初始化向量)
 	// GCM 模式为 Nonce (随机数)
 	Iv string
+
+	// Passphrase 口令, 设置后通过 KDF 派生 AES 密钥
+	// Key, KeyHex, KeyBase64, KeyFile, KeyProvider, Passphrase 只能设置其中一个
+	Passphrase string
+	// Salt 派生密钥使用的盐值, 使用 Passphrase 时必填
+	Salt string
+	// KDF 密钥派生算法, 默认 PBKDF2
+	KDF KDF
+	// Iterations PBKDF2 的迭代次数或 scrypt 的 N 参数, 为 0 时使用默认值
+	Iterations int
+	// KeySize 派生出的密钥长度 (16, 24, 32), 为 0 时默认 32
+	KeySize int
+
+	// Encrypter 自定义加密器, 设置后忽略 Mode 及密钥相关字段
+	Encrypter Encrypter
+
+	// Encoding 密文编码方式, 默认标准 base64
+	Encoding CipherEncoding
+	// PrefixIV 为 true 时在密文前拼接 IV/Nonce 后再编码 (仅 CBC 和 GCM)
+	PrefixIV bool
+
+	// AllowInsecureECB 显式允许使用 ECB 模式
+	// ECB 会暴露明文结构, 仅为兼容保留, 未设置时 Validate 返回错误
+	AllowInsecureECB bool
 }
 
+// Client Bark 推送客户端, 可被多个 goroutine 并发使用
+//
+// 客户端的配置在 New 返回后视为只读: ServerURL 和 HTTPClient 只应在开始推送前修改,
+// 其余配置只能通过 ClientOption 在创建时设置. 需要不同配置时使用 With 派生新的客户端
 type Client struct {
 	ServerURL  string
 	HTTPClient *http.Client
+
+	// enc 客户端默认加密设置
+	enc *EncOpt
+	// logger 日志输出, 为 nil 时使用 slog.Default()
+	logger *slog.Logger
+	// aliases 设备别名地址簿
+	aliases map[string]string
+	// groups 收件人分组, 成员为别名
+	groups map[string][]string
+	// templates 消息模板注册表, 见 PushTemplate
+	templates *Templates
+	// emoji 推送前展开 emoji 短代码, 见 WithEmojiShortcodes
+	emoji bool
+	// backend 非空时替代 HTTP 接口发送推送, 见 WithBackend
+	backend Backend
+	// gzipMin 请求体达到该长度时压缩, 0 表示不压缩, 见 WithGzip
+	gzipMin int
+	// codec 自定义 JSON 编解码, 为 nil 时使用 encoding/json, 见 WithJSONCodec
+	codec *jsonCodec
+	// dial 自定义拨号设置, 见 WithUnixSocket, WithResolver, WithDNSCache
+	dial *dialConfig
+	// validation 推送前的校验设置
+	validation validation
+	// copyCompat 复制内容的兼容设置, 见 WithCopyCompat
+	copyCompat *CopyCompat
+	// history 推送历史, 见 WithHistory
+	history *History
+	// audit 审计记录, 见 WithAuditSink
+	audit AuditSink
+	// chunking 设备较多时拆分请求的设置, 见 WithChunkSize
+	chunking chunking
+	// retry 请求失败时的重试设置, 见 WithRetry
+	retry retryPolicy
+	// useGET 以 GET 请求推送, 见 WithGET
+	useGET bool
+	// clk 时间来源, 为 nil 时使用 SystemClock, 见 WithClock
+	clk Clock
+	// state 客户端运行时状态, With 派生的客户端重新创建
+	state *clientState
+}
+
+// clientState 客户端运行时状态, 不随配置复制
+type clientState struct {
+	// ecbWarned 保证 ECB 警告只输出一次
+	ecbWarned atomic.Bool
+	// gzipRejected 服务器不支持压缩的请求体, 之后不再压缩
+	gzipRejected atomic.Bool
+	// async 通过 Channel 创建的异步队列, Close 时排空
+	async asyncQueues
+}
+
+// ClientOption 客户端配置项
+type ClientOption func(*Client)
+
+// WithLogger 设置客户端日志输出
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithEncryption 设置客户端默认加密参数, 未指定 Options.Enc 的推送都会使用该设置加密
+// 单次推送可以通过 Options.Enc 覆盖, 或设置 Options.DisableEnc 关闭加密
+// 客户端保存 enc 的副本, 之后修改 enc 不影响客户端
+func WithEncryption(enc *EncOpt) ClientOption {
+	return func(c *Client) {
+		if enc == nil {
+			c.enc = nil
+			return
+		}
+		cp := *enc
+		c.enc = &cp
+	}
+}
+
+// WithHTTPClient 设置发送请求使用的 HTTP 客户端, 默认为 10 秒超时的 http.Client
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		if hc != nil {
+			c.HTTPClient = hc
+		}
+	}
 }
 
 // Options 推送参数结构体 (保持不变)
@@ -64,6 +199,21 @@ type Options struct {
 	Delete     string   `json:"delete,omitempty"`
 
 	Enc *EncOpt `json:"-"`
+	// DeviceEnc 按设备指定加密参数, key 为设备 Key
+	// 命中的设备会单独加密并推送, 其余设备使用 Enc
+	DeviceEnc map[string]*EncOpt `json:"-"`
+	// DisableEnc 为 true 时本次推送不加密, 忽略客户端默认加密设置
+	DisableEnc bool `json:"-"`
+	// Recipients 按别名指定的收件人, 推送时解析为设备 Key, 见 Options.To
+	Recipients []string `json:"-"`
+	// Archive 是否保存到历史记录, 推送时转换为 isArchive (1 或 0); 已设置 IsArchive 时以 IsArchive 为准
+	Archive *bool `json:"-"`
+	// AutoCopyEnabled 是否自动复制, 推送时转换为 autoCopy ("1" 或 "0"); 已设置 AutoCopy 时以 AutoCopy 为准
+	// 字符串类型的 AutoCopy 字段为兼容保留, 因此布尔字段使用该名称
+	AutoCopyEnabled *bool `json:"-"`
+	// AllowCustomScheme 允许 URL 使用 http(s) 以外的 scheme (如 weixin:// 等 App 深度链接)
+	// 未设置时 Validate 要求 URL 为绝对的 http(s) 地址, 见 WithCustomURLSchemes
+	AllowCustomScheme bool `json:"-"`
 }
 
 const DefaultDomain = "api.day.app"
@@ -71,7 +221,22 @@ const DefaultURL = "https://" + DefaultDomain
 
 var DefaultClient = New(DefaultURL)
 
-func New(serverURL string) *Client {
+func New(serverURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		ServerURL: normalizeServerURL(serverURL),
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		state: new(clientState),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// normalizeServerURL 补全默认地址和协议, 去掉末尾的 /
+func normalizeServerURL(serverURL string) string {
 	if serverURL == "" {
 		serverURL = DefaultURL
 	}
@@ -79,34 +244,233 @@ func New(serverURL string) *Client {
 	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
 		serverURL = "https://" + serverURL
 	}
+	return serverURL
+}
 
-	return &Client{
-		ServerURL: serverURL,
-		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// With 返回应用了 opts 的新客户端, 原客户端不受影响, 两者可以同时使用
+// 地址簿和分组被复制, HTTPClient, 模板注册表和 Backend 与原客户端共享;
+// 运行时状态 (ECB 警告, 压缩兼容, 异步队列) 不继承, 关闭原客户端不影响派生的客户端
+func (c *Client) With(opts ...ClientOption) *Client {
+	d := *c
+	d.state = new(clientState)
+	d.aliases, d.groups = nil, nil
+	WithAliases(c.aliases)(&d)
+	WithGroups(c.groups)(&d)
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// Push 发送推送, ctx 用于控制请求及密钥获取的超时和取消
+func (c *Client) Push(ctx context.Context, o *Options) error {
+	start := c.clock().Now()
+	sent, err := c.prepareAndPush(ctx, o)
+	c.observe(ctx, o, sent, start, err)
+	return err
+}
+
+// prepareAndPush 解析收件人, 应用客户端设置后推送, 返回实际发送的参数
+func (c *Client) prepareAndPush(ctx context.Context, o *Options) (*Options, error) {
+	resolved, err := c.resolveRecipients(o)
+	if err != nil {
+		return o, err
+	}
+	o = resolved
+	if c.emoji {
+		o = o.Clone().ExpandEmoji()
+	}
+
+	if !o.DisableEnc && o.Enc == nil && c.enc != nil {
+		withEnc := *o
+		withEnc.Enc = c.enc
+		o = &withEnc
+	}
+	o = c.withCopyCompat(o.withBoolFields())
+
+	if err := c.push(ctx, o); err != nil {
+		return o, redactError(err, o)
+	}
+	return o, nil
+}
+
+// observe 记录推送结果, o 为调用方传入的参数, sent 为实际发送的参数, 见 WithHistory, WithAuditSink
+func (c *Client) observe(ctx context.Context, o, sent *Options, start time.Time, err error) {
+	if c.history == nil && c.audit == nil {
+		return
+	}
+	e := newHistoryEntry(sent, start, c.clock().Now(), err)
+	e.Recipients = append([]string(nil), o.Recipients...)
+	if c.history != nil {
+		c.history.add(e)
+	}
+	if c.audit != nil {
+		c.auditRecord(ctx, e)
+	}
+}
+
+// push 校验并发送已解析收件人的推送, 需要时按设备拆分
+func (c *Client) push(ctx context.Context, o *Options) error {
+	if !c.skipValidation(ctx) {
+		if err := o.validate(c.validation); err != nil {
+			return err
+		}
+	}
+	c.warnInsecure(o)
+
+	if !o.perDevice() {
+		if keys := o.routingKeys(); len(c.chunking.chunks(keys)) > 1 {
+			var errs MultiError
+			c.sendShared(ctx, o, keys, &errs)
+			return errs.Err()
+		}
+		return c.send(ctx, o)
+	}
+
+	// 按设备拆分: 使用独立密钥的设备单独推送, 其余设备合并推送 (设备较多时分批)
+	var (
+		errs   MultiError
+		shared []string
+	)
+	for _, key := range o.routingKeys() {
+		enc, ok := o.DeviceEnc[key]
+		if !ok {
+			enc = o.Enc
+			if enc == nil || enc.KeyProvider == nil {
+				shared = append(shared, key)
+				continue
+			}
+		}
+		single := *o
+		single.DeviceKey = key
+		single.DeviceKeys = nil
+		single.Enc = enc
+		single.DeviceEnc = nil
+		errs.Add("device "+Redact(key), c.send(ctx, &single))
+	}
+
+	if len(shared) > 0 {
+		c.sendShared(ctx, o, shared, &errs)
 	}
+
+	return errs.Err()
+}
+
+// perDevice 判断是否需要按设备分别加密推送
+func (o *Options) perDevice() bool {
+	return len(o.DeviceEnc) > 0 || (o.Enc != nil && o.Enc.KeyProvider != nil)
 }
 
-func (c *Client) Push(o *Options) error {
-	if err := o.Validate(); err != nil {
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
+// warnInsecure 使用 ECB 模式时输出一次警告
+func (c *Client) warnInsecure(o *Options) {
+	usesECB := o.Enc != nil && o.Enc.isECB()
+	for _, enc := range o.DeviceEnc {
+		usesECB = usesECB || enc.isECB()
+	}
+	if usesECB && c.state.ecbWarned.CompareAndSwap(false, true) {
+		c.log().Warn("bark: ECB encryption mode leaks plaintext structure, consider switching to GCM")
+	}
+}
+
+// send 发送单个推送请求
+func (c *Client) send(ctx context.Context, o *Options) error {
+	if o.Enc != nil && o.Enc.KeyProvider != nil {
+		enc, err := o.Enc.resolveKey(ctx, o.DeviceKey)
+		if err != nil {
+			return err
+		}
+		withKey := *o
+		withKey.Enc = enc
+		o = &withKey
+	}
+	if c.backend != nil {
+		_, err := c.withRetry(ctx, o, func() (*http.Response, error) {
+			return nil, c.backend.Deliver(ctx, o)
+		})
 		return err
 	}
+	if c.useGET {
+		return c.sendGET(ctx, o)
+	}
 
-	payload, err := c.preparePayload(o)
+	body := newPooledBody()
+	defer body.release()
+	size, err := writePayload(body.buf, o, c.codec)
 	if err != nil {
 		return err
 	}
+	if c.validation.strict && !c.skipValidation(ctx) {
+		if err := checkPayloadSize(size); err != nil {
+			return err
+		}
+	}
 
-	req, err := http.NewRequest("POST", c.ServerURL+"/push", bytes.NewReader(payload))
+	if c.gzipMin > 0 && body.buf.Len() >= c.gzipMin && !c.state.gzipRejected.Load() {
+		gz, err := gzipBody(body.buf.Bytes())
+		if err != nil {
+			return err
+		}
+		status, err := c.post(ctx, o, gz, "gzip")
+		gz.release()
+		if !gzipRefused(status) {
+			return err
+		}
+		// 服务器不支持压缩的请求体, 改为发送原始请求体, 成功后不再压缩
+		if _, err := c.post(ctx, o, body, ""); err != nil {
+			return err
+		}
+		if c.state.gzipRejected.CompareAndSwap(false, true) {
+			c.log().Warn("bark: server rejected gzip request body, sending uncompressed", "server", c.ServerURL, "status", status)
+		}
+		return nil
+	}
+	_, err = c.post(ctx, o, body, "")
+	return err
+}
+
+// post 发送请求体并解析 Bark 响应, 失败时按 WithRetry 重试, 返回 HTTP 状态码 (请求未完成时为 0)
+func (c *Client) post(ctx context.Context, o *Options, body *pooledBody, contentEncoding string) (int, error) {
+	resp, err := c.withRetry(ctx, o, func() (*http.Response, error) {
+		return c.postOnce(ctx, o, body, contentEncoding)
+	})
+	if resp == nil {
+		return 0, err
+	}
+	return resp.StatusCode, err
+}
+
+// postOnce 发送一次请求
+func (c *Client) postOnce(ctx context.Context, o *Options, body *pooledBody, contentEncoding string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.ServerURL+"/push", nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	req.Body = body.reader()
+	req.ContentLength = int64(body.buf.Len())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return body.reader(), nil
 	}
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	return c.do(ctx, req, o)
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// do 发送请求并解析 Bark 响应, 返回的响应体已读取, 替换为可重复读取的副本;
+// 失败时返回的 ResponseError 已隐藏推送 o 的敏感值
+func (c *Client) do(ctx context.Context, req *http.Request, o *Options) (*http.Response, error) {
+	resp, err := c.httpClient(ctx).Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -117,93 +481,188 @@ func (c *Client) Push(o *Options) error {
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
 
-	if err := json.Unmarshal(respBody, &res); err != nil {
-		return fmt.Errorf("status: %d, body: %s", resp.StatusCode, string(respBody))
+	if err := c.codec.decode(respBody, &res); err != nil {
+		return resp, newResponseError(resp, respBody, 0, "", o)
 	}
 
 	if res.Code != 200 {
-		return fmt.Errorf("bark error (%d): %s", res.Code, res.Message)
+		return resp, newResponseError(resp, respBody, res.Code, res.Message, o)
 	}
 
-	return nil
+	return resp, nil
+}
+
+// routingKeys 返回去重后的全部目标设备 Key, device_key 排在最后
+func (o *Options) routingKeys() []string {
+	keys := make([]string, 0, len(o.DeviceKeys)+1)
+	for _, k := range o.DeviceKeys {
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	if o.DeviceKey != "" && !slices.Contains(keys, o.DeviceKey) {
+		keys = append(keys, o.DeviceKey)
+	}
+	return keys
 }
 
 // --- 校验和 Payload 准备 ---
 
-// Validate 检查核心参数和加密参数的合法性
+// Validate 检查核心参数和加密参数的合法性, 包括 Volume 在 0-MaxVolume 之间
 func (o *Options) Validate() error {
-	if len(o.DeviceKey) == 0 && len(o.DeviceKeys) == 0 {
+	return o.validate(validation{})
+}
+
+// validate 按客户端的校验设置检查参数, 返回的错误与 ErrInvalidOptions 匹配
+func (o *Options) validate(v validation) error {
+	if err := o.checkOptions(v); err != nil {
+		return InvalidOptions(err)
+	}
+	return nil
+}
+
+func (o *Options) checkOptions(v validation) error {
+	if len(o.DeviceKey) == 0 && len(o.DeviceKeys) == 0 && len(o.Recipients) == 0 {
 		return errors.New("device_key is required")
 	}
+	if o.DeviceKey != "" {
+		if err := ValidateDeviceKey(o.DeviceKey); err != nil {
+			return err
+		}
+	}
+	for _, key := range o.DeviceKeys {
+		if err := ValidateDeviceKey(key); err != nil {
+			return err
+		}
+	}
 
 	if o.Title == "" && o.Body == "" && o.Markdown == "" {
 		return errors.New("notification content is required")
 	}
+	if err := validateLink("url", o.URL, o.AllowCustomScheme || v.customSchemes); err != nil {
+		return err
+	}
+	if err := validateLink("icon", o.Icon, false); err != nil {
+		return err
+	}
+	if o.Volume != nil && (*o.Volume < 0 || *o.Volume > MaxVolume) {
+		return fmt.Errorf("volume must be between 0 and %d, got %d", MaxVolume, *o.Volume)
+	}
+
+	if o.DisableEnc && (o.Enc != nil || len(o.DeviceEnc) > 0) {
+		return errors.New("disable_enc conflicts with Enc and DeviceEnc")
+	}
 
 	if o.Enc != nil {
-		// 密钥长度校验 (AES-128/192/256 必须是 16, 24, 32 字节)
-		keyLen := len(o.Enc.Key)
-		if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-			return errors.New("encryption key length must be 16 (AES-128), 24 (AES-192), or 32 (AES-256) bytes")
+		if err := o.Enc.validate(); err != nil {
+			return err
 		}
+	}
 
-		// 模式和 IV/Nonce 校验
-		mode := EncMode(strings.ToUpper(string(o.Enc.Mode)))
-
-		switch mode {
-		case EncModeCBC:
-			if len(o.Enc.Iv) == 0 {
-				return errors.New("CBC mode requires IV")
+	if len(o.DeviceEnc) > 0 {
+		keys := o.routingKeys()
+		for key, enc := range o.DeviceEnc {
+			if !slices.Contains(keys, key) {
+				return fmt.Errorf("device_enc contains device %s which is not a recipient", Redact(key))
 			}
-		case EncModeGCM:
-			// GCM Nonce 最好是 12 字节，但我们只在 aesEncrypt 中进行严格校验，这里只检查是否为空。
-			if len(o.Enc.Iv) == 0 {
-				return errors.New("GCM mode requires Nonce (Iv field)")
+			if enc == nil {
+				return fmt.Errorf("device_enc for device %s is nil", Redact(key))
+			}
+			if err := enc.validate(); err != nil {
+				return fmt.Errorf("device %s: %w", Redact(key), err)
 			}
-		case EncModeECB:
-			// ECB 不需要 IV/Nonce
-		default:
-			return fmt.Errorf("unsupported encryption mode: %s (supported: CBC, ECB, GCM)", o.Enc.Mode)
 		}
 	}
 
+	if v.strict {
+		return o.validateContent()
+	}
 	return nil
 }
 
-// preparePayload 处理普通 JSON 或加密 JSON
-func (c *Client) preparePayload(o *Options) ([]byte, error) {
+// validate 检查加密参数的合法性
+func (e *EncOpt) validate() error {
+	switch e.Encoding {
+	case "", EncodingStdBase64, EncodingURLBase64, EncodingHex:
+	default:
+		return fmt.Errorf("unsupported ciphertext encoding: %s (supported: base64, base64url, hex)", e.Encoding)
+	}
+
+	// 自定义加密器自行负责密钥和 IV 的管理
+	if e.Encrypter != nil {
+		return nil
+	}
+
+	if err := e.validateKey(); err != nil {
+		return err
+	}
+
+	// 模式和 IV/Nonce 校验
+	mode := EncMode(strings.ToUpper(string(e.Mode)))
+
+	switch mode {
+	case EncModeCBC:
+		if len(e.Iv) == 0 {
+			return errors.New("CBC mode requires IV")
+		}
+	case EncModeGCM:
+		// GCM Nonce 最好是 12 字节，但我们只在 aesEncrypt 中进行严格校验，这里只检查是否为空。
+		if len(e.Iv) == 0 {
+			return errors.New("GCM mode requires Nonce (Iv field)")
+		}
+	case EncModeECB:
+		// ECB 不需要 IV/Nonce, 但必须显式开启
+		if !e.AllowInsecureECB {
+			return errors.New("ECB mode is insecure and requires AllowInsecureECB; use GCM instead")
+		}
+	default:
+		return fmt.Errorf("unsupported encryption mode: %s (supported: CBC, ECB, GCM)", e.Mode)
+	}
+
+	return nil
+}
+
+func (e *EncOpt) isECB() bool {
+	return e.Encrypter == nil && EncMode(strings.ToUpper(string(e.Mode))) == EncModeECB
+}
+
+// writePayload 使用 codec 将普通 JSON 或加密 JSON 写入 buf
+// 返回送达设备的内容长度 (不含只用于路由的 device_key 和 device_keys), 用于检查 APNs payload 上限
+func writePayload(buf *bytes.Buffer, o *Options, codec *jsonCodec) (int, error) {
 	if o.Enc == nil {
 		// 不加密推送,并不会把device_keys带到每个客户端
-		return json.Marshal(o)
+		if err := codec.encode(buf, o); err != nil {
+			return 0, err
+		}
+		if o.DeviceKey == "" && len(o.DeviceKeys) == 0 {
+			return buf.Len(), nil
+		}
+		content := *o
+		content.DeviceKey = ""
+		content.DeviceKeys = nil
+		plain := getBuffer()
+		defer putBuffer(plain)
+		if err := codec.encode(plain, content); err != nil {
+			return 0, err
+		}
+		return plain.Len(), nil
 	}
 
 	// 1. 存储用于外部路由的 Keys
 	deviceKeyToUse := o.DeviceKey
 	deviceKeysToUse := o.DeviceKeys
 
-	// 2. 创建 Options 副本
-	// 把device_keys 带到每个客户端可能会泄露,所以清除Keys 和 Enc 字段
-	encOpts := *o
-	encOpts.DeviceKey = ""
-	encOpts.DeviceKeys = nil
-	encOpts.Enc = nil
-
-	// 3. 序列化仅含内容的 Options 副本 (plain text)
-	plainBytes, err := json.Marshal(encOpts)
+	// 2. 加密仅含内容的 Options 副本
+	cipherText, err := o.ciphertext(codec)
 	if err != nil {
-		return nil, err
-	}
-
-	// 4. 执行加密
-	cipherText, err := aesEncrypt(plainBytes, o.Enc)
-	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	// 5. 构建外部 Payload
+	// 3. 构建外部 Payload
 	encryptedPayload := make(map[string]interface{})
 	encryptedPayload["ciphertext"] = cipherText
 
@@ -220,34 +679,146 @@ func (c *Client) preparePayload(o *Options) ([]byte, error) {
 		} else if len(finalRoutingKeys) == 1 {
 			encryptedPayload["device_key"] = finalRoutingKeys[0]
 		} else {
-			return nil, errors.New("missing device key for routing")
+			return 0, errors.New("missing device key for routing")
 		}
 	} else {
 		encryptedPayload["device_key"] = deviceKeyToUse
 	}
 
-	return json.Marshal(encryptedPayload)
+	if err := codec.encode(buf, encryptedPayload); err != nil {
+		return 0, err
+	}
+	// 送达设备的是 {"ciphertext":"..."}
+	return len(`{"ciphertext":""}`) + len(cipherText), nil
+}
+
+// Ciphertext 按 o.Enc 加密推送内容, 返回 ciphertext 参数的值
+// 把 device_keys 带到每个客户端可能会泄露, 所以明文中不包含设备 Key 和加密参数
+func (o *Options) Ciphertext() (string, error) {
+	return o.ciphertext(nil)
+}
+
+// ciphertext 使用 codec 序列化明文后加密
+func (o *Options) ciphertext(codec *jsonCodec) (string, error) {
+	if o.Enc == nil {
+		return "", errors.New("encryption is not configured")
+	}
+	encOpts := *o
+	encOpts.DeviceKey = ""
+	encOpts.DeviceKeys = nil
+	encOpts.Enc = nil
+
+	plain := getBuffer()
+	defer putBuffer(plain)
+	if err := codec.encode(plain, encOpts); err != nil {
+		return "", err
+	}
+	return encrypt(plain.Bytes(), o.Enc)
 }
 
 // --- AES 加密实现 ---
 
+// Encrypter 加密器接口, 用于接入内置 AES 模式之外的算法 (如 ChaCha20-Poly1305)
+// Encrypt 返回原始密文字节, 由 SDK 负责编码
+type Encrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+}
+
+// EncrypterFunc 将普通函数适配为 Encrypter
+type EncrypterFunc func(plaintext []byte) ([]byte, error)
+
+func (f EncrypterFunc) Encrypt(plaintext []byte) ([]byte, error) {
+	return f(plaintext)
+}
+
+// NewAEADEncrypter 使用任意 cipher.AEAD 实现 (如 chacha20poly1305.New 的返回值) 和固定 nonce 构造加密器
+// 返回值同时实现 Decrypter
+func NewAEADEncrypter(aead cipher.AEAD, nonce []byte) Encrypter {
+	return &aeadEncrypter{aead: aead, nonce: nonce}
+}
+
+type aeadEncrypter struct {
+	aead  cipher.AEAD
+	nonce []byte
+}
+
+func (e *aeadEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(e.nonce) != e.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce length must be %d bytes", e.aead.NonceSize())
+	}
+	return e.aead.Seal(nil, e.nonce, plaintext, nil), nil
+}
+
+func (e *aeadEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(e.nonce) != e.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce length must be %d bytes", e.aead.NonceSize())
+	}
+	return e.aead.Open(nil, e.nonce, ciphertext, nil)
+}
+
+// encrypt 使用自定义加密器或内置 AES 加密, 并返回编码后的密文
+func encrypt(data []byte, opt *EncOpt) (string, error) {
+	if opt.Encrypter != nil {
+		encrypted, err := opt.Encrypter.Encrypt(data)
+		if err != nil {
+			return "", err
+		}
+		return encodeCipherText(encrypted, opt.Encoding), nil
+	}
+
+	var prefix []byte
+	if opt.PrefixIV {
+		switch EncMode(strings.ToUpper(string(opt.Mode))) {
+		case EncModeCBC, EncModeGCM:
+			// iv || ciphertext, 与多数 Bark 客户端实现的约定一致
+			prefix = []byte(opt.Iv)
+		}
+	}
+	encrypted, err := aesEncrypt(prefix, data, opt)
+	if err != nil {
+		return "", err
+	}
+	return encodeCipherText(encrypted, opt.Encoding), nil
+}
+
+// encodeCipherText 按指定方式编码密文
+func encodeCipherText(data []byte, encoding CipherEncoding) string {
+	switch encoding {
+	case EncodingURLBase64:
+		return base64.URLEncoding.EncodeToString(data)
+	case EncodingHex:
+		return hex.EncodeToString(data)
+	default:
+		return base64.StdEncoding.EncodeToString(data)
+	}
+}
+
 // pKCS7Padding 实现了 PKCS7 填充，仅用于 CBC 和 ECB
-func pKCS7Padding(ciphertext []byte, blockSize int) []byte {
-	padding := blockSize - len(ciphertext)%blockSize
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(ciphertext, padtext...)
+// 返回 prefix || data || padding, 一次分配出完整长度, 便于随后原地加密
+func pKCS7Padding(prefix, data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	out := make([]byte, len(prefix)+len(data)+padding)
+	n := copy(out, prefix)
+	n += copy(out[n:], data)
+	for i := n; i < len(out); i++ {
+		out[i] = byte(padding)
+	}
+	return out
 }
 
-// aesEncrypt 使用标准库进行 AES 加密
-func aesEncrypt(data []byte, opt *EncOpt) (string, error) {
-	key := []byte(opt.Key)
+// aesEncrypt 使用标准库进行 AES 加密, 返回 prefix || ciphertext
+// 密文直接写入按最终长度预分配的切片, 拼接 IV 前缀不需要额外复制
+func aesEncrypt(prefix, data []byte, opt *EncOpt) ([]byte, error) {
+	key, err := opt.keyBytes()
+	if err != nil {
+		return nil, err
+	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var encrypted []byte
 	blockSize := block.BlockSize()
 	mode := strings.ToUpper(string(opt.Mode))
 
@@ -255,41 +826,42 @@ func aesEncrypt(data []byte, opt *EncOpt) (string, error) {
 	case "CBC":
 		iv := []byte(opt.Iv)
 		if len(iv) != blockSize {
-			return "", fmt.Errorf("CBC IV length must be %d", blockSize)
+			return nil, fmt.Errorf("CBC IV length must be %d", blockSize)
 		}
 
-		paddedData := pKCS7Padding(data, blockSize)
-		blockMode := cipher.NewCBCEncrypter(block, iv)
-		encrypted = make([]byte, len(paddedData))
-		blockMode.CryptBlocks(encrypted, paddedData)
+		out := pKCS7Padding(prefix, data, blockSize)
+		body := out[len(prefix):]
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(body, body)
+		return out, nil
 
 	case "ECB":
-		paddedData := pKCS7Padding(data, blockSize)
-		encrypted = make([]byte, len(paddedData))
-		for i := 0; i < len(paddedData); i += blockSize {
-			block.Encrypt(encrypted[i:i+blockSize], paddedData[i:i+blockSize])
+		out := pKCS7Padding(prefix, data, blockSize)
+		body := out[len(prefix):]
+		for i := 0; i < len(body); i += blockSize {
+			block.Encrypt(body[i:i+blockSize], body[i:i+blockSize])
 		}
+		return out, nil
 
 	case "GCM":
 		// GCM 模式 (AEAD) - 不使用 PKCS7 填充
 		nonce := []byte(opt.Iv)
 		if len(nonce) != 12 {
-			return "", fmt.Errorf("GCM Nonce length must be 12 bytes")
+			return nil, fmt.Errorf("GCM Nonce length must be 12 bytes")
 		}
 
 		aesGCM, err := cipher.NewGCM(block)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		// Seal(dst, nonce, plaintext, additionalData)
 		// additionalData 传 nil, plaintext 传未填充的数据
-		encrypted = aesGCM.Seal(nil, nonce, data, nil)
+		out := make([]byte, len(prefix), len(prefix)+len(data)+aesGCM.Overhead())
+		copy(out, prefix)
+		return aesGCM.Seal(out, nonce, data, nil), nil
 
 	default:
-		return "", errors.New("unsupported encryption mode")
+		return nil, errors.New("unsupported encryption mode")
 	}
-
-	return base64.StdEncoding.EncodeToString(encrypted), nil
 }
 
 // IntPtr returns a pointer to an int.
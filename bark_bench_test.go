@@ -0,0 +1,52 @@
+package bark_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+func BenchmarkPush(b *testing.B) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	o := &bark.Options{DeviceKey: "benchkey", Title: "deploy", Body: strings.Repeat("x", 512), Group: "ci"}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.Push(ctx, o); err != nil {
+			b.Fatal(err)
+		}
+		if i%1024 == 0 {
+			srv.Reset()
+		}
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	encs := []struct {
+		name string
+		enc  *bark.EncOpt
+	}{
+		{"CBC", &bark.EncOpt{Mode: bark.EncModeCBC, Key: "1234567890123456", Iv: "abcdefghijklmnop"}},
+		{"GCM", &bark.EncOpt{Mode: bark.EncModeGCM, Key: "1234567890123456", Iv: "abcdefghijkl"}},
+		{"ECB", &bark.EncOpt{Mode: bark.EncModeECB, Key: "1234567890123456", AllowInsecureECB: true}},
+	}
+	for _, tc := range encs {
+		b.Run(tc.name, func(b *testing.B) {
+			o := &bark.Options{DeviceKey: "benchkey", Title: "deploy", Body: strings.Repeat("x", 512), Enc: tc.enc}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := o.Ciphertext(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/gaoyaxuan/go-bark/barklogrus
+
+go 1.21
+
+require (
+	github.com/gaoyaxuan/go-bark v0.0.0-00010101000000-000000000000
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gaoyaxuan/go-bark => ..
@@ -0,0 +1,159 @@
+// Package barklogrus 提供将 logrus 日志推送到 Bark 的 logrus.Hook
+//
+//	hook := barklogrus.New(client, &bark.Options{DeviceKey: "YOUR_DEVICE_KEY"}, logrus.ErrorLevel)
+//	logrus.AddHook(hook)
+package barklogrus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// Hook 将达到指定级别的 logrus 日志推送到 Bark
+//
+// 标题为日志级别, 正文为日志消息和按字母排序的字段; Fatal 和 Panic 同步推送 (logrus 随后会退出进程),
+// 其他级别在 Async 为 true 时通过异步队列推送, 不阻塞日志调用
+type Hook struct {
+	// Pusher 推送目标
+	Pusher bark.Pusher
+	// Defaults 推送的模板参数, 如设备 Key, 分组
+	Defaults *bark.Options
+	// Level 推送的最低级别 (含), 如 logrus.ErrorLevel 表示推送 Error, Fatal, Panic
+	Level logrus.Level
+	// FieldMap 日志字段到推送参数的映射, 如 {"service": "group", "trace_url": "url"};
+	// 已映射的字段不再出现在正文中
+	FieldMap map[string]string
+	// Severities 可选, 按日志级别设置 Bark 级别, 铃声等参数, 如 bark.DefaultSeverityMap;
+	// 为 nil 时 Fatal 和 Panic 使用 timeSensitive 级别
+	Severities bark.SeverityMap
+	// Limiter 推送限流, 为 nil 时不限流
+	Limiter *bark.RateLimiter
+	// Async 为 true 时异步推送, New 默认开启
+	Async bool
+
+	once  sync.Once
+	queue *bark.Queue
+}
+
+// New 创建 Hook, 默认异步推送并限制每分钟最多 10 条
+func New(p bark.Pusher, defaults *bark.Options, level logrus.Level) *Hook {
+	return &Hook{
+		Pusher:   p,
+		Defaults: defaults,
+		Level:    level,
+		Limiter:  bark.NewRateLimiter(10, time.Minute),
+		Async:    true,
+	}
+}
+
+// Levels 实现 logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= h.Level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// Fire 实现 logrus.Hook
+func (h *Hook) Fire(e *logrus.Entry) error {
+	ok, dropped := h.Limiter.Take()
+	if !ok {
+		return nil
+	}
+	o := h.Options(e)
+	if dropped > 0 {
+		o.Body += fmt.Sprintf("\n(%d earlier entries suppressed by rate limit)", dropped)
+	}
+
+	ctx := context.Background()
+	if e.Context != nil {
+		ctx = context.WithoutCancel(e.Context)
+	}
+	if h.Async && e.Level > logrus.FatalLevel {
+		h.once.Do(func() {
+			h.queue = bark.NewQueue(h.Pusher, bark.WithQueueWorkers(1))
+		})
+		// 队列已满时丢弃, 不阻塞日志调用
+		if err := h.queue.TryPush(ctx, o); err != nil {
+			return fmt.Errorf("bark: %w", err)
+		}
+		return nil
+	}
+	return h.Pusher.Push(ctx, o)
+}
+
+// Options 将日志转换为推送参数
+func (h *Hook) Options(e *logrus.Entry) *bark.Options {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	if o.Title == "" {
+		o.Title = strings.ToUpper(e.Level.String())
+	}
+	if h.Severities != nil {
+		h.Severities.Apply(o, severity(e.Level))
+	} else if o.Level == "" && e.Level <= logrus.FatalLevel {
+		o.Level = "timeSensitive"
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for _, k := range keys {
+		v := e.Data[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		if name, ok := h.FieldMap[k]; ok {
+			if o.Set(name, fmt.Sprint(v)) == nil {
+				continue
+			}
+		}
+		fmt.Fprintf(&b, "\n%s=%v", k, v)
+	}
+	o.Body = b.String()
+	return o
+}
+
+// Close 等待异步队列中的推送发送完毕, 应在程序退出前调用
+func (h *Hook) Close() {
+	h.once.Do(func() {})
+	if h.queue != nil {
+		h.queue.Close()
+	}
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// severity 将 logrus 级别转换为 bark.Severity
+func severity(l logrus.Level) bark.Severity {
+	switch l {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return bark.SeverityFatal
+	case logrus.ErrorLevel:
+		return bark.SeverityError
+	case logrus.WarnLevel:
+		return bark.SeverityWarn
+	case logrus.InfoLevel:
+		return bark.SeverityInfo
+	default:
+		return bark.SeverityDebug
+	}
+}
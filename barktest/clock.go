@@ -0,0 +1,152 @@
+package barktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// Clock 手动推进的假时钟, 实现 bark.Clock, 用于确定性地测试重试, 升级重发, 限流等与时间相关的行为
+//
+//	clk := barktest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	client := srv.Client(bark.WithClock(clk), bark.WithRetry(3, time.Second))
+//	go client.Push(ctx, o)
+//	clk.WaitForTimers(1)
+//	clk.Advance(time.Second) // 触发第一次重试
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	// changed 定时器数量变化时关闭并替换, 用于 WaitForTimers
+	changed chan struct{}
+}
+
+// NewClock 创建当前时间为 start 的假时钟, start 为零值时使用 2000-01-01 00:00:00 UTC
+func NewClock(start time.Time) *Clock {
+	if start.IsZero() {
+		start = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Clock{now: start, changed: make(chan struct{})}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *Clock) NewTimer(d time.Duration) bark.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clk: c, ch: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance 将时间推进 d, 触发到期的定时器
+func (c *Clock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set 将时间设置为 t (不早于当前时间), 触发到期的定时器
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].when.Before(c.timers[j].when) })
+	for len(c.timers) > 0 && !c.timers[0].when.After(c.now) {
+		timer := c.timers[0]
+		c.timers = c.timers[1:]
+		select {
+		case timer.ch <- timer.when:
+		default:
+		}
+	}
+	c.notify()
+}
+
+// Timers 返回等待中的定时器数量
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers 阻塞直到等待中的定时器数量不少于 n, 用于确认被测代码已经开始等待后再调用 Advance
+func (c *Clock) WaitForTimers(n int) {
+	for {
+		c.mu.Lock()
+		count, changed := len(c.timers), c.changed
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// schedule 调用方持有 c.mu
+func (c *Clock) schedule(t *fakeTimer, d time.Duration) {
+	t.when = c.now.Add(d)
+	if d <= 0 {
+		// 与 Set 相同, 上次触发的时间未被读取时丢弃本次, 不能在持有 c.mu 时阻塞
+		select {
+		case t.ch <- t.when:
+		default:
+		}
+		return
+	}
+	c.timers = append(c.timers, t)
+	c.notify()
+}
+
+// remove 调用方持有 c.mu, 返回定时器是否在等待中
+func (c *Clock) remove(t *fakeTimer) bool {
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.notify()
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+type fakeTimer struct {
+	clk  *Clock
+	when time.Time
+	ch   chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clk.mu.Lock()
+	defer t.clk.mu.Unlock()
+	return t.clk.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clk.mu.Lock()
+	defer t.clk.mu.Unlock()
+	active := t.clk.remove(t)
+	t.clk.schedule(t, d)
+	return active
+}
+
+var _ bark.Clock = (*Clock)(nil)
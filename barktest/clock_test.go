@@ -0,0 +1,136 @@
+package barktest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestClockAdvance(t *testing.T) {
+	clk := barktest.NewClock(start)
+	timer := clk.NewTimer(time.Minute)
+	after := clk.After(2 * time.Minute)
+	if n := clk.Timers(); n != 2 {
+		t.Fatalf("Timers() = %d, want 2", n)
+	}
+
+	clk.Advance(59 * time.Second)
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("timer fired before its deadline")
+	}
+	clk.Advance(time.Second)
+	if at, ok := fired(timer.C()); !ok || !at.Equal(start.Add(time.Minute)) {
+		t.Fatalf("timer = %v, %v; want fire at %v", at, ok, start.Add(time.Minute))
+	}
+	if _, ok := fired(after); ok {
+		t.Fatal("After fired early")
+	}
+	clk.Advance(time.Hour)
+	if _, ok := fired(after); !ok {
+		t.Fatal("After did not fire")
+	}
+	if got := clk.Now(); !got.Equal(start.Add(time.Hour + time.Minute)) {
+		t.Errorf("Now() = %v", got)
+	}
+	if n := clk.Timers(); n != 0 {
+		t.Errorf("Timers() = %d after all fired", n)
+	}
+}
+
+func TestClockSetDoesNotGoBack(t *testing.T) {
+	clk := barktest.NewClock(start)
+	clk.Set(start.Add(-time.Hour))
+	if got := clk.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v", got, start)
+	}
+}
+
+func TestClockZeroStart(t *testing.T) {
+	if got := barktest.NewClock(time.Time{}).Now(); got.IsZero() {
+		t.Error("zero start should use a fixed default time")
+	}
+}
+
+func TestTimerStopReset(t *testing.T) {
+	clk := barktest.NewClock(start)
+	timer := clk.NewTimer(time.Minute)
+	if !timer.Stop() {
+		t.Error("Stop on a pending timer should return true")
+	}
+	if timer.Stop() {
+		t.Error("second Stop should return false")
+	}
+	clk.Advance(time.Hour)
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("stopped timer fired")
+	}
+
+	if timer.Reset(time.Minute) {
+		t.Error("Reset on a stopped timer should return false")
+	}
+	if !timer.Reset(2 * time.Minute) {
+		t.Error("Reset on a pending timer should return true")
+	}
+	clk.Advance(time.Minute)
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("timer fired at the old deadline after Reset")
+	}
+	clk.Advance(time.Minute)
+	if _, ok := fired(timer.C()); !ok {
+		t.Fatal("timer did not fire at the new deadline")
+	}
+}
+
+// TestTimerResetUndrained Reset(0) 作用于已触发但未读取的定时器时不能阻塞时钟
+func TestTimerResetUndrained(t *testing.T) {
+	clk := barktest.NewClock(start)
+	timer := clk.NewTimer(0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		timer.Reset(0)
+		timer.Reset(-time.Second)
+		clk.Advance(time.Second)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Reset on an undrained timer blocked")
+	}
+	if _, ok := fired(timer.C()); !ok {
+		t.Fatal("timer should hold the first fire time")
+	}
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("dropped fire times should not be delivered later")
+	}
+}
+
+func TestClockWaitForTimers(t *testing.T) {
+	clk := barktest.NewClock(start)
+	got := make(chan time.Time, 1)
+	go func() {
+		got <- <-clk.After(time.Second)
+	}()
+	clk.WaitForTimers(1)
+	clk.Advance(time.Second)
+	select {
+	case at := <-got:
+		if !at.Equal(start.Add(time.Second)) {
+			t.Errorf("fired at %v", at)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timer did not fire after Advance")
+	}
+}
@@ -0,0 +1,60 @@
+package barktest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// Recorder 记录每次 Push 参数的 bark.Pusher, 用于单元测试断言, 可并发使用
+type Recorder struct {
+	// Err 不为 nil 时 Push 在记录后返回该错误
+	Err error
+
+	mu     sync.Mutex
+	pushes []*bark.Options
+}
+
+var _ bark.Pusher = (*Recorder)(nil)
+
+// Push 记录参数副本
+func (r *Recorder) Push(_ context.Context, o *bark.Options) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushes = append(r.pushes, o.Clone())
+	return r.Err
+}
+
+// Pushes 返回已记录的全部参数
+func (r *Recorder) Pushes() []*bark.Options {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*bark.Options, len(r.pushes))
+	copy(out, r.pushes)
+	return out
+}
+
+// Last 返回最后一次记录的参数, 未记录时返回 nil
+func (r *Recorder) Last() *bark.Options {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pushes) == 0 {
+		return nil
+	}
+	return r.pushes[len(r.pushes)-1]
+}
+
+// Len 返回已记录的次数
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pushes)
+}
+
+// Reset 清空记录
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushes = nil
+}
@@ -0,0 +1,278 @@
+// Package barktest 提供测试 Bark 推送的工具, 包括进程内的假服务器
+package barktest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// Server 基于 httptest 的假 Bark 服务器, 实现 /push 和 GET /:device_key/:title/:body 接口并记录收到的推送
+// 加密推送会使用 SetEncryption 配置的参数解密后再记录
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []bark.Options
+	encs     map[string]*bark.EncOpt
+	code     int
+	message  string
+	// rejectGzip 模拟不支持压缩请求体的服务器
+	rejectGzip bool
+}
+
+// NewServer 启动假服务器, 使用完毕后需要调用 Close
+func NewServer() *Server {
+	s := &Server{
+		encs: make(map[string]*bark.EncOpt),
+		code: http.StatusOK,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/push", s.handlePush)
+	mux.HandleFunc("/", s.handleGet)
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		writeResponse(w, http.StatusOK, "pong")
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Client 返回指向该服务器的客户端
+func (s *Server) Client(opts ...bark.ClientOption) *bark.Client {
+	return bark.New(s.URL, opts...)
+}
+
+// SetEncryption 设置解密参数, 对所有设备生效
+func (s *Server) SetEncryption(enc *bark.EncOpt) {
+	s.SetDeviceEncryption("", enc)
+}
+
+// SetDeviceEncryption 为指定设备设置解密参数, 优先于 SetEncryption
+func (s *Server) SetDeviceEncryption(deviceKey string, enc *bark.EncOpt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encs[deviceKey] = enc
+}
+
+// FailWith 让后续请求返回指定的错误码和消息, code 同时作为 HTTP 状态码
+func (s *Server) FailWith(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code = code
+	s.message = message
+}
+
+// Succeed 恢复为正常响应
+func (s *Server) Succeed() {
+	s.FailWith(http.StatusOK, "")
+}
+
+// RejectGzip 让服务器以 415 拒绝 Content-Encoding: gzip 的请求, 用于测试 bark.WithGzip 的回退
+// 默认接受并解压 gzip 请求体
+func (s *Server) RejectGzip(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectGzip = reject
+}
+
+// Received 返回收到的全部推送 (已解密)
+func (s *Server) Received() []bark.Options {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]bark.Options, len(s.received))
+	copy(out, s.received)
+	return out
+}
+
+// Last 返回最后收到的推送
+func (s *Server) Last() (bark.Options, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.received) == 0 {
+		return bark.Options{}, false
+	}
+	return s.received[len(s.received)-1], true
+}
+
+// Reset 清空已记录的推送
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = nil
+}
+
+// encryptedPayload 加密推送的外层结构
+type encryptedPayload struct {
+	Ciphertext string   `json:"ciphertext"`
+	Iv         string   `json:"iv"`
+	DeviceKey  string   `json:"device_key"`
+	DeviceKeys []string `json:"device_keys"`
+}
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		s.mu.Lock()
+		reject := s.rejectGzip
+		s.mu.Unlock()
+		if reject {
+			writeResponse(w, http.StatusUnsupportedMediaType, "unsupported content encoding")
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var outer encryptedPayload
+	if err := json.Unmarshal(body, &outer); err != nil {
+		writeResponse(w, http.StatusBadRequest, "request bind failed: "+err.Error())
+		return
+	}
+	o, err := s.decode(body, &outer)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.record(w, o)
+}
+
+// handleGet 处理 GET /:key, /:key/:body, /:key/:title/:body, /:key/:title/:subtitle/:body 形式的推送,
+// 其余参数和 ciphertext, iv 从查询参数读取
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, seg := range segments {
+		v, err := url.PathUnescape(seg)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		segments[i] = v
+	}
+	if segments[0] == "" || len(segments) > 4 {
+		writeResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	query := r.URL.Query()
+	outer := encryptedPayload{DeviceKey: segments[0], Ciphertext: query.Get("ciphertext"), Iv: query.Get("iv")}
+	var o bark.Options
+	if outer.Ciphertext != "" {
+		var err error
+		if o, err = s.decode(nil, &outer); err != nil {
+			writeResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		o.DeviceKey = segments[0]
+		switch len(segments) {
+		case 2:
+			o.Body = segments[1]
+		case 3:
+			o.Title, o.Body = segments[1], segments[2]
+		case 4:
+			o.Title, o.Subtitle, o.Body = segments[1], segments[2], segments[3]
+		}
+		for name := range query {
+			if err := o.Set(name, query.Get(name)); err != nil {
+				writeResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+	s.record(w, o)
+}
+
+// decode 解析推送内容, 加密推送会解密并还原设备 Key
+func (s *Server) decode(body []byte, outer *encryptedPayload) (bark.Options, error) {
+	var o bark.Options
+	if outer.Ciphertext == "" {
+		err := json.Unmarshal(body, &o)
+		return o, err
+	}
+
+	enc := s.encFor(outer.DeviceKey, outer.DeviceKeys)
+	if enc == nil {
+		return o, errors.New("barktest: received ciphertext but no encryption is configured")
+	}
+	if outer.Iv != "" {
+		withIV := *enc
+		withIV.Iv = outer.Iv
+		enc = &withIV
+	}
+
+	plain, err := bark.Decrypt(outer.Ciphertext, enc)
+	if err != nil {
+		return o, err
+	}
+	if err := json.Unmarshal(plain, &o); err != nil {
+		return o, err
+	}
+	o.DeviceKey = outer.DeviceKey
+	o.DeviceKeys = outer.DeviceKeys
+	return o, nil
+}
+
+func (s *Server) encFor(deviceKey string, deviceKeys []string) *bark.EncOpt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if enc, ok := s.encs[deviceKey]; ok && deviceKey != "" {
+		return enc
+	}
+	for _, k := range deviceKeys {
+		if enc, ok := s.encs[k]; ok {
+			return enc
+		}
+	}
+	return s.encs[""]
+}
+
+func (s *Server) record(w http.ResponseWriter, o bark.Options) {
+	s.mu.Lock()
+	code, message := s.code, s.message
+	if code == http.StatusOK {
+		s.received = append(s.received, o)
+	}
+	s.mu.Unlock()
+
+	if message == "" {
+		message = "success"
+	}
+	writeResponse(w, code, message)
+}
+
+func writeResponse(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":      code,
+		"message":   message,
+		"timestamp": time.Now().Unix(),
+	})
+}
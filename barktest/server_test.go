@@ -0,0 +1,206 @@
+package barktest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+func TestServerRecordsPushes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []bark.ClientOption
+	}{
+		{"post", nil},
+		{"gzip", []bark.ClientOption{bark.WithGzip(1)}},
+		{"get", []bark.ClientOption{bark.WithGET()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := barktest.NewServer()
+			defer srv.Close()
+			client := srv.Client(tt.opts...)
+
+			o := &bark.Options{DeviceKey: "key1", Title: "a/b", Subtitle: "sub", Body: "hello world", Group: "ops", Sound: "bell"}
+			if err := client.Push(context.Background(), o); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := srv.Last()
+			if !ok {
+				t.Fatal("no push recorded")
+			}
+			if got.DeviceKey != "key1" || got.Title != "a/b" || got.Subtitle != "sub" || got.Body != "hello world" || got.Group != "ops" || got.Sound != "bell" {
+				t.Errorf("recorded %+v", got)
+			}
+		})
+	}
+}
+
+func TestServerDecryptsPushes(t *testing.T) {
+	shared := &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab"}
+	device := &bark.EncOpt{Mode: bark.EncModeCBC, Key: "fedcba9876543210", Iv: "0123456789abcdef"}
+
+	for _, get := range []bool{false, true} {
+		srv := barktest.NewServer()
+		srv.SetEncryption(shared)
+		srv.SetDeviceEncryption("special", device)
+		var opts []bark.ClientOption
+		if get {
+			opts = append(opts, bark.WithGET())
+		}
+		client := srv.Client(opts...)
+
+		ctx := context.Background()
+		if err := client.Push(ctx, &bark.Options{DeviceKey: "plain1", Body: "shared", Enc: shared}); err != nil {
+			t.Fatalf("get=%v shared: %v", get, err)
+		}
+		if err := client.Push(ctx, &bark.Options{DeviceKey: "special", Body: "device", Enc: device}); err != nil {
+			t.Fatalf("get=%v device: %v", get, err)
+		}
+		got := srv.Received()
+		if len(got) != 2 || got[0].Body != "shared" || got[0].DeviceKey != "plain1" || got[1].Body != "device" || got[1].DeviceKey != "special" {
+			t.Errorf("get=%v received %+v", get, got)
+		}
+
+		// 使用了错误的密钥时服务器返回 400
+		err := client.Push(ctx, &bark.Options{DeviceKey: "special", Body: "wrong", Enc: shared})
+		var respErr *bark.ResponseError
+		if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("get=%v wrong key: %v", get, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestServerCiphertextWithoutEncryption(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	enc := &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab"}
+	err := srv.Client().Push(context.Background(), &bark.Options{DeviceKey: "key1", Body: "x", Enc: enc})
+	if err == nil || !strings.Contains(err.Error(), "no encryption is configured") {
+		t.Errorf("Push() = %v", err)
+	}
+	if len(srv.Received()) != 0 {
+		t.Error("undecryptable push should not be recorded")
+	}
+}
+
+func TestServerFailWith(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	srv.FailWith(http.StatusBadRequest, "failed to get device token: not found")
+	err := client.Push(ctx, &bark.Options{DeviceKey: "gone", Body: "x"})
+	if !errors.Is(err, bark.ErrDeviceKeyNotFound) {
+		t.Errorf("Push() = %v, want ErrDeviceKeyNotFound", err)
+	}
+	if len(srv.Received()) != 0 {
+		t.Error("failed push should not be recorded")
+	}
+
+	srv.Succeed()
+	if err := client.Push(ctx, &bark.Options{DeviceKey: "back", Body: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Received()) != 1 {
+		t.Error("push after Succeed should be recorded")
+	}
+
+	srv.Reset()
+	if _, ok := srv.Last(); ok || len(srv.Received()) != 0 {
+		t.Error("Reset should clear recorded pushes")
+	}
+}
+
+func TestServerRejectGzip(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	srv.RejectGzip(true)
+	client := srv.Client(bark.WithGzip(1))
+
+	// 客户端在 415 后改为不压缩重发
+	for i := 0; i < 2; i++ {
+		if err := client.Push(context.Background(), &bark.Options{DeviceKey: "key1", Body: strings.Repeat("x", 100)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(srv.Received()); n != 2 {
+		t.Errorf("received %d pushes, want 2", n)
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/ping", http.StatusOK},
+		{http.MethodGet, "/push", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/key1/body", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/", http.StatusNotFound},
+		{http.MethodGet, "/a/b/c/d/e", http.StatusNotFound},
+		{http.MethodGet, "/key1/body?badge=x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := &barktest.Recorder{}
+	if r.Last() != nil || r.Len() != 0 {
+		t.Fatal("new recorder should be empty")
+	}
+
+	o := &bark.Options{DeviceKey: "key1", Body: "first", DeviceKeys: []string{"a"}}
+	if err := r.Push(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	// 记录的是副本, 之后修改原参数不影响记录
+	o.Body = "changed"
+	o.DeviceKeys[0] = "b"
+	if got := r.Last(); got.Body != "first" || got.DeviceKeys[0] != "a" {
+		t.Errorf("recorded %+v", got)
+	}
+
+	r.Err = errors.New("down")
+	if err := r.Push(context.Background(), &bark.Options{Body: "second"}); !errors.Is(err, r.Err) {
+		t.Errorf("Push() = %v, want the configured error", err)
+	}
+	if pushes := r.Pushes(); len(pushes) != 2 || pushes[1].Body != "second" {
+		t.Errorf("Pushes() = %+v", pushes)
+	}
+
+	r.Reset()
+	if r.Len() != 0 {
+		t.Error("Reset should clear the recorder")
+	}
+}
+
+func TestNopPusher(t *testing.T) {
+	var p bark.Pusher = bark.NopPusher{}
+	if err := p.Push(context.Background(), &bark.Options{}); err != nil {
+		t.Errorf("NopPusher.Push() = %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package barkzap 提供将 zap 日志推送到 Bark 的 zapcore.Core
+//
+//	core := barkzap.NewCore(client, &bark.Options{DeviceKey: "YOUR_DEVICE_KEY"}, zapcore.ErrorLevel)
+//	logger = logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+//		return zapcore.NewTee(c, core)
+//	}))
+package barkzap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/md"
+)
+
+// Core 将达到指定级别的 zap 日志以 Markdown 推送到 Bark 的 zapcore.Core
+//
+// 标题为级别 (和 logger 名称), 正文为日志消息和结构化字段; DPanic 及以上级别同步推送
+// (zap 随后可能退出进程), 其他级别通过异步队列推送, 队列已满时丢弃
+type Core struct {
+	zapcore.LevelEnabler
+	shared *shared
+	fields []zapcore.Field
+}
+
+// shared 由 With 派生的 Core 共享的状态
+type shared struct {
+	p       bark.Pusher
+	o       *bark.Options
+	limiter *bark.RateLimiter
+	sev     bark.SeverityMap
+	queue   *bark.Queue
+	once    sync.Once
+}
+
+// Option Core 配置项
+type Option func(*shared)
+
+// WithLimiter 设置推送限流, 默认每分钟最多 10 条, 传入 nil 不限流
+func WithLimiter(l *bark.RateLimiter) Option {
+	return func(s *shared) {
+		s.limiter = l
+	}
+}
+
+// WithSeverities 按日志级别设置 Bark 级别, 铃声等参数, 如 bark.DefaultSeverityMap;
+// 未设置时 DPanic 及以上级别使用 timeSensitive 级别
+func WithSeverities(m bark.SeverityMap) Option {
+	return func(s *shared) {
+		s.sev = m
+	}
+}
+
+// NewCore 创建 Core, o 为推送的模板参数 (设备 Key, 分组等), level 为推送的最低级别
+func NewCore(p bark.Pusher, o *bark.Options, level zapcore.LevelEnabler, opts ...Option) *Core {
+	if o == nil {
+		o = &bark.Options{}
+	}
+	s := &shared{p: p, o: o.Clone(), limiter: bark.NewRateLimiter(10, time.Minute)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return &Core{LevelEnabler: level, shared: s}
+}
+
+// With 实现 zapcore.Core
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	c2 := *c
+	c2.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &c2
+}
+
+// Check 实现 zapcore.Core
+func (c *Core) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+// Write 实现 zapcore.Core
+func (c *Core) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	ok, dropped := c.shared.limiter.Take()
+	if !ok {
+		return nil
+	}
+	o := c.Options(e, fields)
+	if dropped > 0 {
+		o.Markdown += fmt.Sprintf("\n\n_%d earlier entries suppressed by rate limit_", dropped)
+	}
+
+	if e.Level >= zapcore.DPanicLevel {
+		return c.shared.p.Push(context.Background(), o)
+	}
+	c.shared.once.Do(func() {
+		c.shared.queue = bark.NewQueue(c.shared.p, bark.WithQueueWorkers(1))
+	})
+	if err := c.shared.queue.TryPush(context.Background(), o); err != nil {
+		return fmt.Errorf("bark: %w", err)
+	}
+	return nil
+}
+
+// Options 将日志转换为推送参数
+func (c *Core) Options(e zapcore.Entry, fields []zapcore.Field) *bark.Options {
+	o := c.shared.o.Clone()
+	if o.Title == "" {
+		o.Title = e.Level.CapitalString()
+		if e.LoggerName != "" {
+			o.Title += " " + e.LoggerName
+		}
+	}
+	if c.shared.sev != nil {
+		c.shared.sev.Apply(o, severity(e.Level))
+	} else if o.Level == "" && e.Level >= zapcore.DPanicLevel {
+		o.Level = "timeSensitive"
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var b strings.Builder
+	b.WriteString(md.Bold(md.Escape(e.Message)) + "\n")
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		b.WriteString("\n")
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&b, "- %s: %s\n", md.Bold(md.Escape(k)), md.Code(fmt.Sprint(enc.Fields[k])))
+	}
+	if e.Caller.Defined {
+		fmt.Fprintf(&b, "\n%s", md.Escape(e.Caller.TrimmedPath()))
+	}
+	if e.Stack != "" {
+		fmt.Fprintf(&b, "\n%s", md.CodeBlock("", e.Stack))
+	}
+	o.Markdown = strings.TrimSpace(b.String())
+	return o
+}
+
+// Sync 实现 zapcore.Core, 推送由队列异步完成, 需要等待时调用 Close
+func (c *Core) Sync() error {
+	return nil
+}
+
+// Close 等待异步队列中的推送发送完毕, 应在程序退出前调用
+func (c *Core) Close() {
+	c.shared.once.Do(func() {})
+	if c.shared.queue != nil {
+		c.shared.queue.Close()
+	}
+}
+
+var _ zapcore.Core = (*Core)(nil)
+
+// severity 将 zap 级别转换为 bark.Severity
+func severity(l zapcore.Level) bark.Severity {
+	switch {
+	case l >= zapcore.DPanicLevel:
+		return bark.SeverityFatal
+	case l == zapcore.ErrorLevel:
+		return bark.SeverityError
+	case l == zapcore.WarnLevel:
+		return bark.SeverityWarn
+	case l == zapcore.InfoLevel:
+		return bark.SeverityInfo
+	default:
+		return bark.SeverityDebug
+	}
+}
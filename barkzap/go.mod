@@ -0,0 +1,17 @@
+module github.com/gaoyaxuan/go-bark/barkzap
+
+go 1.21
+
+require (
+	github.com/gaoyaxuan/go-bark v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gaoyaxuan/go-bark => ..
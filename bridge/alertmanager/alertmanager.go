@@ -0,0 +1,245 @@
+// Package alertmanager 实现 Prometheus Alertmanager webhook 接收端, 将告警转换为 Bark 推送
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Message Alertmanager webhook 请求体 (version 4)
+type Message struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert 单条告警
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Resolved 告警是否已恢复
+func (a Alert) Resolved() bool {
+	return a.Status == "resolved"
+}
+
+// Severity 告警级别对应的 Bark 参数
+type Severity struct {
+	Level string
+	Sound string
+}
+
+// DefaultSeverities 默认的告警级别映射
+var DefaultSeverities = map[string]Severity{
+	"critical": {Level: "critical", Sound: "alarm"},
+	"error":    {Level: "timeSensitive", Sound: "alarm"},
+	"warning":  {Level: "timeSensitive"},
+	"info":     {Level: "active"},
+}
+
+// TemplateData 自定义模板的渲染数据
+type TemplateData struct {
+	Alert
+	Message *Message
+}
+
+// Handler Alertmanager webhook 接收端
+//
+// 每条告警按 fingerprint 单独推送, 并将 fingerprint 作为通知 ID,
+// 因此恢复通知会替换 Bark App 中对应的告警通知
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Severities 告警级别到 Bark 参数的映射, 默认 DefaultSeverities
+	Severities map[string]Severity
+	// SeverityLabel 表示告警级别的 label, 默认 severity
+	SeverityLabel string
+	// ResolvedLevel 恢复通知使用的级别, 默认 passive
+	ResolvedLevel string
+	// SkipResolved 为 true 时不推送恢复通知
+	SkipResolved bool
+	// Template 可选, 以推送参数命名的子模板 (title, body ...) 覆盖默认内容, 数据为 TemplateData
+	Template *template.Template
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	body, err := bridge.ReadBody(r, 0)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		bridge.Respond(w, http.StatusBadRequest, "invalid alertmanager payload: "+err.Error())
+		return
+	}
+
+	if err := h.Handle(r.Context(), &msg); err != nil {
+		bridge.Respond(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+	bridge.Respond(w, http.StatusOK, "success")
+}
+
+// Handle 推送消息中的全部告警
+func (h *Handler) Handle(ctx context.Context, msg *Message) error {
+	var errs bark.MultiError
+	for _, alert := range dedupe(msg.Alerts) {
+		if alert.Resolved() && h.SkipResolved {
+			continue
+		}
+		o, err := h.Options(msg, alert)
+		if err != nil {
+			errs.Add("", err)
+			continue
+		}
+		errs.Add("alert "+alert.Fingerprint, h.Pusher.Push(ctx, o))
+	}
+	return errs.Err()
+}
+
+// Options 将单条告警转换为推送参数
+func (h *Handler) Options(msg *Message, alert Alert) (*bark.Options, error) {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+
+	name := alert.Labels["alertname"]
+	if name == "" {
+		name = "alert"
+	}
+	state := "FIRING"
+	if alert.Resolved() {
+		state = "RESOLVED"
+	}
+
+	o.Title = fmt.Sprintf("[%s] %s", state, name)
+	o.Subtitle = bridge.FirstNonEmpty(alert.Labels["instance"], alert.Labels["job"])
+	o.Body = alertBody(alert)
+	if o.Group == "" {
+		o.Group = name
+	}
+	if alert.GeneratorURL != "" {
+		o.URL = alert.GeneratorURL
+	}
+	if alert.Fingerprint != "" {
+		o.ID = alert.Fingerprint
+	}
+
+	if alert.Resolved() {
+		o.Level = h.ResolvedLevel
+		if o.Level == "" {
+			o.Level = "passive"
+		}
+	} else if sev, ok := h.severities()[strings.ToLower(alert.Labels[h.severityLabel()])]; ok {
+		o.Level = sev.Level
+		if sev.Sound != "" {
+			o.Sound = sev.Sound
+		}
+	}
+
+	if _, err := bridge.Render(h.Template, TemplateData{Alert: alert, Message: msg}, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (h *Handler) severities() map[string]Severity {
+	if h.Severities != nil {
+		return h.Severities
+	}
+	return DefaultSeverities
+}
+
+func (h *Handler) severityLabel() string {
+	if h.SeverityLabel != "" {
+		return h.SeverityLabel
+	}
+	return "severity"
+}
+
+// dedupe 同一 fingerprint 只保留最后一条
+func dedupe(alerts []Alert) []Alert {
+	index := make(map[string]int, len(alerts))
+	out := make([]Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if a.Fingerprint != "" {
+			if i, ok := index[a.Fingerprint]; ok {
+				out[i] = a
+				continue
+			}
+			index[a.Fingerprint] = len(out)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func alertBody(a Alert) string {
+	var b strings.Builder
+	if s := a.Annotations["summary"]; s != "" {
+		b.WriteString(s)
+		b.WriteString("\n")
+	}
+	if d := a.Annotations["description"]; d != "" {
+		b.WriteString(d)
+		b.WriteString("\n")
+	}
+
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		if k != "alertname" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, a.Labels[k])
+	}
+
+	if a.Resolved() && !a.EndsAt.IsZero() && !a.StartsAt.IsZero() {
+		fmt.Fprintf(&b, "duration: %s", a.EndsAt.Sub(a.StartsAt).Round(time.Second))
+	} else if !a.StartsAt.IsZero() {
+		fmt.Fprintf(&b, "since: %s", a.StartsAt.Format(time.RFC3339))
+	}
+	return strings.TrimSpace(b.String())
+}
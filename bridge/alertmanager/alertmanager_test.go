@@ -0,0 +1,151 @@
+package alertmanager_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/alertmanager"
+)
+
+func TestOptions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		handler *alertmanager.Handler
+		alert   alertmanager.Alert
+		want    bark.Options
+	}{
+		{
+			name:    "firing critical",
+			handler: alertmanager.New(nil, &bark.Options{DeviceKey: "key"}),
+			alert: alertmanager.Alert{
+				Status:       "firing",
+				Labels:       map[string]string{"alertname": "HighCPU", "severity": "Critical", "instance": "web1:9100", "job": "node"},
+				Annotations:  map[string]string{"summary": "CPU is high", "description": "above 90%"},
+				StartsAt:     start,
+				GeneratorURL: "http://prometheus/graph",
+				Fingerprint:  "abc",
+			},
+			want: bark.Options{
+				DeviceKey: "key", Title: "[FIRING] HighCPU", Subtitle: "web1:9100", Group: "HighCPU",
+				Body:  "CPU is high\nabove 90%\ninstance=web1:9100\njob=node\nseverity=Critical\nsince: 2024-01-01T10:00:00Z",
+				Level: "critical", Sound: "alarm", URL: "http://prometheus/graph", ID: "abc",
+			},
+		},
+		{
+			name:    "resolved",
+			handler: alertmanager.New(nil, nil),
+			alert: alertmanager.Alert{
+				Status:   "resolved",
+				Labels:   map[string]string{"alertname": "HighCPU", "severity": "critical", "job": "node"},
+				StartsAt: start,
+				EndsAt:   start.Add(90 * time.Second),
+			},
+			want: bark.Options{Title: "[RESOLVED] HighCPU", Subtitle: "node", Group: "HighCPU", Body: "job=node\nseverity=critical\nduration: 1m30s", Level: "passive"},
+		},
+		{
+			name:    "custom severity label and unknown severity",
+			handler: &alertmanager.Handler{SeverityLabel: "priority"},
+			alert:   alertmanager.Alert{Labels: map[string]string{"priority": "p5"}},
+			want:    bark.Options{Title: "[FIRING] alert", Group: "alert", Body: "priority=p5"},
+		},
+		{
+			name: "template",
+			handler: &alertmanager.Handler{Template: template.Must(template.New("").Parse(
+				`{{define "title"}}{{.Labels.alertname}} on {{.Message.Receiver}}{{end}}`))},
+			alert: alertmanager.Alert{Labels: map[string]string{"alertname": "Down"}},
+			want:  bark.Options{Title: "Down on ops", Group: "Down"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := tt.handler.Options(&alertmanager.Message{Receiver: "ops"}, tt.alert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Subtitle != tt.want.Subtitle || o.Group != tt.want.Group ||
+				o.Level != tt.want.Level || o.Sound != tt.want.Sound || o.URL != tt.want.URL || o.ID != tt.want.ID {
+				t.Errorf("Options() = %+v\nwant %+v", o, &tt.want)
+			}
+			if tt.want.Body != "" && o.Body != tt.want.Body {
+				t.Errorf("body = %q\nwant %q", o.Body, tt.want.Body)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	msg := &alertmanager.Message{Alerts: []alertmanager.Alert{
+		{Status: "firing", Labels: map[string]string{"alertname": "A"}, Fingerprint: "1"},
+		{Status: "resolved", Labels: map[string]string{"alertname": "B"}, Fingerprint: "2"},
+		// 同一 fingerprint 只推送最后一条
+		{Status: "resolved", Labels: map[string]string{"alertname": "A"}, Fingerprint: "1"},
+		{Status: "firing", Labels: map[string]string{"alertname": "C"}},
+	}}
+	tests := []struct {
+		skipResolved bool
+		want         []string
+	}{
+		{false, []string{"[RESOLVED] A", "[RESOLVED] B", "[FIRING] C"}},
+		{true, []string{"[FIRING] C"}},
+	}
+	for _, tt := range tests {
+		r := &barktest.Recorder{}
+		h := alertmanager.New(r, nil)
+		h.SkipResolved = tt.skipResolved
+		if err := h.Handle(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, o := range r.Pushes() {
+			got = append(got, o.Title)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("SkipResolved=%v pushed %v, want %v", tt.skipResolved, got, tt.want)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	const payload = `{"version":"4","status":"firing","receiver":"ops","alerts":[{"status":"firing","labels":{"alertname":"Down","severity":"warning"},"fingerprint":"f1"}]}`
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		err    error
+		code   int
+		pushes int
+	}{
+		{"ok", http.MethodPost, "/?token=t", payload, nil, http.StatusOK, 1},
+		{"wrong method", http.MethodGet, "/?token=t", "", nil, http.StatusMethodNotAllowed, 0},
+		{"bad token", http.MethodPost, "/?token=x", payload, nil, http.StatusUnauthorized, 0},
+		{"invalid payload", http.MethodPost, "/?token=t", `{"alerts":1}`, nil, http.StatusBadRequest, 0},
+		{"push failure", http.MethodPost, "/?token=t", payload, context.DeadlineExceeded, http.StatusBadGateway, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{Err: tt.err}
+			h := alertmanager.New(r, &bark.Options{DeviceKey: "key"})
+			h.Token = "t"
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if r.Len() != tt.pushes {
+				t.Errorf("pushes = %d, want %d", r.Len(), tt.pushes)
+			}
+			if tt.pushes > 0 && r.Last().Level != "timeSensitive" {
+				t.Errorf("pushed %+v", r.Last())
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Package bridge 包含各类消息来源到 Bark 推送的桥接实现的公共工具,
+// 具体实现位于子包中 (webhook, alertmanager 等)
+package bridge
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// DefaultMaxBodyBytes 默认请求体大小上限
+const DefaultMaxBodyBytes = 1 << 20
+
+// ErrMethodNotAllowed 非 POST 请求
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// Respond 以 bark-server 相同的格式返回 JSON 响应
+func Respond(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":      code,
+		"message":   message,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// ReadBody 读取请求体, 超过 limit 字节时返回错误; limit <= 0 时使用 DefaultMaxBodyBytes
+func ReadBody(r *http.Request, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("request body exceeds %d bytes", limit)
+	}
+	return body, nil
+}
+
+// FirstNonEmpty 返回第一个非空的值, 都为空时返回空字符串, 用于在多个可选字段间取值
+func FirstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// CheckToken 校验请求中的令牌, 支持 Authorization: Bearer <token> 或 ?token=<token>
+// token 为空时不校验
+func CheckToken(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	got := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		got = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// Render 执行 tmpl 中以推送参数命名的子模板 (如 title, body, group, url), 并写入 o
+// 渲染结果会去掉首尾空白, 空结果不会覆盖 o 中已有的值; 返回是否定义了任何参数模板
+func Render(tmpl *template.Template, data interface{}, o *bark.Options) (bool, error) {
+	return bark.RenderOptions(tmpl, data, o)
+}
+
+// MessageData 消息队列类桥接 (mqtt, nats, redis, kafka 等) 的模板数据
+type MessageData struct {
+	// Topic 主题, subject, 频道或 stream 名称
+	Topic string
+	// Key 消息键, 没有时为空
+	Key string
+	// Headers 消息头, 没有时为空
+	Headers map[string]string
+	// Payload 原始消息文本
+	Payload string
+	// JSON 消息为合法 JSON 时的解码结果, 否则为 nil
+	JSON interface{}
+}
+
+// NewMessageData 创建 MessageData, payload 为合法 JSON 时同时解码
+func NewMessageData(topic string, payload []byte) MessageData {
+	data := MessageData{Topic: topic, Payload: strings.TrimSpace(string(payload))}
+	var decoded interface{}
+	if json.Unmarshal(payload, &decoded) == nil {
+		data.JSON = decoded
+	}
+	return data
+}
+
+// MessageOptions 将一条消息转换为推送参数
+//
+// tmpl 定义了参数模板时按模板渲染; 否则 JSON 对象消息中与推送参数同名的字段 (title, body, level, url ...)
+// 直接作为参数, 其他消息以主题为标题, 消息内容为正文. 主题同时作为默认分组
+func MessageOptions(defaults *bark.Options, tmpl *template.Template, data MessageData) (*bark.Options, error) {
+	o := &bark.Options{}
+	if defaults != nil {
+		o = defaults.Clone()
+	}
+	if o.Group == "" {
+		o.Group = data.Topic
+	}
+
+	defined, err := Render(tmpl, data, o)
+	if err != nil {
+		return nil, err
+	}
+	if defined {
+		return o, nil
+	}
+	if obj, ok := data.JSON.(map[string]interface{}); ok && ApplyFields(o, obj) {
+		return o, nil
+	}
+	o.Title = data.Topic
+	o.Body = data.Payload
+	return o, nil
+}
+
+// ApplyFields 将 JSON 对象中与推送参数同名的字段写入 o, 返回是否包含标题或正文
+// 设备 Key 只能通过默认参数配置, 对象中的 device_key, device_keys 会被忽略
+func ApplyFields(o *bark.Options, obj map[string]interface{}) bool {
+	for key, v := range obj {
+		if k := strings.ToLower(key); k == "device_key" || k == "device_keys" {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			_ = o.Set(key, v)
+		case float64, bool:
+			_ = o.Set(key, fmt.Sprint(v))
+		}
+	}
+	return o.Title != "" || o.Body != "" || o.Markdown != ""
+}
+
+// Push 推送并将结果写入响应, 参数由 Pusher 按其配置校验
+func Push(w http.ResponseWriter, r *http.Request, p bark.Pusher, o *bark.Options) {
+	if err := p.Push(r.Context(), o); err != nil {
+		Respond(w, PushStatus(err), err.Error())
+		return
+	}
+	Respond(w, http.StatusOK, "success")
+}
+
+// PushStatus 推送失败时的响应状态码: 参数未通过校验 (bark.ErrInvalidOptions) 为 400, 其余为 502
+func PushStatus(err error) int {
+	if errors.Is(err, bark.ErrInvalidOptions) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,179 @@
+package bridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+func TestReadBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		limit   int64
+		wantErr bool
+	}{
+		{"hello", 5, false},
+		{"hello!", 5, true},
+		{"", 5, false},
+		{strings.Repeat("x", bridge.DefaultMaxBodyBytes), 0, false},
+		{strings.Repeat("x", bridge.DefaultMaxBodyBytes+1), 0, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		body, err := bridge.ReadBody(r, tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadBody(%d bytes, limit %d) error = %v", len(tt.body), tt.limit, err)
+		}
+		if err == nil && string(body) != tt.body {
+			t.Errorf("ReadBody() = %d bytes, want %d", len(body), len(tt.body))
+		}
+	}
+}
+
+func TestFirstNonEmpty(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"", ""}, ""},
+		{[]string{"", "b", "c"}, "b"},
+		{[]string{"a", "b"}, "a"},
+	}
+	for _, tt := range tests {
+		if got := bridge.FirstNonEmpty(tt.values...); got != tt.want {
+			t.Errorf("FirstNonEmpty(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		target string
+		auth   string
+		want   bool
+	}{
+		{"no token configured", "", "/", "", true},
+		{"bearer", "s3cret", "/", "Bearer s3cret", true},
+		{"query", "s3cret", "/?token=s3cret", "", true},
+		{"wrong bearer", "s3cret", "/", "Bearer nope", false},
+		{"bearer takes precedence over query", "s3cret", "/?token=s3cret", "Bearer nope", false},
+		{"basic is not accepted", "s3cret", "/", "Basic s3cret", false},
+		{"missing", "s3cret", "/", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			if got := bridge.CheckToken(r, tt.token); got != tt.want {
+				t.Errorf("CheckToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageOptions(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`{{define "title"}}[{{.Topic}}]{{end}}{{define "body"}}{{.JSON.msg}}{{end}}`))
+	tests := []struct {
+		name     string
+		defaults *bark.Options
+		tmpl     *template.Template
+		payload  string
+		want     bark.Options
+	}{
+		{
+			name:    "plain text",
+			payload: "  disk full \n",
+			want:    bark.Options{Title: "alerts", Body: "disk full", Group: "alerts"},
+		},
+		{
+			name:     "json fields",
+			defaults: &bark.Options{DeviceKey: "key", Group: "ops"},
+			payload:  `{"title":"t","body":"b","level":"critical","volume":5,"device_key":"evil"}`,
+			want:     bark.Options{DeviceKey: "key", Title: "t", Body: "b", Group: "ops", Level: "critical", Volume: bark.IntPtr(5)},
+		},
+		{
+			name:    "json without title or body",
+			payload: `{"other":1}`,
+			want:    bark.Options{Title: "alerts", Body: `{"other":1}`, Group: "alerts"},
+		},
+		{
+			name:    "template",
+			tmpl:    tmpl,
+			payload: `{"msg":"from template","title":"ignored"}`,
+			want:    bark.Options{Title: "[alerts]", Body: "from template", Group: "alerts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := bridge.MessageOptions(tt.defaults, tt.tmpl, bridge.NewMessageData("alerts", []byte(tt.payload)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			volume := func(o *bark.Options) string {
+				if o.Volume == nil {
+					return "nil"
+				}
+				return fmt.Sprint(*o.Volume)
+			}
+			if o.Title != tt.want.Title || o.Body != tt.want.Body || o.Group != tt.want.Group ||
+				o.Level != tt.want.Level || o.DeviceKey != tt.want.DeviceKey || volume(o) != volume(&tt.want) {
+				t.Errorf("MessageOptions() = %+v, want %+v", o, &tt.want)
+			}
+		})
+	}
+
+	// 默认参数不被修改
+	defaults := &bark.Options{DeviceKey: "key"}
+	if _, err := bridge.MessageOptions(defaults, nil, bridge.NewMessageData("t", []byte("x"))); err != nil {
+		t.Fatal(err)
+	}
+	if defaults.Title != "" || defaults.Group != "" {
+		t.Errorf("defaults modified: %+v", defaults)
+	}
+}
+
+func TestPush(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"ok", nil, http.StatusOK},
+		{"invalid options", fmt.Errorf("%w: missing body", bark.ErrInvalidOptions), http.StatusBadRequest},
+		{"upstream failure", errors.New("connection refused"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := bark.PusherFunc(func(_ context.Context, _ *bark.Options) error { return tt.err })
+			w := httptest.NewRecorder()
+			bridge.Push(w, httptest.NewRequest(http.MethodPost, "/", nil), p, &bark.Options{})
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			var resp struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != tt.want {
+				t.Errorf("response %s: %v", w.Body, err)
+			}
+			if tt.err != nil && resp.Message != tt.err.Error() {
+				t.Errorf("message = %q, want %q", resp.Message, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Package discord 实现兼容 Discord webhook 的接收端, 让支持 "Discord webhook" 的工具无需修改即可推送到 Bark
+package discord
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Discord 消息类型, 定义和转换规则见 bark.DiscordPayload
+type (
+	Payload    = bark.DiscordPayload
+	Embed      = bark.DiscordEmbed
+	EmbedField = bark.DiscordEmbedField
+)
+
+// Handler Discord webhook 兼容接收端, 成功时与 Discord 一样返回 204 No Content
+//
+// 请求体可以是 JSON, 或包含 payload_json 字段的 multipart 表单
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Token 非空时要求请求携带 Authorization: Bearer <Token>, ?token=<Token>,
+	// 或以 Token 作为路径的最后一段 (与 Discord 的 /api/webhooks/<id>/<token> 格式一致)
+	Token string
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respond(w, http.StatusMethodNotAllowed, "405: Method Not Allowed")
+		return
+	}
+	if !h.checkToken(r) {
+		respond(w, http.StatusUnauthorized, "Invalid Webhook Token")
+		return
+	}
+
+	var body []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		limit := h.MaxBodyBytes
+		if limit <= 0 {
+			limit = bridge.DefaultMaxBodyBytes
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		if err := r.ParseMultipartForm(limit); err != nil {
+			respond(w, http.StatusBadRequest, "Invalid Form Body")
+			return
+		}
+		body = []byte(r.FormValue("payload_json"))
+	} else {
+		var err error
+		if body, err = bridge.ReadBody(r, h.MaxBodyBytes); err != nil {
+			respond(w, http.StatusRequestEntityTooLarge, "Request entity too large")
+			return
+		}
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		respond(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	p.Apply(o)
+	if o.Title == "" && o.Markdown == "" {
+		respond(w, http.StatusBadRequest, "Cannot send an empty message")
+		return
+	}
+	if err := h.Pusher.Push(r.Context(), o); err != nil {
+		respond(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) checkToken(r *http.Request) bool {
+	if bridge.CheckToken(r, h.Token) {
+		return true
+	}
+	last := path.Base(r.URL.Path)
+	return subtle.ConstantTimeCompare([]byte(last), []byte(h.Token)) == 1
+}
+
+// respond 以 Discord 的错误格式返回
+func respond(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": message, "code": 0})
+}
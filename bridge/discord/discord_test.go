@@ -0,0 +1,93 @@
+package discord_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/discord"
+)
+
+func multipartBody(t *testing.T, payload string) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.WriteField("payload_json", payload); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := mw.CreateFormFile("files[0]", "log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write([]byte("attachment"))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), mw.FormDataContentType()
+}
+
+func TestHandler(t *testing.T) {
+	form, formType := multipartBody(t, `{"content":"from form"}`)
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		code        int
+		want        *bark.Options
+	}{
+		{
+			name: "content", method: http.MethodPost, target: "/api/webhooks/1/t",
+			body: `{"content":"deploy done <:rocket:123> see https://ci/1","username":"ci","avatar_url":"https://ci/a.png"}`,
+			code: http.StatusNoContent,
+			want: &bark.Options{Markdown: "deploy done 🚀 see https://ci/1", Subtitle: "ci", Icon: "https://ci/a.png", URL: "https://ci/1"},
+		},
+		{
+			name: "embeds", method: http.MethodPost, target: "/?token=t",
+			body: `{"embeds":[{"title":"Build failed","url":"https://ci/2","description":"job test","fields":[{"name":"branch","value":"main"}],"footer":{"text":"ci bot"}},{"title":"Second"}]}`,
+			code: http.StatusNoContent,
+			want: &bark.Options{Title: "Build failed", Markdown: "job test\n\n**branch**: main\n\nci bot\n\n**Second**", URL: "https://ci/2"},
+		},
+		{
+			name: "multipart", method: http.MethodPost, target: "/?token=t", contentType: formType, body: form,
+			code: http.StatusNoContent, want: &bark.Options{Markdown: "from form"},
+		},
+		{name: "wrong method", method: http.MethodGet, target: "/?token=t", code: http.StatusMethodNotAllowed},
+		{name: "bad token", method: http.MethodPost, target: "/api/webhooks/1/x", body: `{"content":"x"}`, code: http.StatusUnauthorized},
+		{name: "invalid json", method: http.MethodPost, target: "/?token=t", body: `{`, code: http.StatusBadRequest},
+		{name: "empty message", method: http.MethodPost, target: "/?token=t", body: `{"username":"ci"}`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := discord.New(r, &bark.Options{DeviceKey: "key"})
+			h.Token = "t"
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.want == nil {
+				if r.Len() != 0 {
+					t.Errorf("unexpected push %+v", r.Last())
+				}
+				return
+			}
+			got := r.Last()
+			if got == nil || got.DeviceKey != "key" || got.Title != tt.want.Title || got.Markdown != tt.want.Markdown ||
+				got.Subtitle != tt.want.Subtitle || got.Icon != tt.want.Icon || got.URL != tt.want.URL {
+				t.Errorf("pushed %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
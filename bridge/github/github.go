@@ -0,0 +1,337 @@
+// Package github 实现 GitHub webhook 接收端, 校验 HMAC 签名并将 push, pull_request, issues,
+// workflow_run 事件转换为简洁的 Bark 推送
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// ErrInvalidSignature X-Hub-Signature-256 签名校验失败
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrNoSecret 未配置 Secret 且未设置 Insecure, 拒绝所有请求
+var ErrNoSecret = errors.New("webhook secret not configured")
+
+// ErrUnsupportedEvent 不支持或未启用的事件类型
+var ErrUnsupportedEvent = errors.New("unsupported event")
+
+// ErrInvalidPayload 请求体不是合法的事件数据
+var ErrInvalidPayload = errors.New("invalid github payload")
+
+// Payload 各事件请求体中用到的字段
+type Payload struct {
+	Action     string     `json:"action"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+
+	// push 事件
+	Ref     string   `json:"ref"`
+	Compare string   `json:"compare"`
+	Forced  bool     `json:"forced"`
+	Deleted bool     `json:"deleted"`
+	Commits []Commit `json:"commits"`
+	Pusher  struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+
+	// pull_request 事件
+	PullRequest *Issue `json:"pull_request"`
+	// issues 事件
+	Issue *Issue `json:"issue"`
+	// workflow_run 事件
+	WorkflowRun *WorkflowRun `json:"workflow_run"`
+}
+
+// Repository 仓库
+type Repository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// User 用户
+type User struct {
+	Login string `json:"login"`
+}
+
+// Commit push 事件中的提交
+type Commit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+// Issue issue 或 pull request
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Merged  bool   `json:"merged"`
+	Draft   bool   `json:"draft"`
+}
+
+// WorkflowRun Actions 工作流运行
+type WorkflowRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadBranch string `json:"head_branch"`
+	RunNumber  int    `json:"run_number"`
+	HTMLURL    string `json:"html_url"`
+}
+
+// Event 单个事件类型的配置
+type Event struct {
+	// Disabled 为 true 时忽略该事件
+	Disabled bool
+	// Actions 非空时只推送指定的 action (如 opened, closed, completed)
+	Actions []string
+	// Defaults 该事件的推送参数, 覆盖 Handler.Defaults 中的同名字段
+	Defaults *bark.Options
+	// Template 该事件的参数模板, 数据为 TemplateData
+	Template *template.Template
+}
+
+// DefaultEvents 默认启用的事件, workflow_run 只在运行结束时推送
+var DefaultEvents = map[string]Event{
+	"push":         {},
+	"pull_request": {Actions: []string{"opened", "closed", "reopened", "ready_for_review"}},
+	"issues":       {Actions: []string{"opened", "closed", "reopened"}},
+	"workflow_run": {Actions: []string{"completed"}},
+}
+
+// TemplateData 自定义模板的渲染数据
+type TemplateData struct {
+	// Event 事件类型, 即 X-GitHub-Event
+	Event string
+	*Payload
+	// Raw 原始请求体, 用于访问 Payload 中没有的字段
+	Raw map[string]interface{}
+}
+
+// Handler GitHub webhook 接收端
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Secret webhook 密钥, 用于校验 X-Hub-Signature-256; 为空且未设置 Insecure 时拒绝所有请求
+	Secret string
+	// Insecure 为 true 时在 Secret 为空的情况下不校验签名, 仅用于本地调试
+	Insecure bool
+	// Events 事件类型到配置的映射, 为 nil 时使用 DefaultEvents; 不在映射中的事件会被忽略
+	Events map[string]Event
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options, secret string) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults, Secret: secret}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	body, err := bridge.ReadBody(r, h.MaxBodyBytes)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	switch {
+	case h.Secret == "" && !h.Insecure:
+		bridge.Respond(w, http.StatusUnauthorized, ErrNoSecret.Error())
+		return
+	case h.Secret != "" && !VerifySignature(h.Secret, r.Header.Get("X-Hub-Signature-256"), body):
+		bridge.Respond(w, http.StatusUnauthorized, ErrInvalidSignature.Error())
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "ping" {
+		bridge.Respond(w, http.StatusOK, "pong")
+		return
+	}
+
+	err = h.Handle(r.Context(), event, body)
+	switch {
+	case errors.Is(err, ErrUnsupportedEvent):
+		// 返回 2xx, 避免 GitHub 将其记录为投递失败
+		bridge.Respond(w, http.StatusAccepted, "ignored")
+	case errors.Is(err, ErrInvalidPayload):
+		bridge.Respond(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		bridge.Respond(w, bridge.PushStatus(err), err.Error())
+	default:
+		bridge.Respond(w, http.StatusOK, "success")
+	}
+}
+
+// VerifySignature 校验 X-Hub-Signature-256 (sha256=<hex>), secret 为空时返回 false
+func VerifySignature(secret, signature string, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// Handle 解析事件并推送; 未启用的事件或 action 返回 ErrUnsupportedEvent, 请求体无效时返回 ErrInvalidPayload
+func (h *Handler) Handle(ctx context.Context, event string, body []byte) error {
+	cfg, ok := h.event(event)
+	if !ok || cfg.Disabled {
+		return fmt.Errorf("%w: %s", ErrUnsupportedEvent, event)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if len(cfg.Actions) > 0 && !slices.Contains(cfg.Actions, p.Action) {
+		return fmt.Errorf("%w: %s.%s", ErrUnsupportedEvent, event, p.Action)
+	}
+
+	o, err := h.Options(event, &p)
+	if err != nil {
+		return err
+	}
+	if cfg.Template != nil {
+		var raw map[string]interface{}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		if _, err := bridge.Render(cfg.Template, TemplateData{Event: event, Payload: &p, Raw: raw}, o); err != nil {
+			return err
+		}
+	}
+	return h.Pusher.Push(ctx, o)
+}
+
+func (h *Handler) event(name string) (Event, bool) {
+	events := h.Events
+	if events == nil {
+		events = DefaultEvents
+	}
+	cfg, ok := events[name]
+	return cfg, ok
+}
+
+// Options 将事件转换为推送参数 (不含事件模板)
+func (h *Handler) Options(event string, p *Payload) (*bark.Options, error) {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+
+	repo := p.Repository.FullName
+	actor := p.Sender.Login
+	o.Subtitle = repo
+	if o.Group == "" {
+		o.Group = repo
+	}
+
+	switch event {
+	case "push":
+		branch := strings.TrimPrefix(strings.TrimPrefix(p.Ref, "refs/heads/"), "refs/tags/")
+		switch {
+		case p.Deleted:
+			o.Title = fmt.Sprintf("%s deleted %s", actor, branch)
+		case p.Forced:
+			o.Title = fmt.Sprintf("%s force-pushed to %s", actor, branch)
+		default:
+			o.Title = fmt.Sprintf("%s pushed %d commit(s) to %s", actor, len(p.Commits), branch)
+		}
+		o.Body = commitSummary(p.Commits)
+		o.URL = bridge.FirstNonEmpty(p.Compare, p.Repository.HTMLURL)
+	case "pull_request":
+		if p.PullRequest == nil {
+			return nil, fmt.Errorf("%w: pull_request event without pull_request", ErrInvalidPayload)
+		}
+		action := p.Action
+		if action == "closed" && p.PullRequest.Merged {
+			action = "merged"
+		}
+		o.Title = fmt.Sprintf("PR #%d %s by %s", p.PullRequest.Number, action, actor)
+		o.Body = p.PullRequest.Title
+		o.URL = p.PullRequest.HTMLURL
+	case "issues":
+		if p.Issue == nil {
+			return nil, fmt.Errorf("%w: issues event without issue", ErrInvalidPayload)
+		}
+		o.Title = fmt.Sprintf("Issue #%d %s by %s", p.Issue.Number, p.Action, actor)
+		o.Body = p.Issue.Title
+		o.URL = p.Issue.HTMLURL
+	case "workflow_run":
+		run := p.WorkflowRun
+		if run == nil {
+			return nil, fmt.Errorf("%w: workflow_run event without workflow_run", ErrInvalidPayload)
+		}
+		status := bridge.FirstNonEmpty(run.Conclusion, run.Status)
+		o.Title = fmt.Sprintf("%s #%d %s", run.Name, run.RunNumber, status)
+		o.Body = fmt.Sprintf("branch %s, triggered by %s", run.HeadBranch, actor)
+		o.URL = run.HTMLURL
+		if run.Conclusion == "failure" || run.Conclusion == "timed_out" {
+			o.Level = "timeSensitive"
+		}
+	default:
+		o.Title = fmt.Sprintf("%s %s", event, p.Action)
+		o.Body = fmt.Sprintf("by %s", actor)
+		o.URL = p.Repository.HTMLURL
+	}
+
+	if cfg, ok := h.event(event); ok && cfg.Defaults != nil {
+		overlay(o, cfg.Defaults)
+	}
+	return o, nil
+}
+
+// overlay 将 src 中的非空参数写入 o
+func overlay(o, src *bark.Options) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, o)
+}
+
+// commitSummary 每个提交一行: 短 SHA 和提交说明首行, 最多列出 5 个
+func commitSummary(commits []Commit) string {
+	const limit = 5
+	var lines []string
+	for i, c := range commits {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(commits)-limit))
+			break
+		}
+		id := c.ID
+		if len(id) > 7 {
+			id = id[:7]
+		}
+		msg, _, _ := strings.Cut(c.Message, "\n")
+		lines = append(lines, id+" "+msg)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,201 @@
+package github_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge"
+	"github.com/gaoyaxuan/go-bark/bridge/github"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	const body = `{"zen":"hi"}`
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		want      bool
+	}{
+		{"valid", "s3cret", sign("s3cret", body), true},
+		{"wrong secret", "s3cret", sign("other", body), false},
+		{"missing prefix", "s3cret", strings.TrimPrefix(sign("s3cret", body), "sha256="), false},
+		{"not hex", "s3cret", "sha256=zz", false},
+		{"empty signature", "s3cret", "", false},
+		{"no secret", "", sign("", body), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := github.VerifySignature(tt.secret, tt.signature, []byte(body)); got != tt.want {
+				t.Errorf("VerifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func serve(h *github.Handler, event, signature, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/github", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerSignature(t *testing.T) {
+	const body = `{"zen":"hi"}`
+	tests := []struct {
+		name      string
+		secret    string
+		insecure  bool
+		signature string
+		want      int
+	}{
+		{"signed", "s3cret", false, sign("s3cret", body), http.StatusOK},
+		{"forged", "s3cret", false, sign("guess", body), http.StatusUnauthorized},
+		{"unsigned", "s3cret", false, "", http.StatusUnauthorized},
+		{"no secret fails closed", "", false, "", http.StatusUnauthorized},
+		{"no secret with insecure", "", true, "", http.StatusOK},
+		{"insecure still checks a configured secret", "s3cret", true, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := github.New(&barktest.Recorder{}, &bark.Options{DeviceKey: "key"}, tt.secret)
+			h.Insecure = tt.insecure
+			if w := serve(h, "ping", tt.signature, body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	const repo = `"repository":{"full_name":"octo/app","html_url":"https://github.com/octo/app"},"sender":{"login":"alice"}`
+	tests := []struct {
+		name   string
+		events map[string]github.Event
+		event  string
+		body   string
+		want   *bark.Options
+	}{
+		{
+			name:  "push",
+			event: "push",
+			body: `{"ref":"refs/heads/main","compare":"https://github.com/octo/app/compare/a...b",` + repo + `,"commits":[
+				{"id":"0123456789abcdef","message":"fix bug\n\nlong description"},{"id":"fedcba98","message":"add test"}]}`,
+			want: &bark.Options{Title: "alice pushed 2 commit(s) to main", Body: "0123456 fix bug\nfedcba9 add test", URL: "https://github.com/octo/app/compare/a...b"},
+		},
+		{
+			name:  "force push",
+			event: "push",
+			body:  `{"ref":"refs/tags/v1","forced":true,` + repo + `}`,
+			want:  &bark.Options{Title: "alice force-pushed to v1", URL: "https://github.com/octo/app"},
+		},
+		{
+			name:  "merged pull request",
+			event: "pull_request",
+			body:  `{"action":"closed",` + repo + `,"pull_request":{"number":7,"title":"Add feature","merged":true,"html_url":"https://github.com/octo/app/pull/7"}}`,
+			want:  &bark.Options{Title: "PR #7 merged by alice", Body: "Add feature", URL: "https://github.com/octo/app/pull/7"},
+		},
+		{
+			name:  "pull request action not enabled",
+			event: "pull_request",
+			body:  `{"action":"labeled",` + repo + `,"pull_request":{"number":7}}`,
+		},
+		{
+			name:  "issue",
+			event: "issues",
+			body:  `{"action":"opened",` + repo + `,"issue":{"number":3,"title":"Crash","html_url":"https://github.com/octo/app/issues/3"}}`,
+			want:  &bark.Options{Title: "Issue #3 opened by alice", Body: "Crash", URL: "https://github.com/octo/app/issues/3"},
+		},
+		{
+			name:  "failed workflow run",
+			event: "workflow_run",
+			body:  `{"action":"completed",` + repo + `,"workflow_run":{"name":"CI","conclusion":"failure","head_branch":"main","run_number":12,"html_url":"https://github.com/octo/app/actions/runs/1"}}`,
+			want:  &bark.Options{Title: "CI #12 failure", Body: "branch main, triggered by alice", URL: "https://github.com/octo/app/actions/runs/1", Level: "timeSensitive"},
+		},
+		{
+			name:  "unknown event",
+			event: "star",
+			body:  `{"action":"created",` + repo + `}`,
+		},
+		{
+			name: "custom event with defaults and template",
+			events: map[string]github.Event{"release": {
+				Defaults: &bark.Options{Sound: "bell", Group: "releases"},
+				Template: template.Must(template.New("").Parse(`{{define "body"}}{{.Raw.release.tag_name}}{{end}}`)),
+			}},
+			event: "release",
+			body:  `{"action":"published",` + repo + `,"release":{"tag_name":"v2.0.0"}}`,
+			want:  &bark.Options{Title: "release published", Body: "v2.0.0", URL: "https://github.com/octo/app", Sound: "bell", Group: "releases"},
+		},
+		{
+			name:   "disabled event",
+			events: map[string]github.Event{"push": {Disabled: true}},
+			event:  "push",
+			body:   `{` + repo + `}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := github.New(r, &bark.Options{DeviceKey: "key"}, "s3cret")
+			h.Events = tt.events
+			err := h.Handle(context.Background(), tt.event, []byte(tt.body))
+			if tt.want == nil {
+				if !errors.Is(err, github.ErrUnsupportedEvent) || r.Len() != 0 {
+					t.Errorf("Handle() = %v with %d pushes, want ErrUnsupportedEvent", err, r.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.Last()
+			group := bridge.FirstNonEmpty(tt.want.Group, "octo/app")
+			if got.DeviceKey != "key" || got.Subtitle != "octo/app" || got.Group != group || got.Title != tt.want.Title ||
+				got.Body != tt.want.Body || got.URL != tt.want.URL || got.Level != tt.want.Level || got.Sound != tt.want.Sound {
+				t.Errorf("pushed %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		body  string
+		want  int
+	}{
+		{"ignored event", "star", `{"action":"created"}`, http.StatusAccepted},
+		{"invalid payload", "push", `{`, http.StatusBadRequest},
+		{"missing pull request", "pull_request", `{"action":"opened"}`, http.StatusBadRequest},
+		{"push failure", "push", `{"ref":"refs/heads/main"}`, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := github.New(&barktest.Recorder{Err: errors.New("down")}, &bark.Options{DeviceKey: "key"}, "s3cret")
+			if w := serve(h, tt.event, sign("s3cret", tt.body), tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package gotify 实现兼容 Gotify 消息接口的接收端, 现有的 Gotify 集成无需修改发送端即可迁移到 Bark
+package gotify
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Message Gotify 消息格式
+type Message struct {
+	Title    string                            `json:"title"`
+	Message  string                            `json:"message"`
+	Priority *int                              `json:"priority"`
+	Extras   map[string]map[string]interface{} `json:"extras"`
+}
+
+// Application 应用令牌对应的应用
+type Application struct {
+	// Name 应用名称, 作为 Bark 分组
+	Name string
+	// Recipients 可选, 该应用推送的别名或分组名 (由 Client 地址簿解析), 为空时使用 Handler.Defaults 中的设备
+	Recipients []string
+	// DefaultPriority 消息未指定优先级时使用的优先级
+	DefaultPriority int
+}
+
+// Handler Gotify POST /message 兼容接收端
+//
+// 应用令牌通过 X-Gotify-Key 请求头, ?token= 查询参数或 Authorization: Bearer 传递
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Applications 应用令牌到应用的映射, 为空时不校验令牌
+	Applications map[string]Application
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options, apps map[string]Application) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults, Applications: apps}
+}
+
+// Level 将 Gotify 优先级 (0-10) 映射为 Bark 级别:
+// 0-3 为 passive, 4-7 为 active, 8 及以上为 timeSensitive
+func Level(priority int) string {
+	switch {
+	case priority <= 3:
+		return "passive"
+	case priority <= 7:
+		return "active"
+	default:
+		return "timeSensitive"
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		gotifyError(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	app, ok := h.application(r)
+	if !ok {
+		gotifyError(w, http.StatusUnauthorized, "you need to provide a valid access token or user credentials to access this api")
+		return
+	}
+	body, err := bridge.ReadBody(r, h.MaxBodyBytes)
+	if err != nil {
+		gotifyError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	msg, err := parseMessage(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		gotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg.Message == "" {
+		gotifyError(w, http.StatusBadRequest, "Field 'message' is required")
+		return
+	}
+
+	o := h.Options(app, msg)
+	if err := h.Pusher.Push(r.Context(), o); err != nil {
+		gotifyError(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"title":    msg.Title,
+		"message":  msg.Message,
+		"priority": priority(app, msg),
+		"extras":   msg.Extras,
+		"date":     time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// application 按令牌查找应用
+func (h *Handler) application(r *http.Request) (Application, bool) {
+	if len(h.Applications) == 0 {
+		return Application{Name: "gotify"}, true
+	}
+	token := r.Header.Get("X-Gotify-Key")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if auth := r.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	for t, app := range h.Applications {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return app, true
+		}
+	}
+	return Application{}, false
+}
+
+// parseMessage 解析 JSON 或 application/x-www-form-urlencoded 格式的消息
+func parseMessage(contentType string, body []byte) (*Message, error) {
+	var msg Message
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid form: %w", err)
+		}
+		msg.Title = form.Get("title")
+		msg.Message = form.Get("message")
+		if p := form.Get("priority"); p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid priority: %s", p)
+			}
+			msg.Priority = &n
+		}
+		return &msg, nil
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	return &msg, nil
+}
+
+func priority(app Application, msg *Message) int {
+	if msg.Priority != nil {
+		return *msg.Priority
+	}
+	return app.DefaultPriority
+}
+
+// Options 将消息转换为推送参数
+//
+// extras 中 client::notification.click.url 作为通知 url,
+// client::display.contentType 为 text/markdown 时作为 Markdown 推送
+func (h *Handler) Options(app Application, msg *Message) *bark.Options {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	if len(app.Recipients) > 0 {
+		o.DeviceKey, o.DeviceKeys = "", nil
+		o.Recipients = append([]string(nil), app.Recipients...)
+	}
+	if app.Name != "" {
+		o.Group = app.Name
+	}
+
+	o.Title = msg.Title
+	if display, ok := msg.Extras["client::display"]; ok && display["contentType"] == "text/markdown" {
+		o.Markdown = msg.Message
+	} else {
+		o.Body = msg.Message
+	}
+	if n, ok := msg.Extras["client::notification"]; ok {
+		if click, ok := n["click"].(map[string]interface{}); ok {
+			if u, ok := click["url"].(string); ok && u != "" {
+				o.URL = u
+			}
+		}
+	}
+	o.Level = Level(priority(app, msg))
+	return o
+}
+
+// gotifyError 以 Gotify 的错误格式返回
+func gotifyError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":            http.StatusText(code),
+		"errorCode":        code,
+		"errorDescription": description,
+	})
+}
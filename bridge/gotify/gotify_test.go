@@ -0,0 +1,105 @@
+package gotify_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/gotify"
+)
+
+func TestLevel(t *testing.T) {
+	tests := map[int]string{0: "passive", 3: "passive", 4: "active", 7: "active", 8: "timeSensitive", 10: "timeSensitive"}
+	for p, want := range tests {
+		if got := gotify.Level(p); got != want {
+			t.Errorf("Level(%d) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	apps := map[string]gotify.Application{
+		"AppToken1": {Name: "backup", DefaultPriority: 8},
+		"AppToken2": {Name: "ci", Recipients: []string{"dev"}},
+	}
+	tests := []struct {
+		name        string
+		target      string
+		headers     map[string]string
+		contentType string
+		body        string
+		code        int
+		want        *bark.Options
+	}{
+		{
+			name: "json with header token", target: "/message", headers: map[string]string{"X-Gotify-Key": "AppToken1"},
+			body: `{"title":"Backup","message":"done","priority":2}`,
+			code: http.StatusOK, want: &bark.Options{DeviceKey: "key", Group: "backup", Title: "Backup", Body: "done", Level: "passive"},
+		},
+		{
+			name: "default priority", target: "/message?token=AppToken1",
+			body: `{"message":"failed"}`,
+			code: http.StatusOK, want: &bark.Options{DeviceKey: "key", Group: "backup", Body: "failed", Level: "timeSensitive"},
+		},
+		{
+			name: "markdown and click url", target: "/message", headers: map[string]string{"Authorization": "Bearer AppToken2"},
+			body: `{"message":"**ok**","extras":{"client::display":{"contentType":"text/markdown"},"client::notification":{"click":{"url":"https://ci/1"}}}}`,
+			code: http.StatusOK, want: &bark.Options{Group: "ci", Markdown: "**ok**", URL: "https://ci/1", Level: "passive", Recipients: []string{"dev"}},
+		},
+		{
+			name: "form", target: "/message?token=AppToken1", contentType: "application/x-www-form-urlencoded",
+			body: "title=T&message=from+form&priority=5",
+			code: http.StatusOK, want: &bark.Options{DeviceKey: "key", Group: "backup", Title: "T", Body: "from form", Level: "active"},
+		},
+		{name: "bad token", target: "/message?token=nope", body: `{"message":"x"}`, code: http.StatusUnauthorized},
+		{name: "missing message", target: "/message?token=AppToken1", body: `{"title":"x"}`, code: http.StatusBadRequest},
+		{name: "invalid json", target: "/message?token=AppToken1", body: `{`, code: http.StatusBadRequest},
+		{
+			name: "invalid form priority", target: "/message?token=AppToken1", contentType: "application/x-www-form-urlencoded",
+			body: "message=x&priority=high", code: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := gotify.New(r, &bark.Options{DeviceKey: "key"}, apps)
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.want == nil {
+				if !strings.Contains(w.Body.String(), `"errorCode"`) || r.Len() != 0 {
+					t.Errorf("response %s, %d pushes", w.Body, r.Len())
+				}
+				return
+			}
+			got := r.Last()
+			if got == nil || got.DeviceKey != tt.want.DeviceKey || got.Group != tt.want.Group || got.Title != tt.want.Title ||
+				got.Body != tt.want.Body || got.Markdown != tt.want.Markdown || got.URL != tt.want.URL || got.Level != tt.want.Level ||
+				strings.Join(got.Recipients, ",") != strings.Join(tt.want.Recipients, ",") {
+				t.Errorf("pushed %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerWithoutApplications(t *testing.T) {
+	r := &barktest.Recorder{}
+	h := gotify.New(r, nil, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(`{"message":"x"}`)))
+	if w.Code != http.StatusOK || r.Len() != 1 || r.Last().Group != "gotify" {
+		t.Errorf("status = %d, pushes %+v", w.Code, r.Pushes())
+	}
+}
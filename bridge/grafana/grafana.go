@@ -0,0 +1,240 @@
+// Package grafana 实现 Grafana 统一告警 (unified alerting) webhook 接收端, 将告警转换为 Bark 推送
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Message Grafana webhook 请求体
+type Message struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int64             `json:"orgId"`
+	Alerts            []Alert           `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+// Alert 单条告警
+type Alert struct {
+	Status       string             `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	Values       map[string]float64 `json:"values"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+	ValueString  string             `json:"valueString"`
+}
+
+// State 告警状态, 无数据 (DatasourceNoData) 和错误 (DatasourceError) 单独区分
+func (a Alert) State() string {
+	if a.Status == "resolved" {
+		return "resolved"
+	}
+	switch a.Labels["alertname"] {
+	case "DatasourceNoData":
+		return "nodata"
+	case "DatasourceError":
+		return "error"
+	}
+	return "firing"
+}
+
+// DefaultIcon Icons 中没有对应状态时使用的图标
+const DefaultIcon = "https://grafana.com/static/assets/img/fav32.png"
+
+// DefaultLevels 各状态默认使用的 Bark 级别
+var DefaultLevels = map[string]string{
+	"firing":   "timeSensitive",
+	"resolved": "passive",
+	"nodata":   "active",
+	"error":    "timeSensitive",
+}
+
+// TemplateData 自定义模板的渲染数据
+type TemplateData struct {
+	Alert
+	Message *Message
+}
+
+// Handler Grafana webhook 接收端
+//
+// 每条告警单独推送, 通知的 url 优先使用 panelURL, 其次 dashboardURL 和 generatorURL;
+// fingerprint 作为通知 ID, 恢复通知会替换原告警
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Icons 告警状态 (firing, resolved, nodata, error) 到图标 URL 的映射, 未配置的状态使用 DefaultIcon
+	Icons map[string]string
+	// Levels 告警状态到 Bark 级别的映射, 默认 DefaultLevels
+	Levels map[string]string
+	// SkipResolved 为 true 时不推送恢复通知
+	SkipResolved bool
+	// Template 可选, 以推送参数命名的子模板覆盖默认内容, 数据为 TemplateData
+	Template *template.Template
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	body, err := bridge.ReadBody(r, 0)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		bridge.Respond(w, http.StatusBadRequest, "invalid grafana payload: "+err.Error())
+		return
+	}
+
+	if err := h.Handle(r.Context(), &msg); err != nil {
+		bridge.Respond(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+	bridge.Respond(w, http.StatusOK, "success")
+}
+
+// Handle 推送消息中的全部告警
+func (h *Handler) Handle(ctx context.Context, msg *Message) error {
+	var errs bark.MultiError
+	for _, alert := range msg.Alerts {
+		if alert.Status == "resolved" && h.SkipResolved {
+			continue
+		}
+		o, err := h.Options(msg, alert)
+		if err != nil {
+			errs.Add("", err)
+			continue
+		}
+		errs.Add("alert "+alert.Fingerprint, h.Pusher.Push(ctx, o))
+	}
+	return errs.Err()
+}
+
+// Options 将单条告警转换为推送参数
+func (h *Handler) Options(msg *Message, alert Alert) (*bark.Options, error) {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+
+	state := alert.State()
+	name := alert.Labels["alertname"]
+	if name == "" {
+		name = "Grafana alert"
+	}
+
+	o.Title = fmt.Sprintf("[%s] %s", strings.ToUpper(state), name)
+	o.Subtitle = alert.Labels["grafana_folder"]
+	o.Body = alertBody(alert)
+	if o.Group == "" {
+		o.Group = "grafana"
+	}
+	if u := bridge.FirstNonEmpty(alert.PanelURL, alert.DashboardURL, alert.GeneratorURL); u != "" {
+		o.URL = u
+	}
+	if icon, ok := h.Icons[state]; ok {
+		o.Icon = icon
+	} else if o.Icon == "" {
+		o.Icon = DefaultIcon
+	}
+	if level, ok := lookup(h.Levels, DefaultLevels, state); ok {
+		o.Level = level
+	}
+	if alert.Fingerprint != "" {
+		o.ID = alert.Fingerprint
+	}
+
+	if _, err := bridge.Render(h.Template, TemplateData{Alert: alert, Message: msg}, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func lookup(custom, defaults map[string]string, key string) (string, bool) {
+	if custom != nil {
+		v, ok := custom[key]
+		return v, ok
+	}
+	v, ok := defaults[key]
+	return v, ok
+}
+
+func alertBody(a Alert) string {
+	var b strings.Builder
+	if s := a.Annotations["summary"]; s != "" {
+		b.WriteString(s)
+		b.WriteString("\n")
+	}
+	if d := a.Annotations["description"]; d != "" {
+		b.WriteString(d)
+		b.WriteString("\n")
+	}
+
+	if len(a.Values) > 0 {
+		keys := make([]string, 0, len(a.Values))
+		for k := range a.Values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s: %g\n", k, a.Values[k])
+		}
+	} else if a.ValueString != "" {
+		b.WriteString(a.ValueString)
+		b.WriteString("\n")
+	}
+
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		switch k {
+		case "alertname", "grafana_folder":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, a.Labels[k])
+	}
+	return strings.TrimSpace(b.String())
+}
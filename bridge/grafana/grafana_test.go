@@ -0,0 +1,128 @@
+package grafana_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/grafana"
+)
+
+func TestAlertState(t *testing.T) {
+	tests := []struct {
+		alert grafana.Alert
+		want  string
+	}{
+		{grafana.Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLatency"}}, "firing"},
+		{grafana.Alert{Status: "resolved", Labels: map[string]string{"alertname": "DatasourceNoData"}}, "resolved"},
+		{grafana.Alert{Status: "firing", Labels: map[string]string{"alertname": "DatasourceNoData"}}, "nodata"},
+		{grafana.Alert{Status: "firing", Labels: map[string]string{"alertname": "DatasourceError"}}, "error"},
+	}
+	for _, tt := range tests {
+		if got := tt.alert.State(); got != tt.want {
+			t.Errorf("State(%+v) = %q, want %q", tt.alert, got, tt.want)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler *grafana.Handler
+		alert   grafana.Alert
+		want    bark.Options
+	}{
+		{
+			name:    "firing with values",
+			handler: grafana.New(nil, &bark.Options{DeviceKey: "key"}),
+			alert: grafana.Alert{
+				Status:       "firing",
+				Labels:       map[string]string{"alertname": "HighLatency", "grafana_folder": "API", "service": "checkout"},
+				Annotations:  map[string]string{"summary": "p99 high"},
+				Values:       map[string]float64{"B": 1.5, "A": 1200},
+				DashboardURL: "http://grafana/d/1",
+				PanelURL:     "http://grafana/d/1?viewPanel=2",
+				Fingerprint:  "fp",
+			},
+			want: bark.Options{
+				DeviceKey: "key", Title: "[FIRING] HighLatency", Subtitle: "API", Group: "grafana",
+				Body: "p99 high\nA: 1200\nB: 1.5\nservice=checkout", URL: "http://grafana/d/1?viewPanel=2",
+				Icon: grafana.DefaultIcon, Level: "timeSensitive", ID: "fp",
+			},
+		},
+		{
+			name:    "resolved with custom icon and value string",
+			handler: &grafana.Handler{Icons: map[string]string{"resolved": "http://icons/ok.png"}},
+			alert:   grafana.Alert{Status: "resolved", ValueString: "[ var='A' value=3 ]", GeneratorURL: "http://grafana/alerting"},
+			want: bark.Options{
+				Title: "[RESOLVED] Grafana alert", Group: "grafana", Body: "[ var='A' value=3 ]",
+				URL: "http://grafana/alerting", Icon: "http://icons/ok.png", Level: "passive",
+			},
+		},
+		{
+			name:    "custom levels replace the defaults",
+			handler: &grafana.Handler{Levels: map[string]string{"nodata": "passive"}},
+			alert:   grafana.Alert{Status: "firing", Labels: map[string]string{"alertname": "DatasourceNoData"}},
+			want:    bark.Options{Title: "[NODATA] DatasourceNoData", Group: "grafana", Icon: grafana.DefaultIcon, Level: "passive"},
+		},
+		{
+			name:    "default group and icon are kept",
+			handler: grafana.New(nil, &bark.Options{Group: "monitoring", Icon: "http://icons/own.png"}),
+			alert:   grafana.Alert{Status: "firing", Labels: map[string]string{"alertname": "X"}},
+			want:    bark.Options{Title: "[FIRING] X", Group: "monitoring", Icon: "http://icons/own.png", Level: "timeSensitive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := tt.handler.Options(&grafana.Message{}, tt.alert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Subtitle != tt.want.Subtitle || o.Group != tt.want.Group ||
+				o.Body != tt.want.Body || o.URL != tt.want.URL || o.Icon != tt.want.Icon || o.Level != tt.want.Level || o.ID != tt.want.ID {
+				t.Errorf("Options() = %+v\nwant %+v", o, &tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	const payload = `{"receiver":"bark","status":"firing","alerts":[
+		{"status":"firing","labels":{"alertname":"A"},"fingerprint":"1"},
+		{"status":"resolved","labels":{"alertname":"B"},"fingerprint":"2"}]}`
+	tests := []struct {
+		name         string
+		target       string
+		body         string
+		skipResolved bool
+		err          error
+		code         int
+		pushes       int
+	}{
+		{"ok", "/?token=t", payload, false, nil, http.StatusOK, 2},
+		{"skip resolved", "/?token=t", payload, true, nil, http.StatusOK, 1},
+		{"bad token", "/", payload, false, nil, http.StatusUnauthorized, 0},
+		{"invalid payload", "/?token=t", `[]`, false, nil, http.StatusBadRequest, 0},
+		{"push failure", "/?token=t", payload, false, context.Canceled, http.StatusBadGateway, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{Err: tt.err}
+			h := grafana.New(r, &bark.Options{DeviceKey: "key"})
+			h.Token = "t"
+			h.SkipResolved = tt.skipResolved
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if r.Len() != tt.pushes {
+				t.Errorf("pushes = %d, want %d", r.Len(), tt.pushes)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package journald 跟踪 systemd journal, 将匹配的日志条目转换为 Bark 推送 (仅支持 Linux)
+//
+// 通过 journalctl -f -o json 读取日志, 不依赖 cgo 和 libsystemd
+package journald
@@ -0,0 +1,278 @@
+//go:build linux
+
+package journald
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Priority journal 日志优先级, 与 syslog 级别相同, 数值越小越严重
+const (
+	PriorityEmergency = 0
+	PriorityAlert     = 1
+	PriorityCritical  = 2
+	PriorityError     = 3
+	PriorityWarning   = 4
+	PriorityNotice    = 5
+	PriorityInfo      = 6
+	PriorityDebug     = 7
+)
+
+var priorityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// DefaultLevels 优先级到 Bark 级别的映射
+var DefaultLevels = map[int]string{
+	PriorityEmergency: "critical",
+	PriorityAlert:     "timeSensitive",
+	PriorityCritical:  "timeSensitive",
+	PriorityError:     "active",
+	PriorityWarning:   "active",
+}
+
+// Entry journal 日志条目
+type Entry struct {
+	Unit       string
+	Identifier string
+	Hostname   string
+	PID        string
+	Message    string
+	Priority   int
+	Timestamp  time.Time
+	// Cursor 条目在 journal 中的位置
+	Cursor string
+	// Fields 全部字段, 如 _SYSTEMD_UNIT, _EXE, CODE_FILE
+	Fields map[string]string
+}
+
+// PriorityName 返回优先级名称, 如 err, warning
+func (e *Entry) PriorityName() string {
+	if e.Priority >= 0 && e.Priority < len(priorityNames) {
+		return priorityNames[e.Priority]
+	}
+	return strconv.Itoa(e.Priority)
+}
+
+// Watcher journal 跟踪器
+type Watcher struct {
+	// Units 只跟踪指定的 systemd unit (journalctl -u), 为空时跟踪全部
+	Units []string
+	// Identifiers 只跟踪指定的 SYSLOG_IDENTIFIER (journalctl -t)
+	Identifiers []string
+	// MaxPriority 只推送优先级数值不大于该值的条目, New 默认为 PriorityError
+	MaxPriority int
+	// Pattern 非空时只推送消息匹配的条目
+	Pattern *regexp.Regexp
+	// CursorFile 非空时保存已处理条目的位置, 重启后从该位置继续, 避免漏掉或重复推送
+	CursorFile string
+	// User 为 true 时跟踪当前用户的 journal (journalctl --user)
+	User bool
+	// Command journalctl 可执行文件路径, 默认 journalctl
+	Command string
+
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Levels 优先级到 Bark 级别的映射, 默认 DefaultLevels
+	Levels map[int]string
+	// Template 可选, 以推送参数命名的子模板覆盖默认内容, 数据为 *Entry
+	Template *template.Template
+	// Logger 记录推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 Watcher
+func New(p bark.Pusher, defaults *bark.Options, units ...string) *Watcher {
+	return &Watcher{Pusher: p, Defaults: defaults, Units: units, MaxPriority: PriorityError}
+}
+
+func (w *Watcher) logger() *slog.Logger {
+	if w.Logger != nil {
+		return w.Logger
+	}
+	return slog.Default()
+}
+
+// args 构造 journalctl 参数
+func (w *Watcher) args() []string {
+	args := []string{"--follow", "--output=json", "--no-pager", fmt.Sprintf("--priority=0..%d", w.MaxPriority)}
+	if w.User {
+		args = append(args, "--user")
+	}
+	for _, u := range w.Units {
+		args = append(args, "--unit="+u)
+	}
+	for _, id := range w.Identifiers {
+		args = append(args, "--identifier="+id)
+	}
+
+	cursor := ""
+	if w.CursorFile != "" {
+		if data, err := os.ReadFile(w.CursorFile); err == nil {
+			cursor = strings.TrimSpace(string(data))
+		}
+	}
+	if cursor != "" {
+		args = append(args, "--after-cursor="+cursor)
+	} else {
+		// 只跟踪新条目
+		args = append(args, "--lines=0")
+	}
+	return args
+}
+
+// Run 启动 journalctl 并推送匹配的条目, 直到 ctx 结束
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.MaxPriority < 0 || w.MaxPriority > PriorityDebug {
+		return fmt.Errorf("journald: invalid max priority %d", w.MaxPriority)
+	}
+	name := w.Command
+	if name == "" {
+		name = "journalctl"
+	}
+
+	cmd := exec.CommandContext(ctx, name, w.args()...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("journald: start %s: %w", name, err)
+	}
+
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	for sc.Scan() {
+		e, err := ParseEntry(sc.Bytes())
+		if err != nil {
+			w.logger().Debug("bark journald watcher: skip entry", "err", err)
+			continue
+		}
+		w.handle(ctx, e)
+	}
+	scanErr := sc.Err()
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if scanErr != nil {
+		return fmt.Errorf("journald: read: %w", scanErr)
+	}
+	if err != nil {
+		return fmt.Errorf("journald: %s exited: %w", name, err)
+	}
+	return errors.New("journald: journalctl exited unexpectedly")
+}
+
+func (w *Watcher) handle(ctx context.Context, e *Entry) {
+	if e.Priority <= w.MaxPriority && (w.Pattern == nil || w.Pattern.MatchString(e.Message)) {
+		o, err := w.Options(e)
+		if err == nil {
+			err = w.Pusher.Push(ctx, o)
+		}
+		if err != nil {
+			w.logger().Error("bark journald watcher: push failed", "unit", e.Unit, "err", err)
+		}
+	}
+	if w.CursorFile != "" && e.Cursor != "" {
+		if err := os.WriteFile(w.CursorFile, []byte(e.Cursor+"\n"), 0o600); err != nil {
+			w.logger().Warn("bark journald watcher: save cursor failed", "file", w.CursorFile, "err", err)
+		}
+	}
+}
+
+// ParseEntry 解析 journalctl -o json 输出的一行
+// 二进制字段 (以字节数组输出) 按 UTF-8 解码, 重复字段 (以字符串数组输出) 取第一个值
+func ParseEntry(line []byte) (*Entry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, fmt.Errorf("invalid journal entry: %w", err)
+	}
+
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		fields[k] = fieldValue(v)
+	}
+	e := &Entry{
+		Unit:       bridge.FirstNonEmpty(fields["_SYSTEMD_UNIT"], fields["UNIT"], fields["_SYSTEMD_USER_UNIT"]),
+		Identifier: bridge.FirstNonEmpty(fields["SYSLOG_IDENTIFIER"], fields["_COMM"]),
+		Hostname:   fields["_HOSTNAME"],
+		PID:        fields["_PID"],
+		Message:    fields["MESSAGE"],
+		Priority:   PriorityInfo,
+		Cursor:     fields["__CURSOR"],
+		Fields:     fields,
+	}
+	if p, err := strconv.Atoi(fields["PRIORITY"]); err == nil {
+		e.Priority = p
+	}
+	if us, err := strconv.ParseInt(bridge.FirstNonEmpty(fields["__REALTIME_TIMESTAMP"], fields["_SOURCE_REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		e.Timestamp = time.UnixMicro(us)
+	}
+	return e, nil
+}
+
+func fieldValue(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	var b []byte
+	var ints []int
+	if json.Unmarshal(v, &ints) == nil {
+		b = make([]byte, len(ints))
+		for i, n := range ints {
+			b[i] = byte(n)
+		}
+		return string(b)
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(v, &list) == nil && len(list) > 0 {
+		return fieldValue(list[0])
+	}
+	return ""
+}
+
+// Options 将条目转换为推送参数
+func (w *Watcher) Options(e *Entry) (*bark.Options, error) {
+	o := &bark.Options{}
+	if w.Defaults != nil {
+		o = w.Defaults.Clone()
+	}
+
+	source := bridge.FirstNonEmpty(e.Unit, e.Identifier, "journal")
+	o.Title = fmt.Sprintf("[%s] %s", e.PriorityName(), source)
+	o.Subtitle = e.Hostname
+	o.Body = e.Message
+	if o.Group == "" {
+		o.Group = source
+	}
+	levels := w.Levels
+	if levels == nil {
+		levels = DefaultLevels
+	}
+	if level := levels[e.Priority]; level != "" {
+		o.Level = level
+	}
+
+	if _, err := bridge.Render(w.Template, e, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
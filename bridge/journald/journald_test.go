@@ -0,0 +1,165 @@
+//go:build linux
+
+package journald_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/journald"
+)
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want journald.Entry
+	}{
+		{
+			name: "service",
+			line: `{"__CURSOR":"s=1;i=2","__REALTIME_TIMESTAMP":"1700000000123456","PRIORITY":"3","_SYSTEMD_UNIT":"nginx.service","SYSLOG_IDENTIFIER":"nginx","_HOSTNAME":"web1","_PID":"42","MESSAGE":"worker crashed"}`,
+			want: journald.Entry{Unit: "nginx.service", Identifier: "nginx", Hostname: "web1", PID: "42", Message: "worker crashed", Priority: 3, Cursor: "s=1;i=2", Timestamp: time.UnixMicro(1700000000123456)},
+		},
+		{
+			name: "binary message and repeated fields",
+			line: `{"MESSAGE":[104,105,10],"SYSLOG_IDENTIFIER":["first","second"],"PRIORITY":"4"}`,
+			want: journald.Entry{Identifier: "first", Message: "hi\n", Priority: 4},
+		},
+		{
+			name: "fallback fields",
+			line: `{"UNIT":"backup.timer","_COMM":"sh","_SOURCE_REALTIME_TIMESTAMP":"1000000","MESSAGE":"x"}`,
+			want: journald.Entry{Unit: "backup.timer", Identifier: "sh", Message: "x", Priority: journald.PriorityInfo, Timestamp: time.UnixMicro(1000000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := journald.ParseEntry([]byte(tt.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Unit != tt.want.Unit || e.Identifier != tt.want.Identifier || e.Hostname != tt.want.Hostname || e.PID != tt.want.PID ||
+				e.Message != tt.want.Message || e.Priority != tt.want.Priority || e.Cursor != tt.want.Cursor || !e.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("ParseEntry() = %+v\nwant %+v", e, &tt.want)
+			}
+			if e.Fields["MESSAGE"] != tt.want.Message {
+				t.Errorf("Fields[MESSAGE] = %q", e.Fields["MESSAGE"])
+			}
+		})
+	}
+
+	if _, err := journald.ParseEntry([]byte("not json")); err == nil {
+		t.Error("ParseEntry() should fail on invalid JSON")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	w := journald.New(nil, &bark.Options{DeviceKey: "key"})
+	tests := []struct {
+		entry journald.Entry
+		want  bark.Options
+	}{
+		{
+			journald.Entry{Unit: "nginx.service", Hostname: "web1", Message: "crashed", Priority: journald.PriorityCritical},
+			bark.Options{DeviceKey: "key", Title: "[crit] nginx.service", Subtitle: "web1", Body: "crashed", Group: "nginx.service", Level: "timeSensitive"},
+		},
+		{
+			journald.Entry{Identifier: "kernel", Message: "oops", Priority: journald.PriorityInfo},
+			bark.Options{DeviceKey: "key", Title: "[info] kernel", Body: "oops", Group: "kernel"},
+		},
+		{
+			journald.Entry{Message: "?", Priority: 12},
+			bark.Options{DeviceKey: "key", Title: "[12] journal", Body: "?", Group: "journal"},
+		},
+	}
+	for _, tt := range tests {
+		o, err := w.Options(&tt.entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Subtitle != tt.want.Subtitle ||
+			o.Body != tt.want.Body || o.Group != tt.want.Group || o.Level != tt.want.Level {
+			t.Errorf("Options(%+v) = %+v\nwant %+v", tt.entry, o, &tt.want)
+		}
+	}
+}
+
+// fakeJournalctl 写入一个输出 lines 后退出的脚本, 并记录收到的参数
+func fakeJournalctl(t *testing.T, lines ...string) (command, argsFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile = filepath.Join(dir, "args")
+	data := filepath.Join(dir, "entries")
+	if err := os.WriteFile(data, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	command = filepath.Join(dir, "journalctl")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat " + data + "\n"
+	if err := os.WriteFile(command, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return command, argsFile
+}
+
+func TestRun(t *testing.T) {
+	command, argsFile := fakeJournalctl(t,
+		`{"__CURSOR":"c1","PRIORITY":"3","_SYSTEMD_UNIT":"db.service","MESSAGE":"disk failure"}`,
+		`not json`,
+		`{"__CURSOR":"c2","PRIORITY":"6","_SYSTEMD_UNIT":"db.service","MESSAGE":"info is filtered"}`,
+		`{"__CURSOR":"c3","PRIORITY":"2","_SYSTEMD_UNIT":"db.service","MESSAGE":"ignored by pattern"}`,
+		`{"__CURSOR":"c4","PRIORITY":"0","_SYSTEMD_UNIT":"db.service","MESSAGE":"disk gone"}`,
+	)
+	cursorFile := filepath.Join(t.TempDir(), "cursor")
+	r := &barktest.Recorder{}
+	w := journald.New(r, &bark.Options{DeviceKey: "key"}, "db.service")
+	w.Command = command
+	w.CursorFile = cursorFile
+	w.Pattern = regexp.MustCompile(`^disk`)
+
+	// journalctl 退出时 Run 返回错误
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("Run() should fail when journalctl exits")
+	}
+	pushes := r.Pushes()
+	if len(pushes) != 2 || pushes[0].Body != "disk failure" || pushes[1].Body != "disk gone" || pushes[1].Level != "critical" {
+		t.Errorf("pushes %+v", pushes)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "--follow --output=json --no-pager --priority=0..3 --unit=db.service --lines=0" {
+		t.Errorf("journalctl args = %q", got)
+	}
+	// 处理过的条目 (包括被过滤的) 都会更新位置
+	if cursor, err := os.ReadFile(cursorFile); err != nil || string(cursor) != "c4\n" {
+		t.Errorf("cursor file = %q, %v", cursor, err)
+	}
+
+	// 重启后从保存的位置继续
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("Run() should fail when journalctl exits")
+	}
+	args, err = os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(string(args)), "--after-cursor=c4") {
+		t.Errorf("journalctl args after restart = %q", args)
+	}
+}
+
+func TestRunInvalidPriority(t *testing.T) {
+	w := journald.New(&barktest.Recorder{}, nil)
+	w.MaxPriority = 8
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("Run() should reject an invalid max priority")
+	}
+}
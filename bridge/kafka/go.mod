@@ -0,0 +1,18 @@
+module github.com/gaoyaxuan/go-bark/bridge/kafka
+
+go 1.21
+
+require (
+	github.com/gaoyaxuan/go-bark v0.0.0-00010101000000-000000000000
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gaoyaxuan/go-bark => ../..
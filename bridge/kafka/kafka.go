@@ -0,0 +1,170 @@
+// Package kafka 消费 Kafka topic 中的记录并转换为 Bark 推送
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+const (
+	// DefaultMaxRetries 单条记录推送失败后的默认重试次数
+	DefaultMaxRetries = 3
+	// DefaultRetryDelay 重试间隔
+	DefaultRetryDelay = 5 * time.Second
+)
+
+// TemplateData 模板渲染数据, Key 和 Headers 分别为记录的键和消息头
+type TemplateData = bridge.MessageData
+
+// Bridge Kafka 消费者组桥接, 记录按 bridge.MessageOptions 转换为推送参数
+//
+// 记录推送成功 (或重试 MaxRetries 次仍失败) 后才提交 offset, 实现至少一次投递.
+// Pusher 为 *bark.Queue 时推送只是入队, 队列已满时阻塞, 消费随之暂停, 从而把背压传递给 Kafka
+type Bridge struct {
+	// Brokers broker 地址列表
+	Brokers []string
+	// Topics 消费的 topic
+	Topics []string
+	// GroupID 消费者组 ID
+	GroupID string
+	// TLSConfig 非空时使用 TLS 连接
+	TLSConfig *tls.Config
+	// SASL 认证机制, 如 plain.Mechanism{} 或 scram.Mechanism(scram.SHA512, user, pass)
+	SASL sasl.Mechanism
+	// StartOffset 消费者组没有已提交 offset 时的起始位置, kafka.FirstOffset 或 kafka.LastOffset (默认)
+	StartOffset int64
+	// MaxRetries 单条记录推送失败后的重试次数, 默认 DefaultMaxRetries, 小于 0 时不重试
+	MaxRetries int
+	// RetryDelay 重试间隔, 默认 DefaultRetryDelay
+	RetryDelay time.Duration
+
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Template 可选, 以推送参数命名的子模板, 数据为 TemplateData
+	Template *template.Template
+	// Logger 记录消费和推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 Bridge
+func New(brokers []string, groupID string, p bark.Pusher, defaults *bark.Options, topics ...string) *Bridge {
+	return &Bridge{Brokers: brokers, GroupID: groupID, Pusher: p, Defaults: defaults, Topics: topics}
+}
+
+func (b *Bridge) logger() *slog.Logger {
+	if b.Logger != nil {
+		return b.Logger
+	}
+	return slog.Default()
+}
+
+// Run 加入消费者组并消费记录, 直到 ctx 结束
+func (b *Bridge) Run(ctx context.Context) error {
+	if len(b.Brokers) == 0 || len(b.Topics) == 0 {
+		return errors.New("kafka: brokers and topics are required")
+	}
+	if b.GroupID == "" {
+		return errors.New("kafka: group id is required")
+	}
+
+	start := b.StartOffset
+	if start == 0 {
+		start = kafka.LastOffset
+	}
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.Brokers,
+		GroupID:     b.GroupID,
+		GroupTopics: b.Topics,
+		StartOffset: start,
+		Dialer: &kafka.Dialer{
+			Timeout:       10 * time.Second,
+			DualStack:     true,
+			TLS:           b.TLSConfig,
+			SASLMechanism: b.SASL,
+		},
+		ErrorLogger: kafka.LoggerFunc(func(msg string, args ...interface{}) {
+			b.logger().Warn("bark kafka bridge: " + fmt.Sprintf(msg, args...))
+		}),
+	})
+	defer r.Close()
+
+	for {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("kafka: fetch: %w", err)
+		}
+		if err := b.handle(ctx, m); err != nil {
+			if ctx.Err() != nil {
+				// 未提交, 重启后重新消费
+				return nil
+			}
+			b.logger().Error("bark kafka bridge: dropping record after retries",
+				"topic", m.Topic, "partition", m.Partition, "offset", m.Offset, "err", err)
+		}
+		if err := r.CommitMessages(ctx, m); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("kafka: commit: %w", err)
+		}
+	}
+}
+
+// handle 推送一条记录, 失败时按 MaxRetries 重试
+func (b *Bridge) handle(ctx context.Context, m kafka.Message) error {
+	o, err := b.Options(m)
+	if err != nil {
+		return err
+	}
+
+	retries := b.MaxRetries
+	if retries == 0 {
+		retries = DefaultMaxRetries
+	}
+	delay := b.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		err = b.Pusher.Push(ctx, o)
+		if err == nil || attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+		b.logger().Warn("bark kafka bridge: push failed, retrying", "topic", m.Topic, "offset", m.Offset, "attempt", attempt+1, "err", err)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Options 将记录转换为推送参数
+func (b *Bridge) Options(m kafka.Message) (*bark.Options, error) {
+	md := bridge.NewMessageData(m.Topic, m.Value)
+	md.Key = string(m.Key)
+	if len(m.Headers) > 0 {
+		md.Headers = make(map[string]string, len(m.Headers))
+		for _, h := range m.Headers {
+			md.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return bridge.MessageOptions(b.Defaults, b.Template, md)
+}
@@ -0,0 +1,52 @@
+package kafka_test
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	barkkafka "github.com/gaoyaxuan/go-bark/bridge/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOptions(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(
+		`{{define "title"}}{{.Topic}}/{{.Key}}{{end}}{{define "body"}}{{.JSON.msg}} ({{index .Headers "source"}}){{end}}`))
+	tests := []struct {
+		name string
+		tmpl *template.Template
+		msg  kafka.Message
+		want bark.Options
+	}{
+		{
+			name: "template with key and headers",
+			tmpl: tmpl,
+			msg: kafka.Message{Topic: "orders", Key: []byte("o-1"), Value: []byte(`{"msg":"payment failed"}`),
+				Headers: []kafka.Header{{Key: "source", Value: []byte("billing")}}},
+			want: bark.Options{DeviceKey: "key", Title: "orders/o-1", Body: "payment failed (billing)", Group: "orders"},
+		},
+		{
+			name: "json fields",
+			msg:  kafka.Message{Topic: "alerts", Value: []byte(`{"title":"Disk","body":"full","level":"critical"}`)},
+			want: bark.Options{DeviceKey: "key", Title: "Disk", Body: "full", Group: "alerts", Level: "critical"},
+		},
+		{
+			name: "plain text",
+			msg:  kafka.Message{Topic: "logs", Value: []byte("restarted\n")},
+			want: bark.Options{DeviceKey: "key", Title: "logs", Body: "restarted", Group: "logs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := barkkafka.New(nil, "group", nil, &bark.Options{DeviceKey: "key"}, "orders")
+			b.Template = tt.tmpl
+			o, err := b.Options(tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Body != tt.want.Body || o.Group != tt.want.Group || o.Level != tt.want.Level {
+				t.Errorf("Options() = %+v\nwant %+v", o, &tt.want)
+			}
+		})
+	}
+}
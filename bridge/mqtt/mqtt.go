@@ -0,0 +1,141 @@
+// Package mqtt 订阅 MQTT 主题并将消息转换为 Bark 推送, 用于连接 Home Assistant, zigbee2mqtt 等
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// TemplateData 模板渲染数据
+type TemplateData = bridge.MessageData
+
+// Bridge MQTT 订阅桥接, 消息按 bridge.MessageOptions 转换为推送参数
+type Bridge struct {
+	// Broker 服务器地址, 如 tcp://localhost:1883, ssl://broker:8883, ws://broker:80/mqtt
+	Broker string
+	// ClientID 客户端 ID, 默认为 go-bark-<时间戳>; 使用持久会话时需固定
+	ClientID string
+	Username string
+	Password string
+	// TLSConfig ssl:// 和 wss:// 连接使用的 TLS 配置
+	TLSConfig *tls.Config
+	// Topics 订阅的主题过滤器, 支持 + 和 # 通配符
+	Topics []string
+	// QoS 订阅使用的服务质量等级 (0, 1, 2)
+	QoS byte
+	// PersistentSession 为 true 时使用持久会话 (CleanSession=false), 断线期间的 QoS 1/2 消息不会丢失
+	PersistentSession bool
+
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Template 可选, 以推送参数命名的子模板, 数据为 TemplateData
+	Template *template.Template
+	// Logger 记录连接和推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 Bridge
+func New(broker string, p bark.Pusher, defaults *bark.Options, topics ...string) *Bridge {
+	return &Bridge{Broker: broker, Pusher: p, Defaults: defaults, Topics: topics}
+}
+
+func (b *Bridge) logger() *slog.Logger {
+	if b.Logger != nil {
+		return b.Logger
+	}
+	return slog.Default()
+}
+
+// Run 连接服务器并订阅, 断线后自动重连, 直到 ctx 结束
+func (b *Bridge) Run(ctx context.Context) error {
+	if len(b.Topics) == 0 {
+		return errors.New("mqtt: no topics to subscribe")
+	}
+	if b.QoS > 2 {
+		return fmt.Errorf("mqtt: invalid qos %d", b.QoS)
+	}
+
+	opts := b.clientOptions(ctx)
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if err := wait(ctx, token); err != nil {
+		return fmt.Errorf("mqtt: connect %s: %w", b.Broker, err)
+	}
+	defer client.Disconnect(250)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *Bridge) clientOptions(ctx context.Context) *paho.ClientOptions {
+	clientID := b.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("go-bark-%d", time.Now().UnixNano())
+	}
+	opts := paho.NewClientOptions().
+		AddBroker(b.Broker).
+		SetClientID(clientID).
+		SetUsername(b.Username).
+		SetPassword(b.Password).
+		SetCleanSession(!b.PersistentSession).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetOrderMatters(false)
+	if b.TLSConfig != nil {
+		opts.SetTLSConfig(b.TLSConfig)
+	}
+	opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		b.logger().Warn("bark mqtt bridge: connection lost", "broker", b.Broker, "err", err)
+	})
+	// 每次 (重新) 连接后订阅, 持久会话下服务端会保留订阅
+	opts.SetOnConnectHandler(func(c paho.Client) {
+		filters := make(map[string]byte, len(b.Topics))
+		for _, t := range b.Topics {
+			filters[t] = b.QoS
+		}
+		token := c.SubscribeMultiple(filters, func(_ paho.Client, msg paho.Message) {
+			b.handle(ctx, msg.Topic(), msg.Payload())
+		})
+		if err := wait(ctx, token); err != nil {
+			b.logger().Error("bark mqtt bridge: subscribe failed", "topics", b.Topics, "err", err)
+		}
+	})
+	return opts
+}
+
+// wait 等待 token 完成或 ctx 结束
+func wait(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bridge) handle(ctx context.Context, topic string, payload []byte) {
+	o, err := b.Options(topic, payload)
+	if err == nil {
+		err = b.Pusher.Push(ctx, o)
+	}
+	if err != nil {
+		b.logger().Error("bark mqtt bridge: push failed", "topic", topic, "err", err)
+	}
+}
+
+// Options 将消息转换为推送参数
+func (b *Bridge) Options(topic string, payload []byte) (*bark.Options, error) {
+	return bridge.MessageOptions(b.Defaults, b.Template, bridge.NewMessageData(topic, payload))
+}
@@ -0,0 +1,21 @@
+module github.com/gaoyaxuan/go-bark/bridge/nats
+
+go 1.21
+
+require (
+	github.com/gaoyaxuan/go-bark v0.0.0-00010101000000-000000000000
+	github.com/nats-io/nats.go v1.31.0
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/nats-io/nkeys v0.4.5 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gaoyaxuan/go-bark => ../..
@@ -0,0 +1,176 @@
+// Package nats 消费 NATS subject (core NATS 或 JetStream) 中的消息并转换为 Bark 推送
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// DefaultRetryDelay JetStream 推送失败后重新投递的默认延迟
+const DefaultRetryDelay = 10 * time.Second
+
+// TemplateData 模板渲染数据
+type TemplateData = bridge.MessageData
+
+// Bridge NATS 订阅桥接, 消息按 bridge.MessageOptions 转换为推送参数
+//
+// Stream 为空时使用 core NATS 订阅 (可选队列组), 断线期间的消息会丢失;
+// 设置 Stream 和 Durable 后使用 JetStream 持久消费者, 推送成功后才确认消息,
+// 失败的消息会在 RetryDelay 后重新投递, 重启后从上次确认的位置继续消费
+type Bridge struct {
+	// URL 服务器地址, 默认 nats.DefaultURL
+	URL string
+	// Subjects 订阅的 subject, 支持 * 和 > 通配符; JetStream 模式下作为消费者的过滤条件
+	Subjects []string
+	// Queue core NATS 队列组, 多个实例之间负载均衡
+	Queue string
+	// Stream JetStream stream 名称, 非空时使用 JetStream
+	Stream string
+	// Durable JetStream 持久消费者名称, 为空时创建临时消费者
+	Durable string
+	// RetryDelay JetStream 推送失败后重新投递的延迟, 默认 DefaultRetryDelay
+	RetryDelay time.Duration
+	// NATSOptions 连接选项, 如 nats.UserCredentials, nats.RootCAs
+	NATSOptions []nats.Option
+
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Template 可选, 以推送参数命名的子模板, 数据为 TemplateData
+	Template *template.Template
+	// Logger 记录连接和推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 core NATS Bridge
+func New(url string, p bark.Pusher, defaults *bark.Options, subjects ...string) *Bridge {
+	return &Bridge{URL: url, Pusher: p, Defaults: defaults, Subjects: subjects}
+}
+
+func (b *Bridge) logger() *slog.Logger {
+	if b.Logger != nil {
+		return b.Logger
+	}
+	return slog.Default()
+}
+
+// Run 连接服务器并消费消息, 直到 ctx 结束
+func (b *Bridge) Run(ctx context.Context) error {
+	if len(b.Subjects) == 0 && b.Stream == "" {
+		return errors.New("nats: no subjects to subscribe")
+	}
+	url := b.URL
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	opts := append([]nats.Option{
+		nats.Name("go-bark"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				b.logger().Warn("bark nats bridge: disconnected", "err", err)
+			}
+		}),
+	}, b.NATSOptions...)
+	nc, err := nats.Connect(url, opts...)
+	if err != nil {
+		return fmt.Errorf("nats: connect %s: %w", url, err)
+	}
+	defer nc.Drain()
+
+	if b.Stream != "" {
+		return b.runJetStream(ctx, nc)
+	}
+	return b.runCore(ctx, nc)
+}
+
+func (b *Bridge) runCore(ctx context.Context, nc *nats.Conn) error {
+	handler := func(msg *nats.Msg) {
+		if err := b.handle(ctx, msg.Subject, msg.Header, msg.Data); err != nil {
+			b.logger().Error("bark nats bridge: push failed", "subject", msg.Subject, "err", err)
+		}
+	}
+	for _, subject := range b.Subjects {
+		var err error
+		if b.Queue != "" {
+			_, err = nc.QueueSubscribe(subject, b.Queue, handler)
+		} else {
+			_, err = nc.Subscribe(subject, handler)
+		}
+		if err != nil {
+			return fmt.Errorf("nats: subscribe %s: %w", subject, err)
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (b *Bridge) runJetStream(ctx context.Context, nc *nats.Conn) error {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return fmt.Errorf("nats: jetstream: %w", err)
+	}
+	cfg := jetstream.ConsumerConfig{
+		Durable:   b.Durable,
+		AckPolicy: jetstream.AckExplicitPolicy,
+	}
+	switch len(b.Subjects) {
+	case 0:
+	case 1:
+		cfg.FilterSubject = b.Subjects[0]
+	default:
+		cfg.FilterSubjects = b.Subjects
+	}
+	cons, err := js.CreateOrUpdateConsumer(ctx, b.Stream, cfg)
+	if err != nil {
+		return fmt.Errorf("nats: create consumer on %s: %w", b.Stream, err)
+	}
+
+	delay := b.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		if err := b.handle(ctx, msg.Subject(), msg.Headers(), msg.Data()); err != nil {
+			b.logger().Error("bark nats bridge: push failed, redelivering", "subject", msg.Subject(), "err", err)
+			_ = msg.NakWithDelay(delay)
+			return
+		}
+		_ = msg.Ack()
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		b.logger().Warn("bark nats bridge: consume error", "stream", b.Stream, "err", err)
+	}))
+	if err != nil {
+		return fmt.Errorf("nats: consume %s: %w", b.Stream, err)
+	}
+	defer cc.Stop()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *Bridge) handle(ctx context.Context, subject string, header nats.Header, data []byte) error {
+	md := bridge.NewMessageData(subject, data)
+	if len(header) > 0 {
+		md.Headers = make(map[string]string, len(header))
+		for k := range header {
+			md.Headers[k] = header.Get(k)
+		}
+	}
+	o, err := bridge.MessageOptions(b.Defaults, b.Template, md)
+	if err != nil {
+		return err
+	}
+	return b.Pusher.Push(ctx, o)
+}
@@ -0,0 +1,230 @@
+// Package ntfy 实现兼容 ntfy 发布接口的接收端, 已经支持 ntfy 的设备和脚本无需修改即可推送到 Bark
+package ntfy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// DefaultLevels ntfy 优先级 (1-5) 到 Bark 级别的映射
+var DefaultLevels = map[int]string{
+	1: "passive",
+	2: "passive",
+	3: "active",
+	4: "timeSensitive",
+	5: "critical",
+}
+
+// priorityNames ntfy 优先级的文字写法
+var priorityNames = map[string]int{
+	"min":     1,
+	"low":     2,
+	"default": 3,
+	"high":    4,
+	"max":     5,
+	"urgent":  5,
+}
+
+// Message 发布的消息, 对应 ntfy 的 JSON 发布格式
+type Message struct {
+	Topic    string   `json:"topic"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags"`
+	Priority int      `json:"priority"`
+	Click    string   `json:"click"`
+	Icon     string   `json:"icon"`
+	Markdown bool     `json:"markdown"`
+}
+
+// Handler ntfy 发布接口兼容接收端
+//
+// 支持 PUT/POST /<topic> (正文为消息, 参数通过 Title, Priority, Tags, Click, Icon 等请求头或同名查询参数传递)
+// 和 POST / 的 JSON 格式; topic 作为 Bark 分组
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Topics 可选, topic 到收件人 (别名或分组名, 由 Client 地址簿解析) 的映射; 设置后未配置的 topic 返回 404
+	Topics map[string][]string
+	// Levels 优先级到 Bark 级别的映射, 默认 DefaultLevels
+	Levels map[int]string
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler, 挂载时需去掉路径前缀, 如 http.StripPrefix("/ntfy", h)
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	body, err := bridge.ReadBody(r, h.MaxBodyBytes)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	var msg Message
+	topic := strings.Trim(r.URL.Path, "/")
+	if topic == "" {
+		if err := json.Unmarshal(body, &msg); err != nil {
+			bridge.Respond(w, http.StatusBadRequest, "invalid JSON message: "+err.Error())
+			return
+		}
+	} else {
+		msg, err = parseRequest(r, topic, body)
+		if err != nil {
+			bridge.Respond(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if msg.Topic == "" || strings.Contains(msg.Topic, "/") {
+		bridge.Respond(w, http.StatusBadRequest, "invalid topic")
+		return
+	}
+
+	o, ok := h.Options(&msg)
+	if !ok {
+		bridge.Respond(w, http.StatusNotFound, "unknown topic: "+msg.Topic)
+		return
+	}
+	if err := h.Pusher.Push(r.Context(), o); err != nil {
+		bridge.Respond(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+
+	// 与 ntfy 相同, 返回发布的消息
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"time":     time.Now().Unix(),
+		"event":    "message",
+		"topic":    msg.Topic,
+		"title":    msg.Title,
+		"message":  msg.Message,
+		"priority": msg.Priority,
+		"tags":     msg.Tags,
+		"click":    msg.Click,
+	})
+}
+
+// parseRequest 从请求头或查询参数读取消息参数
+func parseRequest(r *http.Request, topic string, body []byte) (Message, error) {
+	msg := Message{
+		Topic:   topic,
+		Message: strings.TrimSpace(string(body)),
+		Title:   param(r, "X-Title", "Title", "ti", "t"),
+		Click:   param(r, "X-Click", "Click"),
+		Icon:    param(r, "X-Icon", "Icon"),
+	}
+	if m := param(r, "X-Message", "Message", "m"); m != "" && msg.Message == "" {
+		msg.Message = m
+	}
+	if tags := param(r, "X-Tags", "Tags", "Tag", "ta"); tags != "" {
+		for _, t := range strings.Split(tags, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				msg.Tags = append(msg.Tags, t)
+			}
+		}
+	}
+	switch strings.ToLower(param(r, "X-Markdown", "Markdown", "md")) {
+	case "1", "yes", "true":
+		msg.Markdown = true
+	}
+	if p := param(r, "X-Priority", "Priority", "prio", "p"); p != "" {
+		n, err := parsePriority(p)
+		if err != nil {
+			return msg, err
+		}
+		msg.Priority = n
+	}
+	return msg, nil
+}
+
+// param 依次从请求头和查询参数中查找
+func param(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if v := r.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	q := r.URL.Query()
+	for _, name := range names {
+		if v := q.Get(strings.ToLower(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func parsePriority(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, ok := priorityNames[s]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 5 {
+		return 0, fmt.Errorf("invalid priority: %s (expected 1-5 or min, low, default, high, max, urgent)", s)
+	}
+	return n, nil
+}
+
+// Options 将消息转换为推送参数, topic 未配置时返回 false
+func (h *Handler) Options(msg *Message) (*bark.Options, bool) {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	if h.Topics != nil {
+		to, ok := h.Topics[msg.Topic]
+		if !ok {
+			return nil, false
+		}
+		o.DeviceKey, o.DeviceKeys = "", nil
+		o.Recipients = append([]string(nil), to...)
+	}
+
+	o.Group = msg.Topic
+	o.Title = msg.Title
+	if msg.Markdown {
+		o.Markdown = msg.Message
+	} else {
+		o.Body = msg.Message
+	}
+	if len(msg.Tags) > 0 {
+		o.Subtitle = strings.Join(msg.Tags, ", ")
+	}
+	if msg.Click != "" {
+		o.URL = msg.Click
+	}
+	if msg.Icon != "" {
+		o.Icon = msg.Icon
+	}
+	if msg.Priority != 0 {
+		levels := h.Levels
+		if levels == nil {
+			levels = DefaultLevels
+		}
+		if level := levels[msg.Priority]; level != "" {
+			o.Level = level
+		}
+	}
+	return o, true
+}
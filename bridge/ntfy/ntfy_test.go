@@ -0,0 +1,100 @@
+package ntfy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/ntfy"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		headers map[string]string
+		body    string
+		topics  map[string][]string
+		code    int
+		want    *bark.Options
+	}{
+		{
+			name: "headers", method: http.MethodPut, target: "/backups",
+			headers: map[string]string{"Title": "Backup", "Priority": "urgent", "Tags": "disk, warning", "Click": "https://nas", "X-Icon": "https://nas/icon.png"},
+			body:    "backup failed\n",
+			code:    http.StatusOK,
+			want:    &bark.Options{DeviceKey: "key", Group: "backups", Title: "Backup", Body: "backup failed", Subtitle: "disk, warning", URL: "https://nas", Icon: "https://nas/icon.png", Level: "critical"},
+		},
+		{
+			name: "query parameters", method: http.MethodPost, target: "/alerts?t=Hi&p=2&m=from+query&md=yes",
+			code: http.StatusOK,
+			want: &bark.Options{DeviceKey: "key", Group: "alerts", Title: "Hi", Markdown: "from query", Level: "passive"},
+		},
+		{
+			name: "json", method: http.MethodPost, target: "/",
+			body: `{"topic":"ci","message":"build ok","title":"CI","priority":4,"tags":["ok"]}`,
+			code: http.StatusOK,
+			want: &bark.Options{DeviceKey: "key", Group: "ci", Title: "CI", Body: "build ok", Subtitle: "ok", Level: "timeSensitive"},
+		},
+		{
+			name: "topic recipients", method: http.MethodPost, target: "/ops", body: "x",
+			topics: map[string][]string{"ops": {"alice", "oncall"}},
+			code:   http.StatusOK,
+			want:   &bark.Options{Group: "ops", Body: "x", Recipients: []string{"alice", "oncall"}},
+		},
+		{name: "unknown topic", method: http.MethodPost, target: "/other", body: "x", topics: map[string][]string{"ops": nil}, code: http.StatusNotFound},
+		{name: "invalid priority", method: http.MethodPost, target: "/t", headers: map[string]string{"Priority": "6"}, body: "x", code: http.StatusBadRequest},
+		{name: "nested topic", method: http.MethodPost, target: "/a/b", body: "x", code: http.StatusBadRequest},
+		{name: "json without topic", method: http.MethodPost, target: "/", body: `{"message":"x"}`, code: http.StatusBadRequest},
+		{name: "invalid json", method: http.MethodPost, target: "/", body: `{`, code: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, target: "/t", code: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := ntfy.New(r, &bark.Options{DeviceKey: "key"})
+			h.Topics = tt.topics
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.want == nil {
+				if r.Len() != 0 {
+					t.Errorf("unexpected push %+v", r.Last())
+				}
+				return
+			}
+			got := r.Last()
+			if got == nil || got.DeviceKey != tt.want.DeviceKey || got.Group != tt.want.Group || got.Title != tt.want.Title ||
+				got.Body != tt.want.Body || got.Markdown != tt.want.Markdown || got.Subtitle != tt.want.Subtitle ||
+				got.URL != tt.want.URL || got.Icon != tt.want.Icon || got.Level != tt.want.Level ||
+				strings.Join(got.Recipients, ",") != strings.Join(tt.want.Recipients, ",") {
+				t.Errorf("pushed %+v\nwant %+v", got, tt.want)
+			}
+			if !strings.Contains(w.Body.String(), `"event":"message"`) {
+				t.Errorf("response %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	h := ntfy.New(&barktest.Recorder{}, nil)
+	h.Token = "t"
+	for target, want := range map[string]int{"/x": http.StatusUnauthorized, "/x?token=t": http.StatusOK} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader("hi")))
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/gaoyaxuan/go-bark/bridge/redis
+
+go 1.21
+
+require (
+	github.com/gaoyaxuan/go-bark v0.0.0-00010101000000-000000000000
+	github.com/redis/go-redis/v9 v9.6.1
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/gaoyaxuan/go-bark => ../..
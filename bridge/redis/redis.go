@@ -0,0 +1,343 @@
+// Package redis 消费 Redis pub/sub 频道或 stream 中的消息并转换为 Bark 推送
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+const (
+	// DefaultBlock XREAD/XREADGROUP 的默认阻塞时间
+	DefaultBlock = 5 * time.Second
+	// DefaultClaimIdle 消费者组中未确认消息被重新认领的默认空闲时间
+	DefaultClaimIdle = time.Minute
+)
+
+// TemplateData 模板渲染数据, stream 消息的 Key 为消息 ID
+type TemplateData = bridge.MessageData
+
+// Bridge Redis 桥接, 消息按 bridge.MessageOptions 转换为推送参数
+//
+// Channels 使用 pub/sub 订阅 (包含 * ? [ 时按模式订阅), 断线期间的消息会丢失;
+// Streams 从 stream 读取, 设置 Group 后使用消费者组: 推送成功后才 XACK,
+// 失败的消息在 ClaimIdle 后重新认领并重试, 实现至少一次投递
+type Bridge struct {
+	Client redis.UniversalClient
+	// Channels pub/sub 频道
+	Channels []string
+	// Streams stream 键名
+	Streams []string
+	// Group 消费者组名称, 不存在时自动创建 (从最新消息开始)
+	Group string
+	// Consumer 消费者名称, 默认为 hostname-pid
+	Consumer string
+	// Field stream 消息中作为消息内容的字段; 为空时全部字段作为 JSON 对象,
+	// 例如 XADD alerts * title 磁盘告警 body "使用率 95%"
+	Field string
+	// Block XREAD/XREADGROUP 的阻塞时间, 默认 DefaultBlock
+	Block time.Duration
+	// ClaimIdle 未确认消息被重新认领的空闲时间, 默认 DefaultClaimIdle
+	ClaimIdle time.Duration
+
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Template 可选, 以推送参数命名的子模板, 数据为 TemplateData
+	Template *template.Template
+	// Logger 记录读取和推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 Bridge, 需再设置 Channels 或 Streams
+func New(client redis.UniversalClient, p bark.Pusher, defaults *bark.Options) *Bridge {
+	return &Bridge{Client: client, Pusher: p, Defaults: defaults}
+}
+
+func (b *Bridge) logger() *slog.Logger {
+	if b.Logger != nil {
+		return b.Logger
+	}
+	return slog.Default()
+}
+
+// Run 消费配置的频道和 stream, 直到 ctx 结束或出现不可恢复的错误
+func (b *Bridge) Run(ctx context.Context) error {
+	if len(b.Channels) == 0 && len(b.Streams) == 0 {
+		return errors.New("redis: no channels or streams to consume")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errc := make(chan error, 2)
+	n := 0
+	if len(b.Channels) > 0 {
+		n++
+		go func() { errc <- b.runPubSub(ctx) }()
+	}
+	if len(b.Streams) > 0 {
+		n++
+		go func() { errc <- b.runStreams(ctx) }()
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		if e := <-errc; e != nil && err == nil {
+			err = e
+			cancel()
+		}
+	}
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (b *Bridge) runPubSub(ctx context.Context) error {
+	var channels, patterns []string
+	for _, ch := range b.Channels {
+		if strings.ContainsAny(ch, "*?[") {
+			patterns = append(patterns, ch)
+		} else {
+			channels = append(channels, ch)
+		}
+	}
+
+	ps := b.Client.Subscribe(ctx, channels...)
+	defer ps.Close()
+	if len(patterns) > 0 {
+		if err := ps.PSubscribe(ctx, patterns...); err != nil {
+			return fmt.Errorf("redis: psubscribe: %w", err)
+		}
+	}
+	// 等待订阅确认, 尽早暴露连接错误
+	if _, err := ps.Receive(ctx); err != nil {
+		return fmt.Errorf("redis: subscribe: %w", err)
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := b.push(ctx, bridge.NewMessageData(msg.Channel, []byte(msg.Payload))); err != nil {
+				b.logger().Error("bark redis bridge: push failed", "channel", msg.Channel, "err", err)
+			}
+		}
+	}
+}
+
+func (b *Bridge) runStreams(ctx context.Context) error {
+	if b.Group != "" {
+		return b.runGroup(ctx)
+	}
+
+	// 不使用消费者组时从最新消息开始读取
+	ids := make([]string, len(b.Streams))
+	for i := range ids {
+		ids[i] = "$"
+	}
+	for ctx.Err() == nil {
+		res, err := b.Client.XRead(ctx, &redis.XReadArgs{
+			Streams: append(append([]string(nil), b.Streams...), ids...),
+			Block:   b.block(),
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			b.logger().Warn("bark redis bridge: xread failed", "err", err)
+			sleep(ctx, time.Second)
+			continue
+		}
+		for _, stream := range res {
+			for _, msg := range stream.Messages {
+				if err := b.push(ctx, b.streamData(stream.Stream, msg)); err != nil {
+					b.logger().Error("bark redis bridge: push failed", "stream", stream.Stream, "id", msg.ID, "err", err)
+				}
+				for i, s := range b.Streams {
+					if s == stream.Stream {
+						ids[i] = msg.ID
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Bridge) runGroup(ctx context.Context) error {
+	for _, stream := range b.Streams {
+		err := b.Client.XGroupCreateMkStream(ctx, stream, b.Group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("redis: create group %s on %s: %w", b.Group, stream, err)
+		}
+	}
+
+	consumer := b.consumer()
+	// 先处理本消费者上次未确认的消息
+	for _, stream := range b.Streams {
+		b.readGroup(ctx, consumer, stream, "0")
+	}
+
+	lastClaim := time.Now()
+	for ctx.Err() == nil {
+		streams := append([]string(nil), b.Streams...)
+		for range b.Streams {
+			streams = append(streams, ">")
+		}
+		res, err := b.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.Group,
+			Consumer: consumer,
+			Streams:  streams,
+			Block:    b.block(),
+		}).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil
+			}
+			b.logger().Warn("bark redis bridge: xreadgroup failed", "err", err)
+			sleep(ctx, time.Second)
+		default:
+			for _, stream := range res {
+				for _, msg := range stream.Messages {
+					b.handleGroup(ctx, stream.Stream, msg)
+				}
+			}
+		}
+
+		if time.Since(lastClaim) >= b.claimIdle() {
+			for _, stream := range b.Streams {
+				b.claim(ctx, consumer, stream)
+			}
+			lastClaim = time.Now()
+		}
+	}
+	return nil
+}
+
+// readGroup 读取并处理本消费者 id 之后的待确认消息
+func (b *Bridge) readGroup(ctx context.Context, consumer, stream, id string) {
+	res, err := b.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    b.Group,
+		Consumer: consumer,
+		Streams:  []string{stream, id},
+		Count:    100,
+		Block:    -1,
+	}).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			b.logger().Warn("bark redis bridge: read pending failed", "stream", stream, "err", err)
+		}
+		return
+	}
+	for _, s := range res {
+		for _, msg := range s.Messages {
+			b.handleGroup(ctx, s.Stream, msg)
+		}
+	}
+}
+
+// claim 认领空闲超过 ClaimIdle 的未确认消息 (包括其他已下线消费者的消息) 并重试
+func (b *Bridge) claim(ctx context.Context, consumer, stream string) {
+	msgs, _, err := b.Client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    b.Group,
+		Consumer: consumer,
+		MinIdle:  b.claimIdle(),
+		Start:    "0-0",
+		Count:    100,
+	}).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			b.logger().Warn("bark redis bridge: xautoclaim failed", "stream", stream, "err", err)
+		}
+		return
+	}
+	for _, msg := range msgs {
+		b.handleGroup(ctx, stream, msg)
+	}
+}
+
+// handleGroup 推送成功后确认消息, 失败时保留在待确认列表中等待重新认领
+func (b *Bridge) handleGroup(ctx context.Context, stream string, msg redis.XMessage) {
+	if err := b.push(ctx, b.streamData(stream, msg)); err != nil {
+		b.logger().Error("bark redis bridge: push failed, will retry", "stream", stream, "id", msg.ID, "err", err)
+		return
+	}
+	if err := b.Client.XAck(ctx, stream, b.Group, msg.ID).Err(); err != nil {
+		b.logger().Warn("bark redis bridge: xack failed", "stream", stream, "id", msg.ID, "err", err)
+	}
+}
+
+// streamData 将 stream 消息转换为模板数据
+func (b *Bridge) streamData(stream string, msg redis.XMessage) bridge.MessageData {
+	var payload []byte
+	if v, ok := msg.Values[b.Field]; ok && b.Field != "" {
+		payload = []byte(fmt.Sprint(v))
+	} else {
+		payload, _ = json.Marshal(msg.Values)
+	}
+	md := bridge.NewMessageData(stream, payload)
+	md.Key = msg.ID
+	return md
+}
+
+func (b *Bridge) push(ctx context.Context, md bridge.MessageData) error {
+	o, err := bridge.MessageOptions(b.Defaults, b.Template, md)
+	if err != nil {
+		return err
+	}
+	return b.Pusher.Push(ctx, o)
+}
+
+func (b *Bridge) consumer() string {
+	if b.Consumer != "" {
+		return b.Consumer
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (b *Bridge) block() time.Duration {
+	if b.Block > 0 {
+		return b.Block
+	}
+	return DefaultBlock
+}
+
+func (b *Bridge) claimIdle() time.Duration {
+	if b.ClaimIdle > 0 {
+		return b.ClaimIdle
+	}
+	return DefaultClaimIdle
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,270 @@
+// Package sentry 实现 Sentry 告警 webhook 接收端, 支持旧版 WebHooks 插件和 Integration Platform
+// (event_alert, issue) 两种格式
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Issue 从请求体中提取的告警信息
+type Issue struct {
+	// Kind 告警类型: alert (告警规则触发), new (新问题), regression (问题复发), 或 issue 资源的其他 action
+	Kind        string
+	Title       string
+	Culprit     string
+	Level       string
+	Environment string
+	Project     string
+	URL         string
+	ID          string
+}
+
+// DefaultLevels Sentry 事件级别到 Bark 级别的映射
+var DefaultLevels = map[string]string{
+	"fatal":   "timeSensitive",
+	"error":   "active",
+	"warning": "active",
+	"info":    "passive",
+	"debug":   "passive",
+}
+
+// TemplateData 自定义模板的渲染数据
+type TemplateData struct {
+	Issue
+	// Raw 原始请求体
+	Raw map[string]interface{}
+}
+
+// Handler Sentry webhook 接收端
+//
+// 新问题和复发问题的级别分别提升为 NewIssueLevel, RegressionLevel, 优先于事件级别映射
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Levels Sentry 事件级别到 Bark 级别的映射, 默认 DefaultLevels
+	Levels map[string]string
+	// NewIssueLevel 新问题使用的级别, 默认 timeSensitive
+	NewIssueLevel string
+	// RegressionLevel 复发问题使用的级别, 默认 timeSensitive
+	RegressionLevel string
+	// Template 可选, 以推送参数命名的子模板覆盖默认内容, 数据为 TemplateData
+	Template *template.Template
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	body, err := bridge.ReadBody(r, 0)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	// Integration Platform 在 Sentry-Hook-Resource 中标明资源类型, installation 等事件直接忽略
+	resource := r.Header.Get("Sentry-Hook-Resource")
+	if resource != "" && resource != "event_alert" && resource != "issue" {
+		bridge.Respond(w, http.StatusOK, "ignored")
+		return
+	}
+
+	issue, raw, err := Parse(body)
+	if err != nil {
+		bridge.Respond(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.Handle(r.Context(), issue, raw); err != nil {
+		bridge.Respond(w, bridge.PushStatus(err), err.Error())
+		return
+	}
+	bridge.Respond(w, http.StatusOK, "success")
+}
+
+// legacyPayload 旧版 WebHooks 插件格式
+type legacyPayload struct {
+	ID          string `json:"id"`
+	ProjectName string `json:"project_name"`
+	Culprit     string `json:"culprit"`
+	Level       string `json:"level"`
+	URL         string `json:"url"`
+	Message     string `json:"message"`
+	Event       struct {
+		Title       string `json:"title"`
+		Environment string `json:"environment"`
+	} `json:"event"`
+}
+
+// platformPayload Integration Platform 格式
+type platformPayload struct {
+	Action string `json:"action"`
+	Data   struct {
+		Event *struct {
+			Title       string `json:"title"`
+			Culprit     string `json:"culprit"`
+			Level       string `json:"level"`
+			Environment string `json:"environment"`
+			WebURL      string `json:"web_url"`
+			IssueID     string `json:"issue_id"`
+			Project     int64  `json:"project"`
+		} `json:"event"`
+		Issue *struct {
+			ID        string `json:"id"`
+			Title     string `json:"title"`
+			Culprit   string `json:"culprit"`
+			Level     string `json:"level"`
+			Substatus string `json:"substatus"`
+			Permalink string `json:"permalink"`
+			WebURL    string `json:"web_url"`
+			Project   struct {
+				Slug string `json:"slug"`
+			} `json:"project"`
+		} `json:"issue"`
+	} `json:"data"`
+}
+
+// Parse 解析 Sentry webhook 请求体
+func Parse(body []byte) (*Issue, map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid sentry payload: %w", err)
+	}
+
+	if _, ok := raw["data"]; ok {
+		var p platformPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, nil, fmt.Errorf("invalid sentry payload: %w", err)
+		}
+		switch {
+		case p.Data.Event != nil:
+			e := p.Data.Event
+			return &Issue{
+				Kind:        "alert",
+				Title:       e.Title,
+				Culprit:     e.Culprit,
+				Level:       e.Level,
+				Environment: e.Environment,
+				URL:         e.WebURL,
+				ID:          e.IssueID,
+			}, raw, nil
+		case p.Data.Issue != nil:
+			i := p.Data.Issue
+			kind := p.Action
+			switch {
+			case p.Action == "created":
+				kind = "new"
+			case p.Action == "unresolved" && i.Substatus == "regressed":
+				kind = "regression"
+			}
+			return &Issue{
+				Kind:    kind,
+				Title:   i.Title,
+				Culprit: i.Culprit,
+				Level:   i.Level,
+				Project: i.Project.Slug,
+				URL:     bridge.FirstNonEmpty(i.Permalink, i.WebURL),
+				ID:      i.ID,
+			}, raw, nil
+		default:
+			return nil, nil, fmt.Errorf("unsupported sentry payload: action %q", p.Action)
+		}
+	}
+
+	var p legacyPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, nil, fmt.Errorf("invalid sentry payload: %w", err)
+	}
+	return &Issue{
+		Kind:        "alert",
+		Title:       bridge.FirstNonEmpty(p.Event.Title, p.Message),
+		Culprit:     p.Culprit,
+		Level:       p.Level,
+		Environment: p.Event.Environment,
+		Project:     p.ProjectName,
+		URL:         p.URL,
+		ID:          p.ID,
+	}, raw, nil
+}
+
+// Handle 推送一条 Sentry 告警
+func (h *Handler) Handle(ctx context.Context, issue *Issue, raw map[string]interface{}) error {
+	o, err := h.Options(issue, raw)
+	if err != nil {
+		return err
+	}
+	return h.Pusher.Push(ctx, o)
+}
+
+// Options 将告警转换为推送参数
+func (h *Handler) Options(issue *Issue, raw map[string]interface{}) (*bark.Options, error) {
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+
+	title := bridge.FirstNonEmpty(issue.Title, "Sentry issue")
+	switch issue.Kind {
+	case "new":
+		title = "[New] " + title
+	case "regression":
+		title = "[Regression] " + title
+	}
+	o.Title = title
+
+	var sub []string
+	for _, s := range []string{issue.Project, issue.Environment} {
+		if s != "" {
+			sub = append(sub, s)
+		}
+	}
+	o.Subtitle = strings.Join(sub, " · ")
+	o.Body = bridge.FirstNonEmpty(issue.Culprit, issue.Title)
+	if issue.URL != "" {
+		o.URL = issue.URL
+	}
+	if o.Group == "" {
+		o.Group = bridge.FirstNonEmpty(issue.Project, "sentry")
+	}
+	if level := h.level(issue); level != "" {
+		o.Level = level
+	}
+
+	if _, err := bridge.Render(h.Template, TemplateData{Issue: *issue, Raw: raw}, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (h *Handler) level(issue *Issue) string {
+	switch issue.Kind {
+	case "new":
+		return bridge.FirstNonEmpty(h.NewIssueLevel, "timeSensitive")
+	case "regression":
+		return bridge.FirstNonEmpty(h.RegressionLevel, "timeSensitive")
+	}
+	levels := h.Levels
+	if levels == nil {
+		levels = DefaultLevels
+	}
+	return levels[strings.ToLower(issue.Level)]
+}
@@ -0,0 +1,150 @@
+package sentry_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/sentry"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want sentry.Issue
+	}{
+		{
+			name: "legacy webhook",
+			body: `{"id":"42","project_name":"api","culprit":"handlers.py in get","level":"error","url":"https://sentry.io/i/42","message":"fallback","event":{"title":"ZeroDivisionError","environment":"prod"}}`,
+			want: sentry.Issue{Kind: "alert", Title: "ZeroDivisionError", Culprit: "handlers.py in get", Level: "error", Environment: "prod", Project: "api", URL: "https://sentry.io/i/42", ID: "42"},
+		},
+		{
+			name: "legacy webhook without event title",
+			body: `{"id":"1","message":"something broke"}`,
+			want: sentry.Issue{Kind: "alert", Title: "something broke", ID: "1"},
+		},
+		{
+			name: "event alert",
+			body: `{"action":"triggered","data":{"event":{"title":"TypeError","culprit":"app.js","level":"fatal","environment":"staging","web_url":"https://sentry.io/e/1","issue_id":"7"}}}`,
+			want: sentry.Issue{Kind: "alert", Title: "TypeError", Culprit: "app.js", Level: "fatal", Environment: "staging", URL: "https://sentry.io/e/1", ID: "7"},
+		},
+		{
+			name: "new issue",
+			body: `{"action":"created","data":{"issue":{"id":"9","title":"KeyError","level":"error","permalink":"https://sentry.io/p/9","web_url":"https://sentry.io/w/9","project":{"slug":"web"}}}}`,
+			want: sentry.Issue{Kind: "new", Title: "KeyError", Level: "error", Project: "web", URL: "https://sentry.io/p/9", ID: "9"},
+		},
+		{
+			name: "regression",
+			body: `{"action":"unresolved","data":{"issue":{"id":"9","title":"KeyError","substatus":"regressed","web_url":"https://sentry.io/w/9"}}}`,
+			want: sentry.Issue{Kind: "regression", Title: "KeyError", URL: "https://sentry.io/w/9", ID: "9"},
+		},
+		{
+			name: "other issue action",
+			body: `{"action":"resolved","data":{"issue":{"id":"9","title":"KeyError"}}}`,
+			want: sentry.Issue{Kind: "resolved", Title: "KeyError", ID: "9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue, raw, err := sentry.Parse([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *issue != tt.want {
+				t.Errorf("Parse() = %+v\nwant %+v", *issue, tt.want)
+			}
+			if raw == nil {
+				t.Error("raw payload not returned")
+			}
+		})
+	}
+
+	for _, body := range []string{`not json`, `{"action":"created","data":{}}`, `{"data":"x"}`} {
+		if _, _, err := sentry.Parse([]byte(body)); err == nil {
+			t.Errorf("Parse(%s) should fail", body)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler *sentry.Handler
+		issue   sentry.Issue
+		want    bark.Options
+	}{
+		{
+			name:    "alert",
+			handler: sentry.New(nil, &bark.Options{DeviceKey: "key"}),
+			issue:   sentry.Issue{Kind: "alert", Title: "TypeError", Culprit: "app.js", Level: "Fatal", Environment: "prod", Project: "web", URL: "https://sentry.io/1"},
+			want:    bark.Options{DeviceKey: "key", Title: "TypeError", Subtitle: "web · prod", Body: "app.js", URL: "https://sentry.io/1", Group: "web", Level: "timeSensitive"},
+		},
+		{
+			name:    "new issue",
+			handler: &sentry.Handler{NewIssueLevel: "critical"},
+			issue:   sentry.Issue{Kind: "new", Title: "KeyError", Level: "info"},
+			want:    bark.Options{Title: "[New] KeyError", Body: "KeyError", Group: "sentry", Level: "critical"},
+		},
+		{
+			name:    "regression",
+			handler: &sentry.Handler{},
+			issue:   sentry.Issue{Kind: "regression", Environment: "prod"},
+			want:    bark.Options{Title: "[Regression] Sentry issue", Subtitle: "prod", Group: "sentry", Level: "timeSensitive"},
+		},
+		{
+			name:    "unknown level",
+			handler: &sentry.Handler{},
+			issue:   sentry.Issue{Kind: "alert", Title: "x", Level: "loud"},
+			want:    bark.Options{Title: "x", Body: "x", Group: "sentry"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := tt.handler.Options(&tt.issue, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Subtitle != tt.want.Subtitle || o.Body != tt.want.Body ||
+				o.URL != tt.want.URL || o.Group != tt.want.Group || o.Level != tt.want.Level {
+				t.Errorf("Options() = %+v\nwant %+v", o, &tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		body     string
+		code     int
+		pushes   int
+	}{
+		{"legacy", "", `{"id":"1","message":"x"}`, http.StatusOK, 1},
+		{"issue resource", "issue", `{"action":"created","data":{"issue":{"id":"1","title":"x"}}}`, http.StatusOK, 1},
+		{"installation is ignored", "installation", `{"action":"created"}`, http.StatusOK, 0},
+		{"invalid payload", "", `[`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := sentry.New(r, &bark.Options{DeviceKey: "key"})
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.resource != "" {
+				req.Header.Set("Sentry-Hook-Resource", tt.resource)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if r.Len() != tt.pushes {
+				t.Errorf("pushes = %d, want %d", r.Len(), tt.pushes)
+			}
+		})
+	}
+}
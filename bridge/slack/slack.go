@@ -0,0 +1,89 @@
+// Package slack 实现兼容 Slack incoming webhook 的接收端, 让支持 "Slack webhook" 的工具无需修改即可推送到 Bark
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Slack 消息类型, 定义和转换规则见 bark.SlackPayload
+type (
+	Payload    = bark.SlackPayload
+	Block      = bark.SlackBlock
+	Text       = bark.SlackText
+	Attachment = bark.SlackAttachment
+	Field      = bark.SlackField
+)
+
+// Handler Slack incoming webhook 兼容接收端, 成功时与 Slack 一样返回纯文本 ok
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler
+func New(p bark.Pusher, defaults *bark.Options) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "invalid_method", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+		return
+	}
+	body, err := bridge.ReadBody(r, h.MaxBodyBytes)
+	if err != nil {
+		http.Error(w, "request_too_large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// 兼容 application/x-www-form-urlencoded 的 payload=<json>
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		body = []byte(form.Get("payload"))
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	p.Apply(o)
+	if o.Title == "" && o.Body == "" {
+		http.Error(w, "no_text", http.StatusBadRequest)
+		return
+	}
+	if err := h.Pusher.Push(r.Context(), o); err != nil {
+		http.Error(w, err.Error(), bridge.PushStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok"))
+}
+
+// Format 将 Slack mrkdwn 转换为纯文本, 同 bark.FormatSlack
+func Format(s string) string {
+	return bark.FormatSlack(s)
+}
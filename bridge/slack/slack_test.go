@@ -0,0 +1,78 @@
+package slack_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/slack"
+)
+
+func TestHandler(t *testing.T) {
+	form := url.Values{"payload": {`{"text":"from form"}`}}.Encode()
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		err         error
+		code        int
+		want        *bark.Options
+	}{
+		{
+			name: "text", method: http.MethodPost, target: "/?token=t", contentType: "application/json",
+			body: `{"text":"deploy <https://ci/1|#1> done :rocket:","username":"ci","channel":"#deploys","icon_url":"https://ci/icon.png"}`,
+			code: http.StatusOK,
+			want: &bark.Options{Body: "deploy #1 (https://ci/1) done 🚀", Subtitle: "ci", Group: "deploys", URL: "https://ci/1", Icon: "https://ci/icon.png"},
+		},
+		{
+			name: "blocks and attachments", method: http.MethodPost, target: "/?token=t", contentType: "application/json",
+			body: `{"blocks":[{"type":"header","text":{"type":"plain_text","text":"Build failed"}},{"type":"section","text":{"type":"mrkdwn","text":"job *test*"},"fields":[{"type":"mrkdwn","text":"branch: main"}]}],
+				"attachments":[{"title":"Details","title_link":"https://ci/2","fields":[{"title":"Duration","value":"3m"}],"footer":"ci bot"}]}`,
+			code: http.StatusOK,
+			want: &bark.Options{Title: "Build failed", Body: "job *test*\nbranch: main\nDetails\nDuration: 3m\nci bot", URL: "https://ci/2"},
+		},
+		{
+			name: "form payload", method: http.MethodPost, target: "/?token=t", contentType: "application/x-www-form-urlencoded",
+			body: form, code: http.StatusOK, want: &bark.Options{Body: "from form"},
+		},
+		{name: "wrong method", method: http.MethodGet, target: "/?token=t", code: http.StatusMethodNotAllowed},
+		{name: "bad token", method: http.MethodPost, target: "/", body: `{"text":"x"}`, code: http.StatusForbidden},
+		{name: "invalid json", method: http.MethodPost, target: "/?token=t", body: `{`, code: http.StatusBadRequest},
+		{name: "no text", method: http.MethodPost, target: "/?token=t", body: `{"username":"ci"}`, code: http.StatusBadRequest},
+		{name: "push failure", method: http.MethodPost, target: "/?token=t", body: `{"text":"x"}`, err: errors.New("down"), code: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{Err: tt.err}
+			h := slack.New(r, &bark.Options{DeviceKey: "key"})
+			h.Token = "t"
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.code == http.StatusOK && w.Body.String() != "ok" {
+				t.Errorf("response body = %q, want ok", w.Body)
+			}
+			if tt.want == nil {
+				return
+			}
+			got := r.Last()
+			if got == nil || got.DeviceKey != "key" || got.Title != tt.want.Title || got.Body != tt.want.Body || got.Subtitle != tt.want.Subtitle ||
+				got.Group != tt.want.Group || got.URL != tt.want.URL || got.Icon != tt.want.Icon {
+				t.Errorf("pushed %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Mail 解析后的邮件
+type Mail struct {
+	// From 发件人, 优先使用 From 头中的显示名称
+	From string
+	// FromAddress 发件人地址
+	FromAddress string
+	// To SMTP 会话中的收件人地址 (RCPT TO)
+	To      []string
+	Subject string
+	Date    time.Time
+	// Text 纯文本正文; 只有 HTML 正文时为去掉标签后的文本
+	Text string
+	// Header 原始邮件头
+	Header mail.Header
+}
+
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		// 只内置 UTF-8 和 ASCII, 其他字符集原样保留
+		return input, nil
+	},
+}
+
+// ParseMail 解析 RFC 5322 邮件, 支持 multipart 以及 base64/quoted-printable 编码
+func ParseMail(data []byte) (*Mail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse mail: %w", err)
+	}
+
+	m := &Mail{Header: msg.Header}
+	if subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		m.Subject = strings.TrimSpace(subject)
+	} else {
+		m.Subject = strings.TrimSpace(msg.Header.Get("Subject"))
+	}
+	if addr, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		m.FromAddress = addr.Address
+		m.From = addr.Name
+		if name, err := wordDecoder.DecodeHeader(addr.Name); err == nil {
+			m.From = name
+		}
+		if m.From == "" {
+			m.From = addr.Address
+		}
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		m.Date = date
+	}
+
+	text, html, err := readBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" && html != "" {
+		text = stripHTML(html)
+	}
+	m.Text = strings.TrimSpace(normalizeNewlines(text))
+	return m, nil
+}
+
+// readBody 读取正文, 返回纯文本和 HTML 部分
+func readBody(contentType, encoding string, r io.Reader) (text, htmlText string, err error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return text, htmlText, fmt.Errorf("parse multipart: %w", err)
+			}
+			// 跳过附件
+			if disp, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disp == "attachment" {
+				continue
+			}
+			t, h, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return text, htmlText, err
+			}
+			if text == "" {
+				text = t
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+		return text, htmlText, nil
+	}
+
+	body, err := io.ReadAll(decodeTransfer(encoding, r))
+	if err != nil {
+		return "", "", fmt.Errorf("decode body: %w", err)
+	}
+	switch mediaType {
+	case "text/plain":
+		return string(body), "", nil
+	case "text/html":
+		return "", string(body), nil
+	default:
+		return "", "", nil
+	}
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineStripper{r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// newlineStripper 去掉 base64 正文中的换行
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	j := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
+
+var (
+	blockTags  = regexp.MustCompile(`(?i)<(br|/p|/div|/tr|/h[1-6]|/li)[^>]*>`)
+	skipTags   = regexp.MustCompile(`(?is)<(style|script)[^>]*>.*?</(style|script)>`)
+	anyTag     = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripHTML 将 HTML 转换为纯文本
+func stripHTML(s string) string {
+	s = skipTags.ReplaceAllString(s, "")
+	s = blockTags.ReplaceAllString(s, "\n")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(normalizeNewlines(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
@@ -0,0 +1,145 @@
+package smtp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark/bridge/smtp"
+)
+
+// crlf 将测试中书写的换行转换为邮件使用的 CRLF
+func crlf(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
+
+func TestParseMail(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		from        string
+		fromAddress string
+		subject     string
+		text        string
+	}{
+		{
+			name: "plain text",
+			data: `From: Cron Daemon <root@example.com>
+Subject: backup finished
+
+done in 3m
+`,
+			from: "Cron Daemon", fromAddress: "root@example.com", subject: "backup finished", text: "done in 3m",
+		},
+		{
+			name: "address without name",
+			data: `From: root@example.com
+Subject: x
+
+body
+`,
+			from: "root@example.com", fromAddress: "root@example.com", subject: "x", text: "body",
+		},
+		{
+			name: "encoded words",
+			data: `From: =?UTF-8?B?5ZGK6K2m?= <alert@example.com>
+Subject: =?UTF-8?Q?disk_=E6=BB=A1?=
+
+x
+`,
+			from: "告警", fromAddress: "alert@example.com", subject: "disk 满", text: "x",
+		},
+		{
+			name: "base64 body",
+			data: `From: a@example.com
+Subject: b64
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: base64
+
+aGVsbG8g
+5LiW55WM
+`,
+			from: "a@example.com", fromAddress: "a@example.com", subject: "b64", text: "hello 世界",
+		},
+		{
+			name: "quoted printable body",
+			data: `From: a@example.com
+Subject: qp
+Content-Transfer-Encoding: quoted-printable
+
+a long line that is =
+soft wrapped =3D ok
+`,
+			from: "a@example.com", fromAddress: "a@example.com", subject: "qp", text: "a long line that is soft wrapped = ok",
+		},
+		{
+			name: "multipart prefers text and skips attachments",
+			data: `From: a@example.com
+Subject: multi
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/html
+
+<p>html version</p>
+--inner
+Content-Type: text/plain
+
+text version
+--inner--
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment; filename="log.txt"
+
+attached log
+--outer--
+`,
+			from: "a@example.com", fromAddress: "a@example.com", subject: "multi", text: "text version",
+		},
+		{
+			name: "html only",
+			data: `From: a@example.com
+Subject: html
+Content-Type: text/html; charset=utf-8
+
+<html><head><style>p { color: red }</style></head>
+<body><h1>Build failed</h1><p>job &amp; step</p><script>x()</script><div>see   <a href="#">log</a></div></body></html>
+`,
+			from: "a@example.com", fromAddress: "a@example.com", subject: "html", text: "Build failed\njob & step\nsee   log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := smtp.ParseMail(crlf(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.From != tt.from || m.FromAddress != tt.fromAddress || m.Subject != tt.subject {
+				t.Errorf("from=%q address=%q subject=%q", m.From, m.FromAddress, m.Subject)
+			}
+			if m.Text != tt.text {
+				t.Errorf("text = %q, want %q", m.Text, tt.text)
+			}
+		})
+	}
+}
+
+func TestParseMailDate(t *testing.T) {
+	m, err := smtp.ParseMail(crlf("From: a@example.com\nDate: Mon, 02 Jan 2006 15:04:05 -0700\n\nx\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC); !m.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", m.Date, want)
+	}
+}
+
+func TestParseMailInvalid(t *testing.T) {
+	if _, err := smtp.ParseMail([]byte("not a header line\r\n")); err == nil {
+		t.Error("ParseMail() should fail on a message without headers")
+	}
+}
@@ -0,0 +1,455 @@
+// Package smtp 实现一个最小的 SMTP 接收服务, 将只能发送邮件告警的设备 (NAS, UPS, 路由器等)
+// 的邮件转换为 Bark 推送
+package smtp
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+const (
+	// DefaultAddr 默认监听地址
+	DefaultAddr = ":2525"
+	// DefaultMaxMessageBytes 默认邮件大小上限
+	DefaultMaxMessageBytes = 10 << 20
+	// DefaultMaxRecipients 单封邮件默认最多收件人数
+	DefaultMaxRecipients = 50
+)
+
+// ErrServerClosed Serve 在 ctx 结束后返回
+var ErrServerClosed = errors.New("smtp: server closed")
+
+// Server SMTP 接收服务
+//
+// 每封邮件生成一条推送: 发件人作为副标题, 主题作为标题, 纯文本正文 (或去掉标签的 HTML) 作为正文;
+// 收件人地址通过 Routes 映射为收件人, 未匹配的地址使用 Defaults 中的设备
+type Server struct {
+	// Addr 监听地址, 默认 DefaultAddr
+	Addr string
+	// Domain 问候语中的主机名, 默认为本机 hostname
+	Domain string
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Routes 收件人地址到收件人 (别名或分组名, 由 Client 地址簿解析) 的映射
+	// 键可以是完整地址 (nas@alerts.local), 本地部分 (nas) 或通配符 *, 匹配时不区分大小写
+	Routes map[string][]string
+	// RejectUnrouted 为 true 时拒绝 Routes 中没有的收件人地址
+	RejectUnrouted bool
+	// Username, Password 非空时要求客户端通过 AUTH PLAIN/LOGIN 认证
+	Username string
+	Password string
+	// TLSConfig 非空时支持 STARTTLS
+	TLSConfig *tls.Config
+	// Template 可选, 以推送参数命名的子模板覆盖默认内容, 数据为 *Mail
+	Template *template.Template
+	// MaxMessageBytes 邮件大小上限, 默认 DefaultMaxMessageBytes
+	MaxMessageBytes int64
+	// ReadTimeout 单条命令的读取超时, 默认 5 分钟
+	ReadTimeout time.Duration
+	// Logger 记录推送失败等错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建 Server
+func New(addr string, p bark.Pusher, defaults *bark.Options) *Server {
+	return &Server{Addr: addr, Pusher: p, Defaults: defaults}
+}
+
+// ListenAndServe 监听 Addr 并处理连接, ctx 结束时关闭监听并返回 ErrServerClosed
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve 在 l 上接受连接, ctx 结束时关闭 l 并等待进行中的会话结束
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+func (s *Server) domain() string {
+	if s.Domain != "" {
+		return s.Domain
+	}
+	if h, err := os.Hostname(); err == nil {
+		return h
+	}
+	return "localhost"
+}
+
+func (s *Server) maxMessageBytes() int64 {
+	if s.MaxMessageBytes > 0 {
+		return s.MaxMessageBytes
+	}
+	return DefaultMaxMessageBytes
+}
+
+// session 单个 SMTP 连接的状态
+type session struct {
+	s      *Server
+	conn   net.Conn
+	text   *textproto.Conn
+	tls    bool
+	authed bool
+	from   string
+	rcpts  []string
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	sess := &session{s: s, conn: conn, text: textproto.NewConn(conn)}
+	sess.reply(220, s.domain()+" ESMTP go-bark")
+	for {
+		if ctx.Err() != nil {
+			sess.reply(421, "service shutting down")
+			return
+		}
+		timeout := s.ReadTimeout
+		if timeout <= 0 {
+			timeout = 5 * time.Minute
+		}
+		_ = sess.conn.SetReadDeadline(time.Now().Add(timeout))
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		if !sess.handle(ctx, strings.ToUpper(verb), strings.TrimSpace(arg)) {
+			return
+		}
+	}
+}
+
+func (sess *session) reply(code int, lines ...string) {
+	for i, line := range lines {
+		sep := " "
+		if i < len(lines)-1 {
+			sep = "-"
+		}
+		_ = sess.text.PrintfLine("%d%s%s", code, sep, line)
+	}
+}
+
+func (sess *session) reset() {
+	sess.from = ""
+	sess.rcpts = nil
+}
+
+// handle 处理一条命令, 返回 false 时关闭连接
+func (sess *session) handle(ctx context.Context, verb, arg string) bool {
+	s := sess.s
+	switch verb {
+	case "HELO":
+		sess.reset()
+		sess.reply(250, s.domain())
+	case "EHLO":
+		sess.reset()
+		ext := []string{s.domain(), fmt.Sprintf("SIZE %d", s.maxMessageBytes()), "8BITMIME", "PIPELINING"}
+		if s.TLSConfig != nil && !sess.tls {
+			ext = append(ext, "STARTTLS")
+		}
+		if s.Username != "" {
+			ext = append(ext, "AUTH PLAIN LOGIN")
+		}
+		sess.reply(250, ext...)
+	case "STARTTLS":
+		if s.TLSConfig == nil || sess.tls {
+			sess.reply(502, "command not implemented")
+			return true
+		}
+		sess.reply(220, "ready to start TLS")
+		tlsConn := tls.Server(sess.conn, s.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return false
+		}
+		sess.conn = tlsConn
+		sess.text = textproto.NewConn(tlsConn)
+		sess.tls = true
+		sess.reset()
+	case "AUTH":
+		sess.auth(arg)
+	case "MAIL":
+		if s.Username != "" && !sess.authed {
+			sess.reply(530, "authentication required")
+			return true
+		}
+		addr, ok := parsePath(arg, "FROM:")
+		if !ok {
+			sess.reply(501, "syntax: MAIL FROM:<address>")
+			return true
+		}
+		sess.reset()
+		sess.from = addr
+		sess.reply(250, "ok")
+	case "RCPT":
+		if sess.from == "" {
+			sess.reply(503, "need MAIL command")
+			return true
+		}
+		addr, ok := parsePath(arg, "TO:")
+		if !ok || addr == "" {
+			sess.reply(501, "syntax: RCPT TO:<address>")
+			return true
+		}
+		if len(sess.rcpts) >= DefaultMaxRecipients {
+			sess.reply(452, "too many recipients")
+			return true
+		}
+		if _, ok := s.route(addr); !ok && s.RejectUnrouted {
+			sess.reply(550, "no such recipient")
+			return true
+		}
+		sess.rcpts = append(sess.rcpts, addr)
+		sess.reply(250, "ok")
+	case "DATA":
+		if len(sess.rcpts) == 0 {
+			sess.reply(503, "need RCPT command")
+			return true
+		}
+		sess.reply(354, "end data with <CR><LF>.<CR><LF>")
+		dr := sess.text.DotReader()
+		data, err := io.ReadAll(io.LimitReader(dr, s.maxMessageBytes()+1))
+		if err != nil {
+			return false
+		}
+		if int64(len(data)) > s.maxMessageBytes() {
+			// 读完同一个 DotReader 中剩余的数据直到结束符, 保持会话同步
+			if _, err := io.Copy(io.Discard, dr); err != nil {
+				return false
+			}
+			sess.reply(552, "message exceeds fixed maximum message size")
+			sess.reset()
+			return true
+		}
+		if err := s.deliver(ctx, sess.from, sess.rcpts, data); err != nil {
+			s.logger().Error("bark smtp bridge: push failed", "from", sess.from, "to", sess.rcpts, "err", err)
+			sess.reply(451, "push failed, try again later")
+		} else {
+			sess.reply(250, "ok: queued")
+		}
+		sess.reset()
+	case "RSET":
+		sess.reset()
+		sess.reply(250, "ok")
+	case "NOOP":
+		sess.reply(250, "ok")
+	case "VRFY":
+		sess.reply(252, "cannot verify user")
+	case "QUIT":
+		sess.reply(221, "bye")
+		return false
+	default:
+		sess.reply(502, "command not implemented")
+	}
+	return true
+}
+
+// auth 处理 AUTH PLAIN 和 AUTH LOGIN
+func (sess *session) auth(arg string) {
+	s := sess.s
+	if s.Username == "" {
+		sess.reply(502, "command not implemented")
+		return
+	}
+	if s.TLSConfig != nil && !sess.tls {
+		sess.reply(538, "encryption required for requested authentication mechanism")
+		return
+	}
+	mech, initial, _ := strings.Cut(arg, " ")
+	var user, pass string
+	switch strings.ToUpper(mech) {
+	case "PLAIN":
+		if initial == "" {
+			sess.reply(334, "")
+			initial = sess.readLine()
+		}
+		raw, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			sess.reply(501, "invalid base64")
+			return
+		}
+		// authzid \0 authcid \0 password
+		parts := strings.SplitN(string(raw), "\x00", 3)
+		if len(parts) != 3 {
+			sess.reply(501, "invalid PLAIN response")
+			return
+		}
+		user, pass = parts[1], parts[2]
+	case "LOGIN":
+		sess.reply(334, base64.StdEncoding.EncodeToString([]byte("Username:")))
+		u, err := base64.StdEncoding.DecodeString(sess.readLine())
+		if err != nil {
+			sess.reply(501, "invalid base64")
+			return
+		}
+		sess.reply(334, base64.StdEncoding.EncodeToString([]byte("Password:")))
+		p, err := base64.StdEncoding.DecodeString(sess.readLine())
+		if err != nil {
+			sess.reply(501, "invalid base64")
+			return
+		}
+		user, pass = string(u), string(p)
+	default:
+		sess.reply(504, "unrecognized authentication type")
+		return
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(s.Password)) == 1
+	if !userOK || !passOK {
+		sess.reply(535, "authentication credentials invalid")
+		return
+	}
+	sess.authed = true
+	sess.reply(235, "authentication successful")
+}
+
+func (sess *session) readLine() string {
+	line, _ := sess.text.ReadLine()
+	return strings.TrimSpace(line)
+}
+
+// parsePath 解析 FROM:<addr> / TO:<addr>, 忽略 SIZE= 等参数
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		addr, _, _ := strings.Cut(rest, " ")
+		return addr, addr != ""
+	}
+	end := strings.IndexByte(rest, '>')
+	if end < 0 {
+		return "", false
+	}
+	return rest[1:end], true
+}
+
+// route 查找收件人地址对应的收件人
+func (s *Server) route(addr string) ([]string, bool) {
+	addr = strings.ToLower(addr)
+	local, _, _ := strings.Cut(addr, "@")
+	for _, key := range []string{addr, local, "*"} {
+		for k, v := range s.Routes {
+			if strings.ToLower(k) == key {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// deliver 解析邮件并推送
+func (s *Server) deliver(ctx context.Context, from string, rcpts []string, data []byte) error {
+	m, err := ParseMail(data)
+	if err != nil {
+		return err
+	}
+	if m.From == "" {
+		m.From = from
+	}
+	m.To = rcpts
+
+	o, err := s.Options(m)
+	if err != nil {
+		return err
+	}
+	return s.Pusher.Push(ctx, o)
+}
+
+// Options 将邮件转换为推送参数
+func (s *Server) Options(m *Mail) (*bark.Options, error) {
+	o := &bark.Options{}
+	if s.Defaults != nil {
+		o = s.Defaults.Clone()
+	}
+
+	var recipients []string
+	routed := false
+	for _, addr := range m.To {
+		to, ok := s.route(addr)
+		if !ok {
+			continue
+		}
+		routed = true
+		for _, r := range to {
+			if !slices.Contains(recipients, r) {
+				recipients = append(recipients, r)
+			}
+		}
+	}
+	if routed {
+		o.DeviceKey, o.DeviceKeys = "", nil
+		o.Recipients = recipients
+	}
+
+	o.Title = m.Subject
+	o.Subtitle = m.From
+	o.Body = m.Text
+	if o.Title == "" && o.Body == "" {
+		o.Body = "(empty message)"
+	}
+	if o.Group == "" {
+		o.Group = "mail"
+	}
+	if _, err := bridge.Render(s.Template, m, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
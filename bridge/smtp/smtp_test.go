@@ -0,0 +1,193 @@
+package smtp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/smtp"
+)
+
+// startServer 在随机端口启动 s, 测试结束时关闭
+func startServer(t *testing.T, s *smtp.Server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, smtp.ErrServerClosed) {
+			t.Errorf("Serve returned %v", err)
+		}
+	})
+	return l.Addr().String()
+}
+
+// client 简单的 SMTP 客户端, 每条命令等待一个响应
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	text *textproto.Conn
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c := &client{t: t, conn: conn, text: textproto.NewConn(conn)}
+	c.expect(220)
+	return c
+}
+
+// expect 读取一个响应 (可以是多行) 并检查状态码, 服务端不响应时在 2 秒后失败
+func (c *client) expect(code int) string {
+	c.t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := c.text.ReadResponse(code)
+	if err != nil {
+		c.t.Fatalf("expected %d: %v", code, err)
+	}
+	return msg
+}
+
+func (c *client) cmd(code int, line string) string {
+	c.t.Helper()
+	if err := c.text.PrintfLine("%s", line); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.expect(code)
+}
+
+// data 发送 DATA 命令和邮件内容, 返回结束符之后的响应
+func (c *client) data(code int, msg string) string {
+	c.t.Helper()
+	c.cmd(354, "DATA")
+	w := c.text.DotWriter()
+	if _, err := w.Write([]byte(msg)); err != nil {
+		c.t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.expect(code)
+}
+
+func TestSessionDelivers(t *testing.T) {
+	rec := &barktest.Recorder{}
+	s := smtp.New("", rec, &bark.Options{DeviceKey: "nas"})
+	s.Domain = "test.local"
+	s.Routes = map[string][]string{"ups": {"ops"}}
+	c := dial(t, startServer(t, s))
+
+	c.cmd(250, "EHLO client")
+	c.cmd(250, "MAIL FROM:<ups@example.com>")
+	c.cmd(250, "RCPT TO:<ups@alerts.local>")
+	c.data(250, "Subject: Power failure\r\n\r\nRunning on battery\r\n")
+	c.cmd(221, "QUIT")
+
+	o := rec.Last()
+	if o == nil {
+		t.Fatal("no push recorded")
+	}
+	if o.Title != "Power failure" || o.Subtitle != "ups@example.com" || !strings.Contains(o.Body, "Running on battery") {
+		t.Errorf("unexpected push: title=%q subtitle=%q body=%q", o.Title, o.Subtitle, o.Body)
+	}
+	if o.DeviceKey != "" || len(o.Recipients) != 1 || o.Recipients[0] != "ops" {
+		t.Errorf("routed push should target ops only, got key=%q recipients=%v", o.DeviceKey, o.Recipients)
+	}
+}
+
+// TestSessionOversizeData 超过大小上限的邮件应立即返回 552, 之后的命令仍按命令处理
+func TestSessionOversizeData(t *testing.T) {
+	rec := &barktest.Recorder{}
+	s := smtp.New("", rec, &bark.Options{DeviceKey: "nas"})
+	s.MaxMessageBytes = 64
+	c := dial(t, startServer(t, s))
+
+	c.cmd(250, "HELO client")
+	c.cmd(250, "MAIL FROM:<nas@example.com>")
+	c.cmd(250, "RCPT TO:<admin@example.com>")
+	c.data(552, "Subject: big\r\n\r\n"+strings.Repeat("x", 1000)+"\r\n")
+	c.cmd(250, "NOOP")
+	c.cmd(503, "RCPT TO:<admin@example.com>")
+	c.cmd(221, "QUIT")
+
+	if rec.Len() != 0 {
+		t.Errorf("oversize message should not be pushed, got %d pushes", rec.Len())
+	}
+}
+
+func TestSessionCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cmds []string
+		code int
+	}{
+		{"rcpt before mail", []string{"RCPT TO:<a@b>"}, 503},
+		{"data before rcpt", []string{"MAIL FROM:<a@b>", "DATA"}, 503},
+		{"bad mail syntax", []string{"MAIL <a@b>"}, 501},
+		{"unknown command", []string{"EXPN list"}, 502},
+		{"auth not offered", []string{"AUTH PLAIN AGFAYg=="}, 502},
+		{"unrouted recipient", []string{"MAIL FROM:<a@b>", "RCPT TO:<nobody@b>"}, 550},
+	}
+	s := smtp.New("", &barktest.Recorder{}, nil)
+	s.Routes = map[string][]string{"ops": {"ops"}}
+	s.RejectUnrouted = true
+	addr := startServer(t, s)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, addr)
+			for _, line := range tt.cmds[:len(tt.cmds)-1] {
+				c.cmd(250, line)
+			}
+			c.cmd(tt.code, tt.cmds[len(tt.cmds)-1])
+		})
+	}
+}
+
+func TestSessionAuth(t *testing.T) {
+	rec := &barktest.Recorder{}
+	s := smtp.New("", rec, &bark.Options{DeviceKey: "nas"})
+	s.Username, s.Password = "user", "secret"
+	addr := startServer(t, s)
+
+	c := dial(t, addr)
+	if ext := c.cmd(250, "EHLO client"); !strings.Contains(ext, "AUTH PLAIN LOGIN") {
+		t.Errorf("EHLO should advertise AUTH, got %q", ext)
+	}
+	c.cmd(530, "MAIL FROM:<a@b>")
+	// \x00user\x00wrong
+	c.cmd(535, "AUTH PLAIN AHVzZXIAd3Jvbmc=")
+	// \x00user\x00secret
+	c.cmd(235, "AUTH PLAIN AHVzZXIAc2VjcmV0")
+	c.cmd(250, "MAIL FROM:<a@b>")
+
+	c = dial(t, addr)
+	c.cmd(334, "AUTH LOGIN")
+	c.cmd(334, "dXNlcg==")
+	c.cmd(235, "c2VjcmV0")
+}
+
+func TestSessionPushFailure(t *testing.T) {
+	rec := &barktest.Recorder{Err: errors.New("push failed")}
+	s := smtp.New("", rec, &bark.Options{DeviceKey: "nas"})
+	c := dial(t, startServer(t, s))
+
+	c.cmd(250, "HELO client")
+	c.cmd(250, "MAIL FROM:<a@b>")
+	c.cmd(250, "RCPT TO:<c@d>")
+	c.data(451, "Subject: hi\r\n\r\nbody\r\n")
+}
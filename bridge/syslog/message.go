@@ -0,0 +1,221 @@
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity syslog 严重级别, 数值越小越严重
+type Severity int
+
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseSeverity 解析级别名称 (emerg, alert, crit, err/error, warning/warn, notice, info, debug) 或数字
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "error":
+		return SeverityError, nil
+	case "warn":
+		return SeverityWarning, nil
+	case "emergency", "panic":
+		return SeverityEmergency, nil
+	case "critical":
+		return SeverityCritical, nil
+	}
+	for i, name := range severityNames {
+		if name == s {
+			return Severity(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(severityNames) {
+		return Severity(n), nil
+	}
+	return 0, fmt.Errorf("unknown syslog severity: %s", s)
+}
+
+// Facility syslog 设施
+type Facility int
+
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+func (f Facility) String() string {
+	if f >= 0 && int(f) < len(facilityNames) {
+		return facilityNames[f]
+	}
+	return strconv.Itoa(int(f))
+}
+
+// ParseFacility 解析设施名称 (kern, user, daemon, local0 ...) 或数字
+func ParseFacility(s string) (Facility, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range facilityNames {
+		if name == s {
+			return Facility(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(facilityNames) {
+		return Facility(n), nil
+	}
+	return 0, fmt.Errorf("unknown syslog facility: %s", s)
+}
+
+// Message 解析后的 syslog 消息
+type Message struct {
+	Facility  Facility
+	Severity  Severity
+	Timestamp time.Time
+	Hostname  string
+	// AppName RFC 3164 中为 TAG
+	AppName string
+	ProcID  string
+	MsgID   string
+	Content string
+	// Raw 原始消息
+	Raw string
+}
+
+// ErrInvalidMessage 消息不是合法的 syslog 格式
+var ErrInvalidMessage = errors.New("invalid syslog message")
+
+// Parse 解析 RFC 5424 或 RFC 3164 (BSD) 格式的消息
+func Parse(b []byte) (*Message, error) {
+	raw := strings.TrimRight(string(b), "\r\n\x00")
+	if !strings.HasPrefix(raw, "<") {
+		return nil, ErrInvalidMessage
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return nil, ErrInvalidMessage
+	}
+	pri, err := strconv.Atoi(raw[1:end])
+	if err != nil || pri > 191 {
+		return nil, ErrInvalidMessage
+	}
+	m := &Message{
+		Facility: Facility(pri / 8),
+		Severity: Severity(pri % 8),
+		Raw:      raw,
+	}
+
+	rest := raw[end+1:]
+	if strings.HasPrefix(rest, "1 ") {
+		parse5424(m, rest[2:])
+	} else {
+		parse3164(m, rest)
+	}
+	return m, nil
+}
+
+// parse5424 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func parse5424(m *Message, s string) {
+	fields := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		var f string
+		f, s, _ = strings.Cut(s, " ")
+		fields = append(fields, nilValue(f))
+	}
+	if t, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+		m.Timestamp = t
+	}
+	m.Hostname, m.AppName, m.ProcID, m.MsgID = fields[1], fields[2], fields[3], fields[4]
+	m.Content = strings.TrimPrefix(skipStructuredData(s), "\ufeff")
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// skipStructuredData 跳过 STRUCTURED-DATA, 返回其后的消息
+func skipStructuredData(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return strings.TrimPrefix(s[1:], " ")
+	}
+	inValue, escaped := false, false
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && inValue:
+			escaped = true
+		case c == '"':
+			inValue = !inValue
+		case c == '[' && !inValue:
+			depth++
+		case c == ']' && !inValue:
+			depth--
+			if depth == 0 && (i+1 == len(s) || s[i+1] != '[') {
+				return strings.TrimPrefix(s[i+1:], " ")
+			}
+		}
+	}
+	return s
+}
+
+// parse3164 Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG, 时间戳和主机名均可缺失
+func parse3164(m *Message, s string) {
+	const stamp = "Jan _2 15:04:05"
+	if len(s) >= len(stamp) {
+		if t, err := time.ParseInLocation(stamp, s[:len(stamp)], time.Local); err == nil {
+			now := time.Now()
+			t = t.AddDate(now.Year(), 0, 0)
+			// 跨年: 12 月底的消息在 1 月初收到
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.Timestamp = t
+			s = strings.TrimPrefix(s[len(stamp):], " ")
+			if host, rest, ok := strings.Cut(s, " "); ok && !strings.HasSuffix(host, ":") && !strings.Contains(host, "[") {
+				m.Hostname, s = host, rest
+			}
+		}
+	}
+
+	// TAG 最长 32 个字母数字字符, 以 [, : 或空格结束
+	i := 0
+	for i < len(s) && i < 48 && s[i] != ':' && s[i] != '[' && s[i] != ' ' {
+		i++
+	}
+	if i > 0 && i < len(s) && (s[i] == ':' || s[i] == '[') {
+		m.AppName = s[:i]
+		rest := s[i:]
+		if strings.HasPrefix(rest, "[") {
+			if j := strings.IndexByte(rest, ']'); j > 0 {
+				m.ProcID = rest[1:j]
+				rest = rest[j+1:]
+			}
+		}
+		s = strings.TrimPrefix(strings.TrimPrefix(rest, ":"), " ")
+	}
+	m.Content = s
+}
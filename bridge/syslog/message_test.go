@@ -0,0 +1,149 @@
+package syslog_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark/bridge/syslog"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want syslog.Message
+	}{
+		{
+			name: "rfc 5424 with structured data",
+			raw:  `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"] An application event log entry...`,
+			want: syslog.Message{Facility: 20, Severity: syslog.SeverityNotice, Hostname: "mymachine.example.com", AppName: "evntslog", MsgID: "ID47", Content: "An application event log entry..."},
+		},
+		{
+			name: "rfc 5424 with bom and no structured data",
+			raw:  "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su 77 ID47 - \ufeff'su root' failed for lonvick on /dev/pts/8\n",
+			want: syslog.Message{Facility: 4, Severity: syslog.SeverityCritical, Hostname: "mymachine.example.com", AppName: "su", ProcID: "77", MsgID: "ID47", Content: "'su root' failed for lonvick on /dev/pts/8"},
+		},
+		{
+			name: "rfc 5424 with escaped brackets and several elements",
+			raw:  `<11>1 - host app - - [a x="\]" y="[\"]"][b z="1"] disk full`,
+			want: syslog.Message{Facility: 1, Severity: syslog.SeverityError, Hostname: "host", AppName: "app", Content: "disk full"},
+		},
+		{
+			name: "rfc 5424 with structured data only",
+			raw:  `<11>1 - - - - - [a x="1"]`,
+			want: syslog.Message{Facility: 1, Severity: syslog.SeverityError},
+		},
+		{
+			name: "rfc 3164",
+			raw:  "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+			want: syslog.Message{Facility: 4, Severity: syslog.SeverityCritical, Hostname: "mymachine", AppName: "su", Content: "'su root' failed for lonvick on /dev/pts/8"},
+		},
+		{
+			name: "rfc 3164 with pid and single digit day",
+			raw:  "<13>Feb  5 17:32:18 10.0.0.99 sshd[1234]: Accepted publickey\r\n",
+			want: syslog.Message{Facility: 1, Severity: syslog.SeverityNotice, Hostname: "10.0.0.99", AppName: "sshd", ProcID: "1234", Content: "Accepted publickey"},
+		},
+		{
+			name: "rfc 3164 without hostname",
+			raw:  "<13>Feb  5 17:32:18 sshd[1234]: Accepted",
+			want: syslog.Message{Facility: 1, Severity: syslog.SeverityNotice, AppName: "sshd", ProcID: "1234", Content: "Accepted"},
+		},
+		{
+			name: "rfc 3164 without timestamp",
+			raw:  "<13>myapp: hello",
+			want: syslog.Message{Facility: 1, Severity: syslog.SeverityNotice, AppName: "myapp", Content: "hello"},
+		},
+		{
+			name: "rfc 3164 without tag",
+			raw:  "<0>just some text",
+			want: syslog.Message{Content: "just some text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := syslog.Parse([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := *m
+			got.Timestamp, got.Raw = time.Time{}, ""
+			if got != tt.want {
+				t.Errorf("Parse() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	m, err := syslog.Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z host app - - - x`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC); !m.Timestamp.Equal(want) {
+		t.Errorf("rfc 5424 timestamp = %v, want %v", m.Timestamp, want)
+	}
+
+	m, err = syslog.Parse([]byte("<34>Oct 11 22:14:15 host su: x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC 3164 没有年份, 取不晚于当前时间的最近一年
+	ts := m.Timestamp
+	if ts.Month() != time.October || ts.Day() != 11 || ts.Hour() != 22 || ts.Minute() != 14 || ts.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("rfc 3164 timestamp = %v", ts)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{"", "no priority", "<>x", "<abc>x", "<192>x", "<12345>x", "<13"} {
+		if m, err := syslog.Parse([]byte(raw)); !errors.Is(err, syslog.ErrInvalidMessage) {
+			t.Errorf("Parse(%q) = %+v, %v; want ErrInvalidMessage", raw, m, err)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := map[string]syslog.Severity{
+		"emerg":   syslog.SeverityEmergency,
+		"panic":   syslog.SeverityEmergency,
+		"crit":    syslog.SeverityCritical,
+		"Error":   syslog.SeverityError,
+		"warn":    syslog.SeverityWarning,
+		" info ":  syslog.SeverityInfo,
+		"7":       syslog.SeverityDebug,
+		"warning": syslog.SeverityWarning,
+	}
+	for s, want := range tests {
+		if got, err := syslog.ParseSeverity(s); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "8", "-1", "loud"} {
+		if _, err := syslog.ParseSeverity(s); err == nil {
+			t.Errorf("ParseSeverity(%q) should fail", s)
+		}
+	}
+}
+
+func TestParseFacility(t *testing.T) {
+	tests := map[string]syslog.Facility{
+		"kern":   0,
+		"daemon": 3,
+		"LOCAL0": 16,
+		"23":     23,
+	}
+	for s, want := range tests {
+		if got, err := syslog.ParseFacility(s); err != nil || got != want {
+			t.Errorf("ParseFacility(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "24", "local8"} {
+		if _, err := syslog.ParseFacility(s); err == nil {
+			t.Errorf("ParseFacility(%q) should fail", s)
+		}
+	}
+	if got := syslog.Facility(16).String(); got != "local0" {
+		t.Errorf("Facility(16) = %q", got)
+	}
+}
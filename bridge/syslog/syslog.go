@@ -0,0 +1,281 @@
+// Package syslog 实现 syslog (RFC 3164/RFC 5424, UDP/TCP) 接收端, 按设施和级别过滤后转换为 Bark 推送
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// DefaultAddr 默认监听地址
+const DefaultAddr = ":514"
+
+// maxMessageSize 单条消息的最大长度
+const maxMessageSize = 64 << 10
+
+// ErrServerClosed ctx 结束后 Serve 系列方法返回
+var ErrServerClosed = errors.New("syslog: server closed")
+
+// DefaultLevels 严重级别到 Bark 级别的映射
+var DefaultLevels = map[Severity]string{
+	SeverityEmergency: "critical",
+	SeverityAlert:     "timeSensitive",
+	SeverityCritical:  "timeSensitive",
+	SeverityError:     "active",
+	SeverityWarning:   "active",
+	SeverityNotice:    "passive",
+	SeverityInfo:      "passive",
+	SeverityDebug:     "passive",
+}
+
+// Server syslog 接收服务
+type Server struct {
+	// UDPAddr, TCPAddr 监听地址, 均为空时 ListenAndServe 监听 UDP DefaultAddr
+	UDPAddr string
+	TCPAddr string
+	Pusher  bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// Threshold 只推送严重程度不低于该级别的消息 (数值 <= Threshold), New 默认为 SeverityWarning
+	Threshold Severity
+	// Facilities 非空时只推送指定设施的消息
+	Facilities []Facility
+	// Pattern 非空时只推送内容匹配的消息
+	Pattern *regexp.Regexp
+	// Levels 严重级别到 Bark 级别的映射, 默认 DefaultLevels
+	Levels map[Severity]string
+	// Template 可选, 以推送参数命名的子模板覆盖默认内容, 数据为 *Message
+	Template *template.Template
+	// Logger 记录解析和推送错误, 默认 slog.Default()
+	Logger *slog.Logger
+}
+
+// New 创建只监听 UDP 的 Server
+func New(udpAddr string, p bark.Pusher, defaults *bark.Options) *Server {
+	return &Server{UDPAddr: udpAddr, Pusher: p, Defaults: defaults, Threshold: SeverityWarning}
+}
+
+// ListenAndServe 监听 UDPAddr 和 TCPAddr, 直到 ctx 结束或任一监听出错
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	udpAddr, tcpAddr := s.UDPAddr, s.TCPAddr
+	if udpAddr == "" && tcpAddr == "" {
+		udpAddr = DefaultAddr
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errc := make(chan error, 2)
+	n := 0
+	if udpAddr != "" {
+		pc, err := net.ListenPacket("udp", udpAddr)
+		if err != nil {
+			return err
+		}
+		n++
+		go func() { errc <- s.ServeUDP(ctx, pc) }()
+	}
+	if tcpAddr != "" {
+		l, err := net.Listen("tcp", tcpAddr)
+		if err != nil {
+			cancel()
+			if n > 0 {
+				<-errc
+			}
+			return err
+		}
+		n++
+		go func() { errc <- s.ServeTCP(ctx, l) }()
+	}
+
+	err := <-errc
+	cancel()
+	for i := 1; i < n; i++ {
+		<-errc
+	}
+	return err
+}
+
+// ServeUDP 从 pc 读取消息, ctx 结束时关闭 pc 并返回 ErrServerClosed
+func (s *Server) ServeUDP(ctx context.Context, pc net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { pc.Close() })
+	defer stop()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+		s.handle(ctx, buf[:n])
+	}
+}
+
+// ServeTCP 在 l 上接受连接, 支持 RFC 6587 的 octet-counting 和换行分隔两种帧格式
+func (s *Server) ServeTCP(ctx context.Context, l net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	var mu sync.Mutex
+	conns := make(map[net.Conn]struct{})
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+		mu.Lock()
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+	})
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+			s.serveStream(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveStream(ctx context.Context, r io.Reader) {
+	br := bufio.NewReaderSize(r, maxMessageSize)
+	for {
+		frame, err := readFrame(br)
+		if len(frame) > 0 {
+			s.handle(ctx, frame)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readFrame 读取一帧: 以数字开头时按 "长度 消息" 读取, 否则读到换行
+func readFrame(br *bufio.Reader) ([]byte, error) {
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		prefix, err := br.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil || n > maxMessageSize {
+			return nil, fmt.Errorf("invalid syslog frame length: %q", prefix)
+		}
+		frame := make([]byte, n)
+		_, err = io.ReadFull(br, frame)
+		return frame, err
+	}
+	line, err := br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("syslog message exceeds %d bytes", maxMessageSize)
+	}
+	return append([]byte(nil), line...), err
+}
+
+func (s *Server) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+func (s *Server) handle(ctx context.Context, b []byte) {
+	m, err := Parse(b)
+	if err != nil {
+		s.logger().Debug("bark syslog bridge: skip message", "err", err)
+		return
+	}
+	if !s.Match(m) {
+		return
+	}
+	o, err := s.Options(m)
+	if err == nil {
+		err = s.Pusher.Push(ctx, o)
+	}
+	if err != nil {
+		s.logger().Error("bark syslog bridge: push failed", "host", m.Hostname, "app", m.AppName, "err", err)
+	}
+}
+
+// Match 判断消息是否满足级别, 设施和内容过滤条件
+func (s *Server) Match(m *Message) bool {
+	if m.Severity > s.Threshold {
+		return false
+	}
+	if len(s.Facilities) > 0 && !slices.Contains(s.Facilities, m.Facility) {
+		return false
+	}
+	return s.Pattern == nil || s.Pattern.MatchString(m.Content)
+}
+
+// Options 将消息转换为推送参数
+func (s *Server) Options(m *Message) (*bark.Options, error) {
+	o := &bark.Options{}
+	if s.Defaults != nil {
+		o = s.Defaults.Clone()
+	}
+
+	title := m.Hostname
+	if m.AppName != "" {
+		if title != "" {
+			title += " "
+		}
+		title += m.AppName
+	}
+	if title == "" {
+		title = "syslog"
+	}
+	o.Title = fmt.Sprintf("[%s] %s", m.Severity, title)
+	o.Subtitle = m.Facility.String()
+	o.Body = m.Content
+	if o.Group == "" {
+		o.Group = bridge.FirstNonEmpty(m.Hostname, "syslog")
+	}
+	levels := s.Levels
+	if levels == nil {
+		levels = DefaultLevels
+	}
+	if level := levels[m.Severity]; level != "" {
+		o.Level = level
+	}
+
+	if _, err := bridge.Render(s.Template, m, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
@@ -0,0 +1,217 @@
+package syslog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/syslog"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		server syslog.Server
+		msg    syslog.Message
+		want   bool
+	}{
+		{"at threshold", syslog.Server{Threshold: syslog.SeverityWarning}, syslog.Message{Severity: syslog.SeverityWarning}, true},
+		{"below threshold", syslog.Server{Threshold: syslog.SeverityWarning}, syslog.Message{Severity: syslog.SeverityInfo}, false},
+		{"facility allowed", syslog.Server{Threshold: syslog.SeverityDebug, Facilities: []syslog.Facility{3, 4}}, syslog.Message{Facility: 4}, true},
+		{"facility filtered", syslog.Server{Threshold: syslog.SeverityDebug, Facilities: []syslog.Facility{3}}, syslog.Message{Facility: 4}, false},
+		{"pattern matches", syslog.Server{Threshold: syslog.SeverityDebug, Pattern: regexp.MustCompile(`disk`)}, syslog.Message{Content: "disk full"}, true},
+		{"pattern does not match", syslog.Server{Threshold: syslog.SeverityDebug, Pattern: regexp.MustCompile(`disk`)}, syslog.Message{Content: "cpu hot"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.server.Match(&tt.msg); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		server *syslog.Server
+		msg    syslog.Message
+		want   bark.Options
+	}{
+		{
+			name:   "host and app",
+			server: syslog.New("", nil, &bark.Options{DeviceKey: "key"}),
+			msg:    syslog.Message{Facility: 4, Severity: syslog.SeverityCritical, Hostname: "db1", AppName: "su", Content: "failed"},
+			want:   bark.Options{DeviceKey: "key", Title: "[crit] db1 su", Subtitle: "auth", Body: "failed", Group: "db1", Level: "timeSensitive"},
+		},
+		{
+			name:   "no host or app",
+			server: syslog.New("", nil, &bark.Options{Group: "infra"}),
+			msg:    syslog.Message{Facility: 16, Severity: syslog.SeverityEmergency, Content: "down"},
+			want:   bark.Options{Title: "[emerg] syslog", Subtitle: "local0", Body: "down", Group: "infra", Level: "critical"},
+		},
+		{
+			name:   "custom levels",
+			server: &syslog.Server{Levels: map[syslog.Severity]string{syslog.SeverityError: "critical"}},
+			msg:    syslog.Message{Facility: 1, Severity: syslog.SeverityError, AppName: "app", Content: "x"},
+			want:   bark.Options{Title: "[err] app", Subtitle: "user", Body: "x", Group: "syslog", Level: "critical"},
+		},
+		{
+			name: "template",
+			server: &syslog.Server{Template: template.Must(template.New("").Parse(
+				`{{define "title"}}{{.Hostname}}: {{.ProcID}}{{end}}{{define "group"}}{{.AppName}}{{end}}`))},
+			msg:  syslog.Message{Facility: 1, Severity: syslog.SeverityInfo, Hostname: "web", AppName: "nginx", ProcID: "42", Content: "x"},
+			want: bark.Options{Title: "web: 42", Subtitle: "user", Body: "x", Group: "nginx", Level: "passive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := tt.server.Options(&tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DeviceKey != tt.want.DeviceKey || o.Title != tt.want.Title || o.Subtitle != tt.want.Subtitle ||
+				o.Body != tt.want.Body || o.Group != tt.want.Group || o.Level != tt.want.Level {
+				t.Errorf("Options() = %+v\nwant %+v", o, &tt.want)
+			}
+		})
+	}
+}
+
+// waitFor 等待 r 记录 n 条推送
+func waitFor(t *testing.T, r *barktest.Recorder, n int) []*bark.Options {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Len() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d pushes, want %d", r.Len(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return r.Pushes()
+}
+
+func bodies(pushes []*bark.Options) string {
+	s := ""
+	for i, o := range pushes {
+		if i > 0 {
+			s += ","
+		}
+		s += o.Body
+	}
+	return s
+}
+
+func TestServeTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &barktest.Recorder{}
+	s := syslog.New("", r, &bark.Options{DeviceKey: "key"})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.ServeTCP(ctx, l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	framed := "<11>1 - host app - - - octet counted"
+	stream := fmt.Sprintf("%d %s", len(framed), framed) +
+		"<14>Oct 11 22:14:15 host app: info is filtered\n" +
+		"not a syslog message\n" +
+		"<11>Oct 11 22:14:15 host app: newline delimited\n"
+	if _, err := conn.Write([]byte(stream)); err != nil {
+		t.Fatal(err)
+	}
+	pushes := waitFor(t, r, 2)
+	if got := bodies(pushes); got != "octet counted,newline delimited" {
+		t.Errorf("received %s", got)
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, syslog.ErrServerClosed) {
+			t.Errorf("ServeTCP() = %v, want ErrServerClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeTCP did not return after cancel")
+	}
+}
+
+func TestServeTCPInvalidFrame(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &barktest.Recorder{}
+	s := syslog.New("", r, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.ServeTCP(ctx, l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 长度超过上限时关闭连接
+	if _, err := fmt.Fprintf(conn, "999999999 <11>x\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("connection should be closed after an invalid frame, read error %v", err)
+	}
+	if r.Len() != 0 {
+		t.Errorf("invalid frame produced %d pushes", r.Len())
+	}
+}
+
+func TestServeUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &barktest.Recorder{}
+	s := syslog.New("", r, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.ServeUDP(ctx, pc) }()
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, msg := range []string{"<15>debug: skipped", "<10>1 - host cron - - - job failed\n"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pushes := waitFor(t, r, 1)
+	if len(pushes) != 1 || pushes[0].Body != "job failed" || pushes[0].Title != "[crit] host cron" {
+		t.Errorf("received %+v", pushes)
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, syslog.ErrServerClosed) {
+			t.Errorf("ServeUDP() = %v, want ErrServerClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeUDP did not return after cancel")
+	}
+}
@@ -0,0 +1,82 @@
+// Package webhook 提供通用的 JSON webhook 到 Bark 推送的 http.Handler
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// Handler 接收任意 JSON webhook, 通过模板映射为推送并转发
+//
+// Template 中以推送参数命名的子模板 (title, body, markdown, group, url ...) 以解码后的 JSON 为数据渲染,
+// 例如 {{define "title"}}{{.repository.name}}{{end}}; 未设置模板时正文为格式化后的 JSON
+type Handler struct {
+	Pusher bark.Pusher
+	// Defaults 默认推送参数, 如设备 Key, 分组
+	Defaults *bark.Options
+	// Template 参数模板
+	Template *template.Template
+	// Token 非空时要求请求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+	// MaxBodyBytes 请求体大小上限, 默认 bridge.DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// New 创建 Handler, tmpl 可以为 nil
+func New(p bark.Pusher, defaults *bark.Options, tmpl *template.Template) *Handler {
+	return &Handler{Pusher: p, Defaults: defaults, Template: tmpl}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+		return
+	}
+	if !bridge.CheckToken(r, h.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	body, err := bridge.ReadBody(r, h.MaxBodyBytes)
+	if err != nil {
+		bridge.Respond(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		bridge.Respond(w, http.StatusBadRequest, "invalid JSON payload: "+err.Error())
+		return
+	}
+
+	o := &bark.Options{}
+	if h.Defaults != nil {
+		o = h.Defaults.Clone()
+	}
+	defined, err := bridge.Render(h.Template, payload, o)
+	if err != nil {
+		bridge.Respond(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if !defined {
+		if o.Title == "" {
+			o.Title = "Webhook"
+		}
+		o.Body = prettyJSON(body)
+	}
+
+	bridge.Push(w, r, h.Pusher, o)
+}
+
+func prettyJSON(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	return buf.String()
+}
@@ -0,0 +1,68 @@
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+	"github.com/gaoyaxuan/go-bark/bridge/webhook"
+)
+
+func TestHandler(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`{{define "title"}}{{.repository.name}}{{end}}{{define "body"}}{{.action}} by {{.sender}}{{end}}`))
+	tests := []struct {
+		name   string
+		tmpl   *template.Template
+		method string
+		target string
+		body   string
+		code   int
+		want   *bark.Options
+	}{
+		{
+			name: "template", tmpl: tmpl, method: http.MethodPost, target: "/?token=s3cret",
+			body: `{"repository":{"name":"go-bark"},"action":"opened","sender":"alice"}`,
+			code: http.StatusOK, want: &bark.Options{DeviceKey: "key", Title: "go-bark", Body: "opened by alice"},
+		},
+		{
+			name: "pretty json without template", method: http.MethodPost, target: "/?token=s3cret",
+			body: `{"a":1}`,
+			code: http.StatusOK, want: &bark.Options{DeviceKey: "key", Title: "Webhook", Body: "{\n  \"a\": 1\n}"},
+		},
+		{name: "wrong method", method: http.MethodGet, target: "/?token=s3cret", code: http.StatusMethodNotAllowed},
+		{name: "missing token", method: http.MethodPost, target: "/", body: `{}`, code: http.StatusUnauthorized},
+		{name: "invalid json", method: http.MethodPost, target: "/?token=s3cret", body: `{`, code: http.StatusBadRequest},
+		{name: "too large", method: http.MethodPost, target: "/?token=s3cret", body: `"` + strings.Repeat("x", 128) + `"`, code: http.StatusRequestEntityTooLarge},
+		{
+			name: "template error", tmpl: template.Must(template.New("").Parse(`{{define "title"}}{{.a.b.c}}{{end}}`)), method: http.MethodPost, target: "/?token=s3cret",
+			body: `{"a":"string"}`, code: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &barktest.Recorder{}
+			h := webhook.New(r, &bark.Options{DeviceKey: "key"}, tt.tmpl)
+			h.Token = "s3cret"
+			h.MaxBodyBytes = 128
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			got := r.Last()
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("unexpected push %+v", got)
+				}
+				return
+			}
+			if got == nil || got.DeviceKey != tt.want.DeviceKey || got.Title != tt.want.Title || got.Body != tt.want.Body {
+				t.Errorf("pushed %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
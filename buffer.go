@@ -0,0 +1,76 @@
+package bark
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer 超过该容量的缓冲区不放回池中, 避免个别超大推送长期占用内存
+const maxPooledBuffer = 64 << 10
+
+// bufferPool 复用序列化推送内容和请求体的缓冲区
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// encodeJSON 将 v 序列化到 buf, 与 json.Marshal 输出一致 (去掉 Encoder 追加的换行)
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// pooledBody 引用计数的请求体缓冲区
+//
+// http.Transport 可能在 RoundTrip 返回后仍在读取请求体, 也可能在重试时通过 GetBody 重新读取,
+// 因此只有发送方和所有读取方都释放后才把缓冲区放回池中
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+func newPooledBody() *pooledBody {
+	p := &pooledBody{buf: getBuffer()}
+	p.refs.Store(1)
+	return p
+}
+
+// reader 返回一个新的请求体读取方, 关闭时释放引用
+func (p *pooledBody) reader() io.ReadCloser {
+	p.refs.Add(1)
+	return &pooledReader{Reader: bytes.NewReader(p.buf.Bytes()), p: p}
+}
+
+func (p *pooledBody) release() {
+	if p.refs.Add(-1) == 0 {
+		putBuffer(p.buf)
+	}
+}
+
+type pooledReader struct {
+	*bytes.Reader
+	p    *pooledBody
+	once sync.Once
+}
+
+func (r *pooledReader) Close() error {
+	r.once.Do(r.p.release)
+	return nil
+}
@@ -0,0 +1,93 @@
+package bark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultChunkSize 单次请求默认的最大设备数, 与内嵌服务端的 DefaultMaxDeviceKeys 一致
+const DefaultChunkSize = 100
+
+// chunking 按设备数拆分请求的设置, 零值使用默认值
+type chunking struct {
+	size        int
+	concurrency int
+}
+
+// WithChunkSize 设置单次请求的最大设备数, 超过时将 DeviceKeys 拆分为多次请求, 默认 DefaultChunkSize
+// n 小于 0 时不拆分
+func WithChunkSize(n int) ClientOption {
+	return func(c *Client) {
+		c.chunking.size = n
+	}
+}
+
+// WithChunkConcurrency 设置拆分后同时发送的请求数, 默认 1 (依次发送)
+func WithChunkConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.chunking.concurrency = n
+	}
+}
+
+// chunks 按设置的大小拆分设备 Key
+func (ch chunking) chunks(keys []string) [][]string {
+	size := ch.size
+	if size == 0 {
+		size = DefaultChunkSize
+	}
+	if size < 0 || len(keys) <= size {
+		return [][]string{keys}
+	}
+	out := make([][]string, 0, (len(keys)+size-1)/size)
+	for len(keys) > size {
+		out = append(out, keys[:size:size])
+		keys = keys[size:]
+	}
+	return append(out, keys)
+}
+
+// sendShared 向共用加密设置的设备推送, 设备较多时拆分为多次请求, 失败记录到 errs
+// 拆分时失败项为 "chunk i/n", 全部请求都会发送, 不会因某次失败而中止
+func (c *Client) sendShared(ctx context.Context, o *Options, keys []string, errs *MultiError) {
+	chunks := c.chunking.chunks(keys)
+	results := make([]error, len(chunks))
+	send := func(i int) {
+		rest := *o
+		rest.DeviceKey = ""
+		rest.DeviceKeys = chunks[i]
+		rest.DeviceEnc = nil
+		if len(chunks[i]) == 1 {
+			rest.DeviceKey = chunks[i][0]
+			rest.DeviceKeys = nil
+		}
+		results[i] = c.send(ctx, &rest)
+	}
+
+	concurrency := c.chunking.concurrency
+	if concurrency <= 1 || len(chunks) == 1 {
+		for i := range chunks {
+			send(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i := range chunks {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(i int) {
+				defer func() { <-sem; wg.Done() }()
+				send(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	for i, err := range results {
+		if len(chunks) == 1 {
+			errs.Add("", err)
+		} else {
+			errs.Add(fmt.Sprintf("chunk %d/%d", i+1, len(chunks)), err)
+		}
+	}
+}
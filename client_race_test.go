@@ -0,0 +1,70 @@
+package bark_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+// TestClientConcurrentUse 多个 goroutine 同时使用同一个客户端推送, 派生和关闭, 需配合 go test -race 运行
+func TestClientConcurrentUse(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	client := srv.Client(
+		bark.WithAliases(map[string]string{"ops": "opskey"}),
+		bark.WithHistory(bark.NewHistory(64)),
+		bark.WithGzip(64),
+	)
+
+	const workers, pushes = 8, 20
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*pushes*3)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived := client.With(bark.WithAliases(map[string]string{fmt.Sprintf("w%d", i): "derivedkey"}))
+			ch := derived.Channel(ctx)
+			for j := 0; j < pushes; j++ {
+				body := fmt.Sprintf("worker %d push %d", i, j)
+				if err := client.Push(ctx, &bark.Options{DeviceKey: "sharedkey", Body: body}); err != nil {
+					errs <- err
+				}
+				if err := derived.Push(ctx, (&bark.Options{Body: body}).To("ops", fmt.Sprintf("w%d", i))); err != nil {
+					errs <- err
+				}
+				ch <- &bark.Options{DeviceKey: "asynckey", Body: body}
+			}
+			close(ch)
+			closeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			if err := derived.Close(closeCtx); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(srv.Received()), workers*pushes*3; got != want {
+		t.Fatalf("received %d pushes, want %d", got, want)
+	}
+	if _, err := client.Resolve(fmt.Sprintf("w%d", 0)); err == nil {
+		t.Fatal("alias added by With leaked into the original client")
+	}
+	if err := client.Push(ctx, &bark.Options{DeviceKey: "sharedkey", Body: "after close"}); err != nil {
+		t.Fatalf("push after Close: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package bark
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时间来源, 重试, 升级重发, 队列优先级, 限流, 缓存过期和推送历史通过它获取时间, 测试时可替换为 barktest.Clock
+type Clock interface {
+	Now() time.Time
+	// After 等同于 time.After
+	After(d time.Duration) <-chan time.Time
+	// NewTimer 等同于 time.NewTimer
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer Clock 创建的定时器
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock 使用系统时间的 Clock, 默认值
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct{ *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+// WithClock 设置客户端的时间来源, 默认 SystemClock, 同时用于 WithDNSCache 的缓存过期
+func WithClock(clk Clock) ClientOption {
+	return func(c *Client) {
+		c.clk = clk
+		if c.dial != nil && c.dial.cache != nil {
+			c.setDial(func(d *dialConfig) {
+				d.cache = newDNSCache(d.cache.resolver, d.cache.ttl, clk)
+			})
+		}
+	}
+}
+
+func (c *Client) clock() Clock {
+	return clockOrSystem(c.clk)
+}
+
+func clockOrSystem(clk Clock) Clock {
+	if clk == nil {
+		return SystemClock
+	}
+	return clk
+}
+
+// afterFunc 等同于 time.AfterFunc, 使用 clk 计时
+// 返回的 stop 在 f 开始执行前调用时取消 f 并返回 true, 返回 false 表示 f 已经或正在执行
+func afterFunc(clk Clock, d time.Duration, f func()) (stop func() bool) {
+	const (
+		pending int32 = iota
+		running
+		cancelled
+	)
+	var state atomic.Int32
+	t := clk.NewTimer(d)
+	cancel := make(chan struct{})
+	go func() {
+		select {
+		case <-t.C():
+			if state.CompareAndSwap(pending, running) {
+				f()
+			}
+		case <-cancel:
+		}
+	}()
+	return func() bool {
+		if !state.CompareAndSwap(pending, cancelled) {
+			return false
+		}
+		t.Stop()
+		close(cancel)
+		return true
+	}
+}
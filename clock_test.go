@@ -0,0 +1,182 @@
+package bark_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+var clockStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// wait 等待 ch 关闭或收到值, 超时则失败
+func wait[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+		panic("unreachable")
+	}
+}
+
+func TestRetryUsesClock(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	clk := barktest.NewClock(clockStart)
+	client := srv.Client(bark.WithClock(clk), bark.WithRetry(2, time.Second))
+
+	srv.FailWith(http.StatusServiceUnavailable, "unavailable")
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.Push(context.Background(), &bark.Options{DeviceKey: "key", Body: "retry"})
+	}()
+
+	// 第一次重试前等待 1 秒
+	clk.WaitForTimers(1)
+	clk.Advance(999 * time.Millisecond)
+	if clk.Timers() != 1 {
+		t.Fatal("retry fired before the backoff elapsed")
+	}
+	clk.Advance(time.Millisecond)
+
+	// 第二次重试前等待 2 秒, 此时恢复正常
+	clk.WaitForTimers(1)
+	srv.Succeed()
+	clk.Advance(2 * time.Second)
+
+	if err := wait(t, errc); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := srv.Received(); len(got) != 1 || got[0].Body != "retry" {
+		t.Fatalf("received %+v", got)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	clk := barktest.NewClock(clockStart)
+	client := srv.Client(bark.WithClock(clk), bark.WithRetry(1, time.Second))
+
+	srv.FailWith(http.StatusInternalServerError, "boom")
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.Push(context.Background(), &bark.Options{DeviceKey: "key", Body: "fail"})
+	}()
+	clk.WaitForTimers(1)
+	clk.Advance(time.Second)
+	if err := wait(t, errc); err == nil {
+		t.Fatal("Push should fail after the retries are used up")
+	}
+}
+
+func TestEscalateUsesClock(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	clk := barktest.NewClock(clockStart)
+	client := srv.Client(bark.WithClock(clk))
+
+	policy := bark.EscalationPolicy{Interval: time.Minute, MaxRepeats: 3, CallAfter: 2}
+	esc, err := client.Escalate(context.Background(), &bark.Options{DeviceKey: "key", Body: "db down"}, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 间隔依次为 1, 2, 4 分钟
+	for i, d := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		clk.WaitForTimers(1)
+		clk.Advance(d - time.Second)
+		if n := len(srv.Received()); n != i+1 {
+			t.Fatalf("repeat %d sent early: %d pushes", i+1, n)
+		}
+		clk.Advance(time.Second)
+	}
+	wait(t, esc.Done())
+
+	got := srv.Received()
+	if len(got) != 4 || esc.Repeats() != 3 || esc.Err() != nil {
+		t.Fatalf("pushes=%d repeats=%d err=%v", len(got), esc.Repeats(), esc.Err())
+	}
+	for i, o := range got {
+		want := ""
+		if i >= 2 {
+			want = "1"
+		}
+		if o.Call != want {
+			t.Errorf("push %d call = %q, want %q", i, o.Call, want)
+		}
+	}
+}
+
+func TestEscalateCancel(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	clk := barktest.NewClock(clockStart)
+	client := srv.Client(bark.WithClock(clk))
+
+	esc, err := client.Escalate(context.Background(), &bark.Options{DeviceKey: "key", Body: "page"}, bark.EscalationPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.WaitForTimers(1)
+	esc.Cancel()
+	wait(t, esc.Done())
+	clk.Advance(time.Hour)
+	if n := len(srv.Received()); n != 1 || esc.Repeats() != 0 {
+		t.Fatalf("cancelled escalation repeated: pushes=%d repeats=%d", n, esc.Repeats())
+	}
+}
+
+func TestRateLimiterUsesClock(t *testing.T) {
+	clk := barktest.NewClock(clockStart)
+	l := bark.NewRateLimiter(2, time.Minute).WithClock(clk)
+
+	steps := []struct {
+		advance time.Duration
+		allow   bool
+		dropped int
+	}{
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, 0},
+		// 30 秒补充 1 个令牌
+		{30 * time.Second, true, 1},
+		{0, false, 0},
+		{time.Hour, true, 1},
+		{0, true, 0},
+		{0, false, 0},
+	}
+	for i, s := range steps {
+		clk.Advance(s.advance)
+		ok, dropped := l.Take()
+		if ok != s.allow || dropped != s.dropped {
+			t.Errorf("step %d: Take() = %v, %d; want %v, %d", i, ok, dropped, s.allow, s.dropped)
+		}
+	}
+}
+
+func TestWriterFlushUsesClock(t *testing.T) {
+	rec := &barktest.Recorder{}
+	clk := barktest.NewClock(clockStart)
+	w := bark.NewWriter(rec, &bark.Options{DeviceKey: "key"}, bark.WithWriterClock(clk), bark.WithFlushInterval(time.Minute))
+
+	if _, err := w.Write([]byte("first\nsecond\n")); err != nil {
+		t.Fatal(err)
+	}
+	clk.WaitForTimers(1)
+	if rec.Len() != 0 {
+		t.Fatal("lines pushed before the flush interval")
+	}
+	clk.Advance(time.Minute)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Len() != 1 || rec.Last().Body != "first\nsecond" {
+		t.Fatalf("pushes=%d last=%+v", rec.Len(), rec.Last())
+	}
+}
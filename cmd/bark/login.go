@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/credentials"
+)
+
+func runLogin(_ context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark login", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var name, key, encKey, encHex string
+	fs.StringVar(&name, "profile", defaultCredentialName, "name to store the credentials under")
+	fs.StringVar(&key, "key", "", "device key to store")
+	fs.StringVar(&encKey, "enc-key", "", "encryption key to store")
+	fs.StringVar(&encHex, "enc-key-hex", "", "hex encoded encryption key to store")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if key == "" && encKey == "" && encHex == "" {
+		return usagef("login requires --key, --enc-key or --enc-key-hex")
+	}
+
+	store := credentials.New("")
+	if key != "" {
+		if err := store.SetDeviceKey(name, key); err != nil {
+			return err
+		}
+	}
+	if encKey != "" || encHex != "" {
+		raw := []byte(encKey)
+		if encHex != "" {
+			var err error
+			if raw, err = bark.DecodeKey(encHex, bark.KeyFormatHex); err != nil {
+				return &usageError{err: err}
+			}
+		}
+		if err := store.SetEncryptionKey(name, raw); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(stdout, "credentials stored for %q\n", name)
+	return nil
+}
+
+func runLogout(_ context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark logout", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var name string
+	fs.StringVar(&name, "profile", defaultCredentialName, "name the credentials are stored under")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+
+	store := credentials.New("")
+	keyErr := store.DeleteDeviceKey(name)
+	encErr := store.DeleteEncryptionKey(name)
+	if keyErr != nil && encErr != nil {
+		return fmt.Errorf("no credentials stored for %q", name)
+	}
+	fmt.Fprintf(stdout, "credentials removed for %q\n", name)
+	return nil
+}
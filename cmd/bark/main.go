@@ -0,0 +1,126 @@
+// Command bark 在命令行发送 Bark 推送
+//
+//	bark push --title "部署完成" --body "v1.2.3" --key YOUR_DEVICE_KEY --level timeSensitive
+//	make test 2>&1 | bark --title "make test"
+//	bark run -- make deploy
+//	bark watch --file /var/log/app.log --pattern 'ERROR|panic'
+//	bark login --key YOUR_DEVICE_KEY
+//
+// 退出码: 0 成功, 1 推送失败, 2 参数或配置错误; bark run 透传被包装命令的退出码
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// usageError 参数或配置错误, 以 exitUsage 退出
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{name: "push", summary: "send a notification", run: runPush},
+	{name: "run", summary: "run a command and notify when it finishes", run: runRun},
+	{name: "watch", summary: "follow a log file and push matching lines", run: runWatch},
+	{name: "serve", summary: "run an HTTP webhook to bark bridge", run: runServe},
+	{name: "login", summary: "store a device key (and encryption key) in the OS keyring", run: runLogin},
+	{name: "logout", summary: "remove stored credentials from the OS keyring", run: runLogout},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	args := os.Args[1:]
+	if stdinIsPipe() && (len(args) == 0 || isFlag(args[0])) {
+		// cmd | bark [flags] 等价于 bark push --stdin [flags]
+		args = append([]string{"push", "--stdin"}, args...)
+	}
+	code := run(ctx, args, os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(ctx, args[1:], stdin, stdout, stderr)
+		return exitCode(err, stderr)
+	}
+
+	fmt.Fprintf(stderr, "bark: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return exitUsage
+}
+
+func stdinIsPipe() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+func isFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && arg != "-h" && arg != "--help"
+}
+
+func exitCode(err error, stderr io.Writer) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var se *exitStatusError
+	if errors.As(err, &se) {
+		// 被包装命令的输出已经展示给用户, 不再重复打印
+		return se.code
+	}
+	fmt.Fprintf(stderr, "bark: %v\n", err)
+	var ue *usageError
+	if errors.As(err, &ue) {
+		return exitUsage
+	}
+	return exitFailure
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: bark <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "bark <command> -h" for command flags.`)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+func runPush(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark push", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		target       targetFlags
+		quiet        bool
+		fromStdin    bool
+		split        int
+		lastSubtitle bool
+		tmplPath     string
+		dataPath     string
+	)
+	target.register(fs)
+	fs.BoolVar(&quiet, "quiet", false, "do not print anything on success")
+	fs.BoolVar(&fromStdin, "stdin", false, "read the body from standard input")
+	fs.IntVar(&split, "split", 0, "with --stdin, split the body into several pushes of at most N characters")
+	fs.BoolVar(&lastSubtitle, "last-line-subtitle", false, "with --stdin, use the last input line as subtitle")
+	fs.StringVar(&tmplPath, "template", "", "Go text/template file rendering title, subtitle, body and markdown")
+	fs.StringVar(&dataPath, "data", "", "JSON file with template data, - reads standard input")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments: %v", fs.Args())
+	}
+	if !fromStdin && (split > 0 || lastSubtitle) {
+		return usagef("--split and --last-line-subtitle require --stdin")
+	}
+	if dataPath != "" && tmplPath == "" {
+		return usagef("--data requires --template")
+	}
+	if fromStdin && dataPath == "-" {
+		return usagef("--stdin and --data - cannot both read standard input")
+	}
+
+	client, o, err := target.build()
+	if err != nil {
+		return err
+	}
+
+	if tmplPath != "" {
+		if err := applyTemplate(o, tmplPath, dataPath, stdin); err != nil {
+			return err
+		}
+	}
+
+	pushes := []*bark.Options{o}
+	if fromStdin {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		if pushes, err = stdinPushes(o, string(input), split, lastSubtitle); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range pushes {
+		if err := p.Validate(); err != nil {
+			return &usageError{err: err}
+		}
+	}
+	var errs []error
+	for _, p := range pushes {
+		if err := client.Push(ctx, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Fprintln(stdout, "ok")
+	}
+	return nil
+}
+
+// stdinPushes 以标准输入内容作为正文构造推送, 按需拆分为多条
+func stdinPushes(o *bark.Options, input string, split int, lastSubtitle bool) ([]*bark.Options, error) {
+	body := strings.TrimRight(input, "\r\n")
+	if lastSubtitle {
+		if i := strings.LastIndexByte(body, '\n'); i >= 0 {
+			o.Subtitle = strings.TrimSpace(body[i+1:])
+			body = strings.TrimRight(body[:i], "\r\n")
+		} else {
+			o.Subtitle = strings.TrimSpace(body)
+			body = ""
+		}
+	}
+	if strings.TrimSpace(body) == "" && o.Subtitle == "" {
+		return nil, usagef("standard input is empty")
+	}
+	if body == "" {
+		body = o.Subtitle
+	}
+
+	chunks := []string{body}
+	if split > 0 {
+		chunks = splitText(body, split)
+	}
+	if len(chunks) == 1 {
+		o.Body = chunks[0]
+		return []*bark.Options{o}, nil
+	}
+
+	pushes := make([]*bark.Options, 0, len(chunks))
+	for i, chunk := range chunks {
+		p := o.Clone()
+		p.Body = chunk
+		p.Title = strings.TrimSpace(fmt.Sprintf("%s (%d/%d)", o.Title, i+1, len(chunks)))
+		// 共用 ID 会让后续分片覆盖前面的通知
+		p.ID = ""
+		pushes = append(pushes, p)
+	}
+	return pushes, nil
+}
+
+// splitText 按行将文本拆分为不超过 limit 个字符的片段, 超长的单行按字符截断
+func splitText(text string, limit int) []string {
+	var (
+		chunks []string
+		cur    strings.Builder
+		curLen int
+	)
+	flush := func() {
+		if curLen > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			curLen = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for utf8.RuneCountInString(line) > limit {
+			flush()
+			head, rest := cutRunes(line, limit)
+			chunks = append(chunks, strings.TrimRight(head, "\n"))
+			line = rest
+		}
+		n := utf8.RuneCountInString(line)
+		if curLen+n > limit {
+			flush()
+		}
+		cur.WriteString(line)
+		curLen += n
+	}
+	flush()
+
+	for i := range chunks {
+		chunks[i] = strings.TrimRight(chunks[i], "\r\n")
+	}
+	return chunks
+}
+
+func cutRunes(s string, n int) (string, string) {
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos], s[pos:]
+		}
+		i++
+	}
+	return s, ""
+}
+
+// parseError 将 flag 解析错误转换为 usageError, -h 保持原样以便正常退出
+func parseError(err error) error {
+	if err == flag.ErrHelp {
+		return err
+	}
+	return &usageError{err: err}
+}
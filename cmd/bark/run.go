@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exitStatusError 以指定退出码退出, 用于透传被包装命令的退出码
+type exitStatusError struct {
+	code int
+	err  error
+}
+
+func (e *exitStatusError) Error() string { return e.err.Error() }
+func (e *exitStatusError) Unwrap() error { return e.err }
+
+func runRun(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		target       targetFlags
+		tail         int
+		on           string
+		failureLevel string
+	)
+	target.register(fs)
+	fs.IntVar(&tail, "tail", 10, "number of trailing output lines to include in the notification")
+	fs.StringVar(&on, "on", "always", "when to notify: always, success or failure")
+	fs.StringVar(&failureLevel, "failure-level", "timeSensitive", "bark level used when the command fails")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	argv := fs.Args()
+	if len(argv) == 0 {
+		return usagef("usage: bark run [flags] -- command [args...]")
+	}
+	switch on {
+	case "always", "success", "failure":
+	default:
+		return usagef("--on must be always, success or failure")
+	}
+
+	client, o, err := target.build()
+	if err != nil {
+		return err
+	}
+
+	out := newTailBuffer(tail)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = stdin
+	cmd.Stdout = io.MultiWriter(stdout, out)
+	cmd.Stderr = io.MultiWriter(stderr, out)
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start).Round(time.Millisecond)
+
+	code := 0
+	if runErr != nil {
+		code = -1
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+	}
+	failed := runErr != nil
+
+	if (on == "success" && failed) || (on == "failure" && !failed) {
+		return commandResult(code, runErr)
+	}
+
+	name := strings.Join(argv, " ")
+	if failed {
+		if o.Title == "" {
+			o.Title = "❌ " + name
+		}
+		o.Level = failureLevel
+	} else if o.Title == "" {
+		o.Title = "✅ " + name
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "duration: %s\n", duration)
+	if code >= 0 {
+		fmt.Fprintf(&body, "exit code: %d", code)
+	} else {
+		fmt.Fprintf(&body, "error: %v", runErr)
+	}
+	if lines := out.String(); lines != "" {
+		body.WriteString("\n\n")
+		body.WriteString(lines)
+	}
+	o.Body = body.String()
+
+	// 通知使用独立的 context, 被包装命令因信号退出时仍然可以发送
+	pushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), client.HTTPClient.Timeout+time.Second)
+	defer cancel()
+	if err := client.Push(pushCtx, o); err != nil {
+		fmt.Fprintf(stderr, "bark: push failed: %v\n", err)
+		if code == 0 {
+			return &exitStatusError{code: exitFailure, err: err}
+		}
+	}
+	return commandResult(code, runErr)
+}
+
+// commandResult 将被包装命令的结果转换为退出码
+func commandResult(code int, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case code > 0:
+		return &exitStatusError{code: code, err: fmt.Errorf("command exited with code %d", code)}
+	default:
+		return err
+	}
+}
+
+// tailBuffer 只保留最后 n 行输出
+type tailBuffer struct {
+	mu      sync.Mutex
+	n       int
+	lines   []string
+	partial strings.Builder
+}
+
+func newTailBuffer(n int) *tailBuffer {
+	return &tailBuffer{n: n}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	if t.n <= 0 {
+		return len(p), nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range p {
+		if b != '\n' {
+			t.partial.WriteByte(b)
+			continue
+		}
+		t.push(t.partial.String())
+		t.partial.Reset()
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) push(line string) {
+	t.lines = append(t.lines, strings.TrimRight(line, "\r"))
+	if len(t.lines) > t.n {
+		t.lines = t.lines[len(t.lines)-t.n:]
+	}
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := t.lines
+	if t.partial.Len() > 0 {
+		lines = append(append([]string(nil), lines...), t.partial.String())
+		if len(lines) > t.n {
+			lines = lines[len(lines)-t.n:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
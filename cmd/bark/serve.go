@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge/webhook"
+)
+
+func runServe(ctx context.Context, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		target   targetFlags
+		listen   string
+		path     string
+		tmplPath string
+		token    string
+	)
+	target.register(fs)
+	fs.StringVar(&listen, "listen", ":8080", "address to listen on")
+	fs.StringVar(&path, "path", "/webhook", "URL path of the webhook endpoint")
+	fs.StringVar(&tmplPath, "template", "", "Go text/template file mapping the JSON payload to bark parameters")
+	fs.StringVar(&token, "token", os.Getenv("BARK_SERVE_TOKEN"), "require this bearer token (or ?token=), defaults to $BARK_SERVE_TOKEN")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments: %v", fs.Args())
+	}
+
+	client, defaults, err := target.build()
+	if err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if tmplPath != "" {
+		if tmpl, err = template.New(filepath.Base(tmplPath)).Funcs(bark.TemplateFuncs()).ParseFiles(tmplPath); err != nil {
+			return usagef("parse template: %v", err)
+		}
+	}
+
+	h := webhook.New(client, defaults, tmpl)
+	h.Token = token
+	mux := http.NewServeMux()
+	mux.Handle(path, h)
+
+	return serveHTTP(ctx, listen, mux, stdout)
+}
+
+// serveHTTP 启动 HTTP 服务, ctx 结束时优雅退出
+func serveHTTP(ctx context.Context, listen string, handler http.Handler, stdout io.Writer) error {
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(stdout, "listening on %s\n", listen)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
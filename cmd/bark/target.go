@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/credentials"
+)
+
+// defaultCredentialName 未指定 profile 时钥匙串中使用的名称
+const defaultCredentialName = "default"
+
+// stringList 可重复且支持逗号分隔的字符串参数
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// optionValue 将命令行参数写入推送参数, 按出现顺序记录以便覆盖配置中的默认值
+type optionValue struct {
+	name string
+	set  *[][2]string
+}
+
+func (v optionValue) String() string { return "" }
+
+func (v optionValue) Set(s string) error {
+	// 先校验取值是否合法
+	if err := (&bark.Options{}).Set(v.name, s); err != nil {
+		return err
+	}
+	*v.set = append(*v.set, [2]string{v.name, s})
+	return nil
+}
+
+// targetFlags 各子命令共用的连接、收件人、加密及推送参数
+type targetFlags struct {
+	config   string
+	profile  string
+	server   string
+	timeout  time.Duration
+	keys     stringList
+	to       stringList
+	encMode  string
+	encKey   string
+	encHex   string
+	encFile  string
+	encIV    string
+	allowECB bool
+	preset   string
+	// allowScheme 允许 --url 使用 App 深度链接
+	allowScheme bool
+
+	options [][2]string
+}
+
+func (t *targetFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&t.config, "config", os.Getenv("BARK_CONFIG"), "config file (yaml, toml or json), defaults to $BARK_CONFIG or the user config dir")
+	fs.StringVar(&t.profile, "profile", "", "profile name in the config file")
+	fs.StringVar(&t.server, "server", "", "server URL, overrides config and $BARK_SERVER_URL")
+	fs.DurationVar(&t.timeout, "timeout", 0, "request timeout")
+	fs.Var(&t.keys, "key", "device key, repeatable or comma separated")
+	fs.Var(&t.to, "to", "recipient alias or group from the config, repeatable or comma separated")
+	fs.StringVar(&t.encMode, "enc-mode", "", "encryption mode: GCM, CBC or ECB")
+	fs.StringVar(&t.encKey, "enc-key", "", "encryption key, defaults to the key stored by \"bark login\"")
+	fs.StringVar(&t.encHex, "enc-key-hex", "", "hex encoded encryption key")
+	fs.StringVar(&t.encFile, "enc-key-file", "", "file containing the raw encryption key")
+	fs.StringVar(&t.encIV, "enc-iv", "", "encryption IV (CBC) or nonce (GCM)")
+	fs.BoolVar(&t.allowECB, "allow-insecure-ecb", false, "allow the insecure ECB mode")
+	fs.StringVar(&t.preset, "preset", "", "notification style preset: success, warning, error or critical")
+	fs.BoolVar(&t.allowScheme, "allow-custom-scheme", false, "allow --url to use non-http(s) schemes such as app deep links")
+
+	for _, name := range bark.OptionNames() {
+		switch name {
+		case "device_key", "device_keys":
+			continue
+		}
+		fs.Var(optionValue{name: name, set: &t.options}, flagName(name), "bark parameter "+name)
+	}
+}
+
+// build 构造客户端和推送参数: 配置文件或环境变量提供默认值, 命令行参数优先
+func (t *targetFlags) build() (*bark.Client, *bark.Options, error) {
+	client, defaults, err := t.base()
+	if err != nil {
+		return nil, nil, &usageError{err: err}
+	}
+	if t.timeout > 0 {
+		client.HTTPClient.Timeout = t.timeout
+	}
+
+	o := defaults.Clone()
+	for _, kv := range t.options {
+		if err := o.Set(kv[0], kv[1]); err != nil {
+			return nil, nil, &usageError{err: err}
+		}
+	}
+	if t.preset != "" {
+		p, err := bark.PresetByName(t.preset)
+		if err != nil {
+			return nil, nil, &usageError{err: err}
+		}
+		o.ApplyPreset(p)
+	}
+	if t.allowScheme {
+		o.AllowCustomScheme = true
+	}
+
+	if len(t.keys) > 0 || len(t.to) > 0 {
+		o.DeviceKey = ""
+		o.DeviceKeys = nil
+		for _, key := range t.keys {
+			if _, resolveErr := client.Resolve(key); resolveErr == nil {
+				// --key 也接受配置中的别名
+				o.Recipients = append(o.Recipients, key)
+			} else {
+				o.DeviceKeys = append(o.DeviceKeys, key)
+			}
+		}
+		o.Recipients = append(o.Recipients, t.to...)
+	}
+
+	store := credentials.New("")
+	name := t.credentialName()
+	if o.DeviceKey == "" && len(o.DeviceKeys) == 0 && len(o.Recipients) == 0 {
+		key, err := store.DeviceKey(name)
+		if err != nil {
+			return nil, nil, usagef("no device key: use --key, a config profile, $BARK_DEVICE_KEY or \"bark login\"")
+		}
+		o.DeviceKey = key
+	}
+
+	if t.encMode != "" {
+		enc := &bark.EncOpt{
+			Mode:             bark.EncMode(strings.ToUpper(t.encMode)),
+			Key:              t.encKey,
+			KeyHex:           t.encHex,
+			KeyFile:          t.encFile,
+			Iv:               t.encIV,
+			AllowInsecureECB: t.allowECB,
+		}
+		if enc.Key == "" && enc.KeyHex == "" && enc.KeyFile == "" {
+			key, err := store.EncryptionKey(name)
+			if err != nil {
+				return nil, nil, usagef("--enc-mode requires --enc-key, --enc-key-hex, --enc-key-file or a key stored by \"bark login\"")
+			}
+			enc.Key = string(key)
+		}
+		o.Enc = enc
+	}
+
+	return client, o, nil
+}
+
+// base 读取配置文件 (若存在), 否则读取环境变量; --server 只替换其中的服务器地址
+func (t *targetFlags) base() (*bark.Client, *bark.Options, error) {
+	client, defaults, err := t.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.server != "" {
+		// 借助 bark.New 按相同规则补全协议和去掉末尾的 /
+		client.ServerURL = bark.New(t.server).ServerURL
+	}
+	return client, defaults, nil
+}
+
+func (t *targetFlags) load() (*bark.Client, *bark.Options, error) {
+	path := t.config
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path == "" {
+		if t.profile != "" {
+			return nil, nil, errors.New("--profile requires a config file")
+		}
+		return bark.FromEnv()
+	}
+
+	cfg, err := bark.LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Client(t.profile)
+}
+
+func (t *targetFlags) credentialName() string {
+	if t.profile != "" {
+		return t.profile
+	}
+	return defaultCredentialName
+}
+
+// defaultConfigPath 返回用户配置目录下第一个存在的 bark/config.{yaml,yml,toml,json}
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	for _, ext := range []string{"yaml", "yml", "toml", "json"} {
+		path := filepath.Join(dir, "bark", "config."+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// flagName 将参数名转换为命令行参数名, 如 autoCopy -> auto-copy
+func flagName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
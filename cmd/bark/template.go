@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// applyTemplate 使用 text/template 渲染推送内容
+// 模板文件通过 {{define "title"}}...{{end}} 等以参数名定义各字段; 未定义任何字段时整个模板作为 body
+// data 为 JSON 文件路径, - 表示标准输入, 为空时模板数据为 nil
+func applyTemplate(o *bark.Options, tmplPath, dataPath string, stdin io.Reader) error {
+	text, err := os.ReadFile(tmplPath)
+	if err != nil {
+		return &usageError{err: err}
+	}
+	tmpl, err := template.New("root").Option("missingkey=error").Funcs(bark.TemplateFuncs()).Parse(string(text))
+	if err != nil {
+		return &usageError{err: fmt.Errorf("parse template: %w", err)}
+	}
+
+	var data interface{}
+	if dataPath != "" {
+		var raw []byte
+		if dataPath == "-" {
+			raw, err = io.ReadAll(stdin)
+		} else {
+			raw, err = os.ReadFile(dataPath)
+		}
+		if err != nil {
+			return &usageError{err: err}
+		}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return &usageError{err: fmt.Errorf("parse template data: %w", err)}
+		}
+	}
+
+	defined, err := bark.RenderOptions(tmpl, data, o)
+	if err != nil {
+		return &usageError{err: fmt.Errorf("render template: %w", err)}
+	}
+	if defined {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return &usageError{err: fmt.Errorf("render template: %w", err)}
+	}
+	o.Body = strings.TrimSpace(buf.String())
+	return nil
+}
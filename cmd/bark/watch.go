@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+func runWatch(ctx context.Context, args []string, stdin io.Reader, _, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bark watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		target    targetFlags
+		file      string
+		patterns  stringList
+		fromStart bool
+		poll      time.Duration
+		dedup     time.Duration
+		throttle  time.Duration
+	)
+	target.register(fs)
+	fs.StringVar(&file, "file", "-", "file to follow, - reads standard input")
+	fs.Var(&patterns, "pattern", "regular expression to match, repeatable (a line matching any pattern is pushed)")
+	fs.BoolVar(&fromStart, "from-start", false, "read the file from the beginning instead of the end")
+	fs.DurationVar(&poll, "poll", time.Second, "polling interval while waiting for new lines")
+	fs.DurationVar(&dedup, "dedup", 5*time.Minute, "suppress identical lines seen within this window, 0 disables")
+	fs.DurationVar(&throttle, "throttle", 30*time.Second, "minimum interval between pushes, 0 disables")
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments: %v", fs.Args())
+	}
+	if len(patterns) == 0 {
+		return usagef("watch requires at least one --pattern")
+	}
+
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return usagef("invalid --pattern %q: %v", p, err)
+		}
+		res = append(res, re)
+	}
+
+	client, tmpl, err := target.build()
+	if err != nil {
+		return err
+	}
+	if tmpl.Title == "" {
+		source := file
+		if source == "-" {
+			source = "stdin"
+		}
+		tmpl.Title = "bark watch: " + source
+	}
+
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		if file == "-" {
+			errc <- scanLines(ctx, stdin, lines)
+			return
+		}
+		errc <- followFile(ctx, file, fromStart, poll, lines)
+	}()
+
+	w := &watcher{
+		pusher:   client,
+		tmpl:     tmpl,
+		patterns: res,
+		dedup:    dedup,
+		throttle: throttle,
+		seen:     make(map[string]time.Time),
+		stderr:   stderr,
+		clock:    bark.SystemClock,
+	}
+loop:
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				break loop
+			}
+			w.handle(ctx, line)
+		case <-w.flushC():
+			w.flush(ctx)
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	// 收到中断信号时 ctx 已取消, 最后一次推送使用独立的超时
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalFlushTimeout)
+	w.flush(flushCtx)
+	cancel()
+
+	if ctx.Err() != nil {
+		// 读取标准输入的协程可能阻塞在 Read 上, 不再等待
+		return nil
+	}
+	if err := <-errc; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// finalFlushTimeout 退出前推送被限流的匹配行的超时
+const finalFlushTimeout = 10 * time.Second
+
+// watcher 匹配日志行并按去重和限流规则推送
+type watcher struct {
+	pusher   bark.Pusher
+	tmpl     *bark.Options
+	patterns []*regexp.Regexp
+	dedup    time.Duration
+	throttle time.Duration
+	stderr   io.Writer
+	clock    bark.Clock
+
+	seen       map[string]time.Time
+	lastPush   time.Time
+	suppressed []string
+	// timer 限流窗口结束时触发, 推送被限流的匹配行
+	timer bark.Timer
+}
+
+func (w *watcher) handle(ctx context.Context, line string) {
+	if !w.matches(line) {
+		return
+	}
+	now := w.clock.Now()
+	if w.dedup > 0 {
+		if last, ok := w.seen[line]; ok && now.Sub(last) < w.dedup {
+			return
+		}
+		w.seen[line] = now
+		if len(w.seen) > 10000 {
+			w.expire(now)
+		}
+	}
+	if w.throttle > 0 && now.Sub(w.lastPush) < w.throttle {
+		w.suppressed = append(w.suppressed, line)
+		if w.timer == nil {
+			w.timer = w.clock.NewTimer(w.throttle - now.Sub(w.lastPush))
+		}
+		return
+	}
+	w.push(ctx, line, now)
+}
+
+// flushC 返回限流窗口结束的通知, 没有被限流的匹配行时为 nil
+func (w *watcher) flushC() <-chan time.Time {
+	if w.timer == nil {
+		return nil
+	}
+	return w.timer.C()
+}
+
+// flush 限流窗口结束或输入结束时推送被限流的匹配行
+func (w *watcher) flush(ctx context.Context) {
+	if len(w.suppressed) == 0 {
+		return
+	}
+	last := w.suppressed[len(w.suppressed)-1]
+	w.suppressed = w.suppressed[:len(w.suppressed)-1]
+	w.push(ctx, last, w.clock.Now())
+}
+
+func (w *watcher) push(ctx context.Context, line string, now time.Time) {
+	o := w.tmpl.Clone()
+	o.Body = line
+	if n := len(w.suppressed); n > 0 {
+		o.Body = fmt.Sprintf("%s\n\n(+%d more matches suppressed, last: %s)", line, n, w.suppressed[n-1])
+		w.suppressed = nil
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.lastPush = now
+	if err := w.pusher.Push(ctx, o); err != nil {
+		fmt.Fprintf(w.stderr, "bark: push failed: %v\n", err)
+	}
+}
+
+func (w *watcher) matches(line string) bool {
+	for _, re := range w.patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watcher) expire(now time.Time) {
+	for line, t := range w.seen {
+		if now.Sub(t) >= w.dedup {
+			delete(w.seen, line)
+		}
+	}
+}
+
+func scanLines(ctx context.Context, r io.Reader, lines chan<- string) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		select {
+		case lines <- sc.Text():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return sc.Err()
+}
+
+// followFile 类似 tail -F: 轮询读取新增行, 文件被截断或轮转时重新打开
+func followFile(ctx context.Context, path string, fromStart bool, poll time.Duration, lines chan<- string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	if !fromStart {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	r := bufio.NewReader(f)
+	var partial strings.Builder
+	for {
+		chunk, err := r.ReadString('\n')
+		partial.WriteString(chunk)
+		if err == nil {
+			select {
+			case lines <- strings.TrimRight(partial.String(), "\r\n"):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			partial.Reset()
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-time.After(poll):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		reopen, err := rotated(f, path)
+		if err != nil {
+			return err
+		}
+		if reopen {
+			nf, err := os.Open(path)
+			if err != nil {
+				// 轮转过程中文件可能暂时不存在
+				continue
+			}
+			f.Close()
+			f = nf
+			r.Reset(f)
+			partial.Reset()
+		}
+	}
+}
+
+// rotated 判断文件是否被截断或替换
+func rotated(f *os.File, path string) (bool, error) {
+	cur, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	latest, err := os.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	if !os.SameFile(cur, latest) {
+		return true, nil
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	return latest.Size() < offset, nil
+}
@@ -0,0 +1,45 @@
+package bark
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonCodec 自定义 JSON 编解码函数, nil 或未设置的函数使用 encoding/json
+type jsonCodec struct {
+	marshal   func(v interface{}) ([]byte, error)
+	unmarshal func(data []byte, v interface{}) error
+}
+
+// WithJSONCodec 替换客户端使用的 JSON 编解码函数, 如 jsoniter 或 sonic 的 Marshal 和 Unmarshal
+//
+// marshal 用于序列化推送参数, 加密前的明文和加密推送的外层结构, unmarshal 用于解析服务器响应;
+// 为 nil 的一方继续使用 encoding/json. 自定义编码器需遵守 Options 的 json 标签
+func WithJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) ClientOption {
+	return func(c *Client) {
+		if marshal == nil && unmarshal == nil {
+			c.codec = nil
+			return
+		}
+		c.codec = &jsonCodec{marshal: marshal, unmarshal: unmarshal}
+	}
+}
+
+func (c *jsonCodec) encode(buf *bytes.Buffer, v interface{}) error {
+	if c == nil || c.marshal == nil {
+		return encodeJSON(buf, v)
+	}
+	b, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+func (c *jsonCodec) decode(data []byte, v interface{}) error {
+	if c == nil || c.unmarshal == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.unmarshal(data, v)
+}
@@ -0,0 +1,256 @@
+package bark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 配置文件结构, YAML/TOML/JSON 使用相同的字段名:
+//
+//	default: home
+//	aliases:
+//	  dad: DEVICE_KEY_OF_DAD
+//	  mum: DEVICE_KEY_OF_MUM
+//	groups:
+//	  family: [dad, mum]
+//	profiles:
+//	  home:
+//	    server: https://api.day.app
+//	    timeout: 10s
+//	    encryption: {mode: GCM, key: 16byteskey123456, iv: 12bytesnonce}
+//	    defaults: {device_keys: [dad], group: home, sound: alarm}
+type Config struct {
+	// Default 默认使用的 profile 名称
+	Default string `json:"default,omitempty"`
+	// Aliases 所有 profile 共享的设备别名, 别名 -> 设备 Key
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Groups 所有 profile 共享的收件人分组, 分组名 -> 成员别名
+	Groups map[string][]string `json:"groups,omitempty"`
+	// Profiles 按名称配置的服务器
+	Profiles map[string]*ProfileConfig `json:"profiles,omitempty"`
+}
+
+// ProfileConfig 单个服务器的配置
+type ProfileConfig struct {
+	// Server 服务器地址, 为空时使用 DefaultURL
+	Server string `json:"server,omitempty"`
+	// Timeout 请求超时
+	Timeout Duration `json:"timeout,omitempty"`
+	// Aliases 当前 profile 的设备别名, 与全局别名合并, 同名时覆盖全局
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Groups 当前 profile 的收件人分组, 与全局分组合并, 同名时覆盖全局
+	Groups map[string][]string `json:"groups,omitempty"`
+	// Encryption 客户端默认加密参数
+	Encryption *EncConfig `json:"encryption,omitempty"`
+	// Defaults 默认推送参数, device_key(s) 中可以使用别名
+	Defaults *Options `json:"defaults,omitempty"`
+}
+
+// EncConfig 配置文件中的加密参数
+type EncConfig struct {
+	Mode             EncMode        `json:"mode,omitempty"`
+	Key              string         `json:"key,omitempty"`
+	KeyHex           string         `json:"key_hex,omitempty"`
+	KeyBase64        string         `json:"key_base64,omitempty"`
+	KeyFile          string         `json:"key_file,omitempty"`
+	Passphrase       string         `json:"passphrase,omitempty"`
+	Salt             string         `json:"salt,omitempty"`
+	KDF              KDF            `json:"kdf,omitempty"`
+	Iterations       int            `json:"iterations,omitempty"`
+	KeySize          int            `json:"key_size,omitempty"`
+	Iv               string         `json:"iv,omitempty"`
+	Encoding         CipherEncoding `json:"encoding,omitempty"`
+	PrefixIV         bool           `json:"prefix_iv,omitempty"`
+	AllowInsecureECB bool           `json:"allow_insecure_ecb,omitempty"`
+}
+
+// EncOpt 转换为 EncOpt
+func (e *EncConfig) EncOpt() *EncOpt {
+	return &EncOpt{
+		Mode:             EncMode(strings.ToUpper(string(e.Mode))),
+		Key:              e.Key,
+		KeyHex:           e.KeyHex,
+		KeyBase64:        e.KeyBase64,
+		KeyFile:          e.KeyFile,
+		Passphrase:       e.Passphrase,
+		Salt:             e.Salt,
+		KDF:              e.KDF,
+		Iterations:       e.Iterations,
+		KeySize:          e.KeySize,
+		Iv:               e.Iv,
+		Encoding:         e.Encoding,
+		PrefixIV:         e.PrefixIV,
+		AllowInsecureECB: e.AllowInsecureECB,
+	}
+}
+
+// Duration 支持 "10s" 形式或整数秒的时长
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		// 非字符串时按整数秒处理
+		s = string(b)
+	}
+	v, err := parseTimeout(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: %w", string(b), err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig 读取配置文件, 按扩展名识别格式 (.yaml/.yml, .toml, .json)
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseConfig(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig 解析配置内容, format 为 yaml, yml, toml 或 json
+func ParseConfig(data []byte, format string) (*Config, error) {
+	var raw map[string]interface{}
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case "toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format: %q (supported: yaml, toml, json)", format)
+	}
+
+	// 统一转换为 JSON 后解码, 三种格式共用 json tag 定义的字段名
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(normalized, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ProfileNames 返回排序后的 profile 名称
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Client 根据名为 name 的 profile 构造客户端和默认推送参数
+// name 为空时使用 Default, 若只配置了一个 profile 则使用该 profile
+func (c *Config) Client(name string) (*Client, *Options, error) {
+	name, p, err := c.profile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aliases := c.aliases(p)
+	opts := []ClientOption{WithAliases(aliases), WithGroups(c.groups(p))}
+	if p.Encryption != nil {
+		enc := p.Encryption.EncOpt()
+		if err := enc.validate(); err != nil {
+			return nil, nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		opts = append(opts, WithEncryption(enc))
+	}
+
+	client := New(p.Server, opts...)
+	if p.Timeout > 0 {
+		client.HTTPClient.Timeout = time.Duration(p.Timeout)
+	}
+
+	defaults := &Options{}
+	if p.Defaults != nil {
+		defaults = p.Defaults.Clone()
+	}
+	defaults.DeviceKey = resolveAlias(aliases, defaults.DeviceKey)
+	for i, key := range defaults.DeviceKeys {
+		defaults.DeviceKeys[i] = resolveAlias(aliases, key)
+	}
+
+	return client, defaults, nil
+}
+
+func (c *Config) profile(name string) (string, *ProfileConfig, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		if len(c.Profiles) != 1 {
+			return "", nil, errors.New("no profile specified and config has no default")
+		}
+		for n := range c.Profiles {
+			name = n
+		}
+	}
+	p, ok := c.Profiles[name]
+	if !ok || p == nil {
+		return "", nil, fmt.Errorf("unknown profile: %s", strconv.Quote(name))
+	}
+	return name, p, nil
+}
+
+// aliases 合并全局和 profile 的设备别名
+func (c *Config) aliases(p *ProfileConfig) map[string]string {
+	merged := make(map[string]string, len(c.Aliases)+len(p.Aliases))
+	for k, v := range c.Aliases {
+		merged[k] = v
+	}
+	for k, v := range p.Aliases {
+		merged[k] = v
+	}
+	return merged
+}
+
+// groups 合并全局和 profile 的收件人分组
+func (c *Config) groups(p *ProfileConfig) map[string][]string {
+	merged := make(map[string][]string, len(c.Groups)+len(p.Groups))
+	for k, v := range c.Groups {
+		merged[k] = v
+	}
+	for k, v := range p.Groups {
+		merged[k] = v
+	}
+	return merged
+}
+
+func resolveAlias(aliases map[string]string, name string) string {
+	if key, ok := aliases[name]; ok {
+		return key
+	}
+	return name
+}
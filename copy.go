@@ -0,0 +1,61 @@
+package bark
+
+import (
+	"strings"
+
+	"github.com/gaoyaxuan/go-bark/md"
+)
+
+// autoCopyMinIOS 从该 iOS 版本起, 系统不再允许通知在送达时自动写入剪贴板, 需要用户长按或下拉通知后复制
+const autoCopyMinIOS = 16
+
+// CopyCompat 复制内容时的兼容设置, 见 Options.SetCopy
+type CopyCompat struct {
+	// TargetIOS 接收设备的 iOS 主版本号, 0 表示未知 (按 iOS 16 及以上处理)
+	TargetIOS int
+	// AppendToBody 自动复制不可靠时, 把复制内容追加到正文 (已包含时不追加), 便于用户直接看到
+	AppendToBody bool
+}
+
+// SetCopy 设置通知要复制的内容, 并按目标 iOS 版本兼容自动复制的差异, 返回 o 以便链式调用
+//
+// 始终设置 copy 和 autoCopy=1: iOS 16 以下送达时自动复制; iOS 16 及以上系统只在用户长按或下拉通知时复制,
+// 此时按 AppendToBody 把内容追加到正文 (设置了 Markdown 时追加为行内代码)
+func (o *Options) SetCopy(value string, compat CopyCompat) *Options {
+	o.Copy = value
+	o.AutoCopy = "1"
+	o.AutoCopyEnabled = nil
+	if !compat.AppendToBody || value == "" {
+		return o
+	}
+	if compat.TargetIOS != 0 && compat.TargetIOS < autoCopyMinIOS {
+		return o
+	}
+	switch {
+	case o.Markdown != "":
+		if !strings.Contains(o.Markdown, value) {
+			o.Markdown += "\n\n" + md.Code(value)
+		}
+	case o.Body == "":
+		o.Body = value
+	case !strings.Contains(o.Body, value):
+		o.Body += "\n" + value
+	}
+	return o
+}
+
+// WithCopyCompat 为该客户端所有设置了 Copy 的推送应用 SetCopy 的兼容处理, 用于已知设备 iOS 版本的场景
+// 显式设置 autoCopy=0 的推送不受影响
+func WithCopyCompat(compat CopyCompat) ClientOption {
+	return func(c *Client) {
+		c.copyCompat = &compat
+	}
+}
+
+// withCopyCompat 按客户端的兼容设置处理复制内容, 需要修改时返回副本
+func (c *Client) withCopyCompat(o *Options) *Options {
+	if c.copyCompat == nil || o.Copy == "" || o.AutoCopy == "0" {
+		return o
+	}
+	return o.Clone().SetCopy(o.Copy, *c.copyCompat)
+}
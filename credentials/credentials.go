@@ -0,0 +1,106 @@
+// Package credentials 使用操作系统钥匙串 (macOS Keychain, Windows Credential Manager,
+// Linux Secret Service) 保存和读取设备 Key 及加密密钥
+package credentials
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// DefaultService 钥匙串中使用的默认服务名
+const DefaultService = "go-bark"
+
+// ErrNotFound 钥匙串中不存在对应的条目
+var ErrNotFound = errors.New("credentials: not found in keyring")
+
+const (
+	deviceKeyPrefix = "device-key:"
+	encKeyPrefix    = "enc-key:"
+)
+
+// Store 钥匙串存储, 条目按 Service 隔离
+type Store struct {
+	Service string
+}
+
+// New 创建使用指定服务名的 Store, service 为空时使用 DefaultService
+func New(service string) *Store {
+	if service == "" {
+		service = DefaultService
+	}
+	return &Store{Service: service}
+}
+
+// SetDeviceKey 保存名为 name 的设备 Key (name 可以是 profile 名或设备别名)
+func (s *Store) SetDeviceKey(name, deviceKey string) error {
+	return keyring.Set(s.service(), deviceKeyPrefix+name, deviceKey)
+}
+
+// DeviceKey 读取名为 name 的设备 Key
+func (s *Store) DeviceKey(name string) (string, error) {
+	return s.get(deviceKeyPrefix + name)
+}
+
+// DeleteDeviceKey 删除名为 name 的设备 Key
+func (s *Store) DeleteDeviceKey(name string) error {
+	return s.delete(deviceKeyPrefix + name)
+}
+
+// SetEncryptionKey 保存名为 name 的加密密钥, 以 base64 形式存储以支持二进制密钥
+func (s *Store) SetEncryptionKey(name string, key []byte) error {
+	return keyring.Set(s.service(), encKeyPrefix+name, base64.StdEncoding.EncodeToString(key))
+}
+
+// EncryptionKey 读取名为 name 的加密密钥
+func (s *Store) EncryptionKey(name string) ([]byte, error) {
+	v, err := s.get(encKeyPrefix + name)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// DeleteEncryptionKey 删除名为 name 的加密密钥
+func (s *Store) DeleteEncryptionKey(name string) error {
+	return s.delete(encKeyPrefix + name)
+}
+
+// KeyProvider 返回以设备 Key 为名称读取加密密钥的 bark.KeyProvider
+// 设备未单独保存密钥时回退到名为 fallback 的密钥, fallback 为空则返回 ErrNotFound
+func (s *Store) KeyProvider(fallback string) bark.KeyProvider {
+	return bark.KeyProviderFunc(func(_ context.Context, deviceKey string) ([]byte, error) {
+		key, err := s.EncryptionKey(deviceKey)
+		if errors.Is(err, ErrNotFound) && fallback != "" {
+			return s.EncryptionKey(fallback)
+		}
+		return key, err
+	})
+}
+
+func (s *Store) get(account string) (string, error) {
+	v, err := keyring.Get(s.service(), account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return v, err
+}
+
+func (s *Store) delete(account string) error {
+	err := keyring.Delete(s.service(), account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Store) service() string {
+	if s.Service == "" {
+		return DefaultService
+	}
+	return s.Service
+}
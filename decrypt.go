@@ -0,0 +1,151 @@
+package bark
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Decrypter 解密器接口, 自定义 Encrypter 同时实现该接口时 Decrypt 可用于解密其密文
+type Decrypter interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Decrypt 解密由 SDK 生成的密文, 返回 JSON 明文
+// 与加密时相同, 会依次处理 Encoding, PrefixIV 和加密模式; 不支持 KeyProvider
+func Decrypt(ciphertext string, opt *EncOpt) ([]byte, error) {
+	if opt.KeyProvider != nil {
+		return nil, errors.New("decrypt does not support KeyProvider, resolve the key first")
+	}
+
+	data, err := decodeCipherText(ciphertext, opt.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.Encrypter != nil {
+		d, ok := opt.Encrypter.(Decrypter)
+		if !ok {
+			return nil, errors.New("custom encrypter does not implement Decrypter")
+		}
+		return d.Decrypt(data)
+	}
+
+	if opt.PrefixIV {
+		switch EncMode(strings.ToUpper(string(opt.Mode))) {
+		case EncModeCBC, EncModeGCM:
+			n := len(opt.Iv)
+			if n == 0 {
+				n = ivSize(opt.Mode)
+			}
+			if len(data) < n {
+				return nil, errors.New("ciphertext shorter than iv prefix")
+			}
+			withIV := *opt
+			withIV.Iv = string(data[:n])
+			opt = &withIV
+			data = data[n:]
+		}
+	}
+	return aesDecrypt(data, opt)
+}
+
+// ivSize 返回模式要求的 IV/Nonce 长度
+func ivSize(mode EncMode) int {
+	if EncMode(strings.ToUpper(string(mode))) == EncModeGCM {
+		return 12
+	}
+	return aes.BlockSize
+}
+
+func decodeCipherText(s string, encoding CipherEncoding) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch encoding {
+	case EncodingURLBase64:
+		data, err = base64.URLEncoding.DecodeString(s)
+	case EncodingHex:
+		data, err = hex.DecodeString(s)
+	default:
+		data, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode ciphertext: %w", err)
+	}
+	return data, nil
+}
+
+// pKCS7Unpadding 去除 PKCS7 填充
+func pKCS7Unpadding(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, errors.New("invalid padded data length")
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-padding], nil
+}
+
+// aesDecrypt aesEncrypt 的逆操作
+func aesDecrypt(data []byte, opt *EncOpt) ([]byte, error) {
+	key, err := opt.keyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := block.BlockSize()
+	switch EncMode(strings.ToUpper(string(opt.Mode))) {
+	case EncModeCBC:
+		iv := []byte(opt.Iv)
+		if len(iv) != blockSize {
+			return nil, fmt.Errorf("CBC IV length must be %d", blockSize)
+		}
+		if len(data)%blockSize != 0 {
+			return nil, errors.New("ciphertext is not a multiple of the block size")
+		}
+		plain := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+		return pKCS7Unpadding(plain, blockSize)
+
+	case EncModeECB:
+		if len(data)%blockSize != 0 {
+			return nil, errors.New("ciphertext is not a multiple of the block size")
+		}
+		plain := make([]byte, len(data))
+		for i := 0; i < len(data); i += blockSize {
+			block.Decrypt(plain[i:i+blockSize], data[i:i+blockSize])
+		}
+		return pKCS7Unpadding(plain, blockSize)
+
+	case EncModeGCM:
+		nonce := []byte(opt.Iv)
+		if len(nonce) != 12 {
+			return nil, fmt.Errorf("GCM Nonce length must be 12 bytes")
+		}
+		aesGCM, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return aesGCM.Open(nil, nonce, data, nil)
+
+	default:
+		return nil, errors.New("unsupported encryption mode")
+	}
+}
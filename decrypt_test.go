@@ -0,0 +1,129 @@
+package bark_test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+func TestDecryptRoundTrip(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "bark.key")
+	if err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		enc  bark.EncOpt
+	}{
+		{"cbc", bark.EncOpt{Mode: bark.EncModeCBC, Key: "0123456789abcdef", Iv: "fedcba9876543210"}},
+		{"cbc lower case mode", bark.EncOpt{Mode: "cbc", Key: "0123456789abcdef", Iv: "fedcba9876543210"}},
+		{"gcm", bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef01234567", Iv: "0123456789ab"}},
+		{"ecb", bark.EncOpt{Mode: bark.EncModeECB, Key: "0123456789abcdef", AllowInsecureECB: true}},
+		{"url base64", bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab", Encoding: bark.EncodingURLBase64}},
+		{"hex", bark.EncOpt{Mode: bark.EncModeCBC, Key: "0123456789abcdef", Iv: "fedcba9876543210", Encoding: bark.EncodingHex}},
+		{"cbc prefix iv", bark.EncOpt{Mode: bark.EncModeCBC, Key: "0123456789abcdef", Iv: "fedcba9876543210", PrefixIV: true}},
+		{"gcm prefix iv", bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab", PrefixIV: true, Encoding: bark.EncodingHex}},
+		{"key hex", bark.EncOpt{Mode: bark.EncModeGCM, KeyHex: "000102030405060708090a0b0c0d0e0f", Iv: "0123456789ab"}},
+		{"key base64", bark.EncOpt{Mode: bark.EncModeGCM, KeyBase64: "AAECAwQFBgcICQoLDA0ODw==", Iv: "0123456789ab"}},
+		{"key file", bark.EncOpt{Mode: bark.EncModeCBC, KeyFile: keyFile, Iv: "fedcba9876543210"}},
+		{"pbkdf2", bark.EncOpt{Mode: bark.EncModeGCM, Passphrase: "correct horse", Salt: "battery staple", Iterations: 1000, Iv: "0123456789ab"}},
+		{"scrypt", bark.EncOpt{Mode: bark.EncModeCBC, Passphrase: "correct horse", Salt: "battery staple", KDF: bark.KDFScrypt, Iterations: 1 << 10, KeySize: 16, Iv: "fedcba9876543210"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := tt.enc
+			o := &bark.Options{DeviceKey: "key1", DeviceKeys: []string{"key2"}, Title: "title", Body: strings.Repeat("body ", 10), Enc: &enc}
+			ciphertext, err := o.Ciphertext()
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := bark.Decrypt(ciphertext, &enc)
+			if err != nil {
+				t.Fatalf("Decrypt() = %v", err)
+			}
+			var got bark.Options
+			if err := json.Unmarshal(plain, &got); err != nil {
+				t.Fatalf("plaintext %q: %v", plain, err)
+			}
+			if got.Title != o.Title || got.Body != o.Body {
+				t.Errorf("decrypted %+v", got)
+			}
+			// 明文中不包含设备 Key
+			if got.DeviceKey != "" || len(got.DeviceKeys) != 0 {
+				t.Errorf("plaintext leaks device keys: %s", plain)
+			}
+		})
+	}
+}
+
+func TestDecryptCustomEncrypter(t *testing.T) {
+	block, err := aes.NewCipher([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := &bark.EncOpt{Encrypter: bark.NewAEADEncrypter(aead, []byte("0123456789ab"))}
+	o := &bark.Options{Body: "custom", Enc: enc}
+	ciphertext, err := o.Ciphertext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := bark.Decrypt(ciphertext, enc)
+	if err != nil || !strings.Contains(string(plain), `"custom"`) {
+		t.Fatalf("Decrypt() = %q, %v", plain, err)
+	}
+
+	// 只实现了 Encrypter 的自定义加密器无法解密
+	encOnly := &bark.EncOpt{Encrypter: bark.EncrypterFunc(func(p []byte) ([]byte, error) { return p, nil })}
+	if _, err := bark.Decrypt(ciphertext, encOnly); err == nil {
+		t.Error("Decrypt() with an encrypt-only Encrypter should fail")
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	cbc := &bark.EncOpt{Mode: bark.EncModeCBC, Key: "0123456789abcdef", Iv: "fedcba9876543210"}
+	gcm := &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab"}
+	cbcText, err := (&bark.Options{Body: "x", Enc: cbc}).Ciphertext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcmText, err := (&bark.Options{Body: "x", Enc: gcm}).Ciphertext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		enc        *bark.EncOpt
+	}{
+		{"wrong gcm key", gcmText, &bark.EncOpt{Mode: bark.EncModeGCM, Key: "fedcba9876543210", Iv: "0123456789ab"}},
+		{"wrong gcm nonce", gcmText, &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "ba9876543210"}},
+		{"tampered gcm", gcmText[:len(gcmText)-4] + "AAA=", gcm},
+		{"wrong cbc key", cbcText, &bark.EncOpt{Mode: bark.EncModeCBC, Key: "fedcba9876543210", Iv: "fedcba9876543210"}},
+		{"wrong mode", cbcText, gcm},
+		{"bad base64", "not base64!", cbc},
+		{"bad hex", "zz", &bark.EncOpt{Mode: bark.EncModeCBC, Key: "0123456789abcdef", Iv: "fedcba9876543210", Encoding: bark.EncodingHex}},
+		{"short cbc", "AAAA", cbc},
+		{"shorter than iv prefix", "AAAA", &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", PrefixIV: true}},
+		{"key provider", gcmText, &bark.EncOpt{Mode: bark.EncModeGCM, KeyProvider: bark.KeyProviderFunc(func(context.Context, string) ([]byte, error) { return nil, nil }), Iv: "0123456789ab"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plain, err := bark.Decrypt(tt.ciphertext, tt.enc); err == nil {
+				t.Errorf("Decrypt() = %q, want an error", plain)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DeepLink 生成与推送等价的 Bark GET 链接 https://host/key/title/subtitle/body?group=...,
+// 可用于 "重新发送" 链接, 二维码或快捷指令 (Shortcuts) 自动化. serverURL 为空时使用 DefaultURL
+//
+// 路径段和查询参数均经过转义; 设置了 Enc 时只包含 ciphertext (和 iv) 参数, 链接中不出现明文内容.
+// 链接只能指定一个设备, 不支持 KeyProvider 和 DeviceEnc
+func (o *Options) DeepLink(serverURL string) (string, error) {
+	return o.deepLink(serverURL, nil)
+}
+
+func (o *Options) deepLink(serverURL string, codec *jsonCodec) (string, error) {
+	keys := o.routingKeys()
+	if len(keys) != 1 {
+		return "", fmt.Errorf("deep link requires exactly one device key, got %d", len(keys))
+	}
+	if o.perDevice() {
+		return "", errors.New("deep link does not support key providers or per-device encryption")
+	}
+	o = o.withBoolFields()
+
+	link := normalizeServerURL(serverURL) + "/" + url.PathEscape(keys[0])
+	query := url.Values{}
+	if o.Enc != nil {
+		ciphertext, err := o.ciphertext(codec)
+		if err != nil {
+			return "", err
+		}
+		query.Set("ciphertext", ciphertext)
+		if iv := o.Enc.queryIV(); iv != "" {
+			query.Set("iv", iv)
+		}
+		return link + "?" + query.Encode(), nil
+	}
+
+	// 与 bark-server 的路由一致: /key/body, /key/title/body, /key/title/subtitle/body
+	inPath := map[string]bool{}
+	if o.Body != "" {
+		switch {
+		case o.Title != "" && o.Subtitle != "":
+			link += "/" + url.PathEscape(o.Title) + "/" + url.PathEscape(o.Subtitle)
+			inPath["title"], inPath["subtitle"] = true, true
+		case o.Title != "":
+			link += "/" + url.PathEscape(o.Title)
+			inPath["title"] = true
+		}
+		link += "/" + url.PathEscape(o.Body)
+		inPath["body"] = true
+	}
+
+	v := reflect.ValueOf(o).Elem()
+	for _, f := range optionFields() {
+		name := jsonName(f)
+		if inPath[name] || name == "device_key" || name == "device_keys" {
+			continue
+		}
+		switch value := v.FieldByIndex(f.Index).Interface().(type) {
+		case string:
+			if value != "" {
+				query.Set(name, value)
+			}
+		case *int:
+			if value != nil {
+				query.Set(name, strconv.Itoa(*value))
+			}
+		case []string:
+			if len(value) > 0 {
+				query.Set(name, strings.Join(value, ","))
+			}
+		}
+	}
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link, nil
+}
+
+// queryIV 返回需要随密文发送的 iv 参数: 使用固定 IV/Nonce 且未拼接在密文前时为 Iv, 否则为空
+func (e *EncOpt) queryIV() string {
+	if e.Encrypter != nil || e.PrefixIV || e.isECB() {
+		return ""
+	}
+	return e.Iv
+}
+
+// WithGET 以 GET 请求推送: GET /<device_key>/<title>/<body>?..., 加密推送为 GET /<device_key>?ciphertext=...&iv=...
+// 用于只允许 GET 请求通过的代理链; 每个设备单独发送一次请求, 链接格式见 Options.DeepLink.
+// 内容较长时注意服务器和代理对 URL 长度的限制
+func WithGET() ClientOption {
+	return func(c *Client) {
+		c.useGET = true
+	}
+}
+
+// sendGET 以 GET 请求向每个设备推送
+func (c *Client) sendGET(ctx context.Context, o *Options) error {
+	keys := o.routingKeys()
+	if len(keys) <= 1 {
+		return c.get(ctx, o)
+	}
+	var errs MultiError
+	for _, key := range keys {
+		single := *o
+		single.DeviceKey = key
+		single.DeviceKeys = nil
+		errs.Add("device "+Redact(key), c.get(ctx, &single))
+	}
+	return errs.Err()
+}
+
+func (c *Client) get(ctx context.Context, o *Options) error {
+	link, err := o.deepLink(c.ServerURL, c.codec)
+	if err != nil {
+		return err
+	}
+	_, err = c.withRetry(ctx, o, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+		if err != nil {
+			return nil, err
+		}
+		return c.do(ctx, req, o)
+	})
+	return err
+}
@@ -0,0 +1,124 @@
+package bark
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DiscordPayload Discord webhook 请求体
+type DiscordPayload struct {
+	Content   string         `json:"content"`
+	Username  string         `json:"username"`
+	AvatarURL string         `json:"avatar_url"`
+	Embeds    []DiscordEmbed `json:"embeds"`
+}
+
+// DiscordEmbed Discord 消息嵌入内容
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	URL         string              `json:"url"`
+	Fields      []DiscordEmbedField `json:"fields"`
+	Author      *struct {
+		Name    string `json:"name"`
+		URL     string `json:"url"`
+		IconURL string `json:"icon_url"`
+	} `json:"author"`
+	Footer *struct {
+		Text string `json:"text"`
+	} `json:"footer"`
+	Thumbnail *struct {
+		URL string `json:"url"`
+	} `json:"thumbnail"`
+}
+
+// DiscordEmbedField 嵌入内容的字段
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// FromDiscordPayload 将 Discord webhook 的 JSON 消息 (content, embeds) 转换为推送参数,
+// 转换规则见 DiscordPayload.Apply; 消息中没有文本时返回 ErrEmptyPayload
+func FromDiscordPayload(b []byte) (*Options, error) {
+	var p DiscordPayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("invalid discord payload: %w", err)
+	}
+	o := &Options{}
+	p.Apply(o)
+	if o.Title == "" && o.Markdown == "" {
+		return nil, ErrEmptyPayload
+	}
+	return o, nil
+}
+
+// Apply 将 Discord 消息写入推送参数
+//
+// Discord 消息本身是 Markdown, 因此内容写入 Markdown 字段: 第一个嵌入内容的标题作为标题,
+// content, 嵌入内容的描述, 字段和页脚合并为正文; 第一个嵌入链接 (或 content 中的第一个链接) 作为 url,
+// username 作为副标题, avatar_url 作为图标
+func (p *DiscordPayload) Apply(o *Options) {
+	var title, link string
+	var parts []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, FormatDiscord(s))
+		}
+	}
+
+	add(p.Content)
+	for _, e := range p.Embeds {
+		if e.Author != nil && e.Author.Name != "" {
+			add("*" + e.Author.Name + "*")
+		}
+		if e.Title != "" {
+			if title == "" {
+				title = FormatDiscord(e.Title)
+			} else {
+				add("**" + e.Title + "**")
+			}
+		}
+		if e.URL != "" && link == "" {
+			link = e.URL
+		}
+		add(e.Description)
+		for _, f := range e.Fields {
+			add(fmt.Sprintf("**%s**: %s", strings.TrimSpace(f.Name), strings.TrimSpace(f.Value)))
+		}
+		if e.Footer != nil {
+			add(e.Footer.Text)
+		}
+	}
+	if link == "" {
+		link = discordLinkPattern.FindString(p.Content)
+	}
+
+	if title != "" {
+		o.Title = title
+	}
+	o.Markdown = strings.Join(parts, "\n\n")
+	if link != "" {
+		o.URL = link
+	}
+	if p.Username != "" && o.Subtitle == "" {
+		o.Subtitle = p.Username
+	}
+	if p.AvatarURL != "" && o.Icon == "" {
+		o.Icon = p.AvatarURL
+	}
+}
+
+var (
+	discordEmojiPattern = regexp.MustCompile(`<a?:(\w+):\d+>`)
+	discordLinkPattern  = regexp.MustCompile(`https?://[^\s<>)\]]+`)
+)
+
+// FormatDiscord 将 Discord 扩展语法转换为标准 Markdown: 自定义 emoji <:name:id> 转为 :name:,
+// 并展开 :rocket: 形式的 emoji 短代码
+func FormatDiscord(s string) string {
+	return ExpandEmoji(discordEmojiPattern.ReplaceAllString(s, ":$1:"))
+}
@@ -0,0 +1,342 @@
+package bark
+
+import "strings"
+
+// Emoji 短代码 (不含冒号) 到 Unicode emoji 的映射, 覆盖 GitHub, Slack 常用的短代码, 可以注册自定义短代码
+var Emoji = map[string]string{
+	// 状态
+	"white_check_mark":            "✅",
+	"heavy_check_mark":            "✔️",
+	"ballot_box_with_check":       "☑️",
+	"x":                           "❌",
+	"negative_squared_cross_mark": "❎",
+	"heavy_multiplication_x":      "✖️",
+	"warning":                     "⚠️",
+	"no_entry":                    "⛔",
+	"no_entry_sign":               "\U0001f6ab",
+	"exclamation":                 "❗",
+	"heavy_exclamation_mark":      "❗",
+	"grey_exclamation":            "❕",
+	"question":                    "❓",
+	"grey_question":               "❔",
+	"bangbang":                    "‼️",
+	"interrobang":                 "⁉️",
+	"information_source":          "ℹ️",
+	"rotating_light":              "\U0001f6a8",
+	"sos":                         "\U0001f198",
+	"red_circle":                  "\U0001f534",
+	"orange_circle":               "\U0001f7e0",
+	"yellow_circle":               "\U0001f7e1",
+	"green_circle":                "\U0001f7e2",
+	"large_blue_circle":           "\U0001f535",
+	"blue_circle":                 "\U0001f535",
+	"purple_circle":               "\U0001f7e3",
+	"black_circle":                "⚫",
+	"white_circle":                "⚪",
+	"red_square":                  "\U0001f7e5",
+	"orange_square":               "\U0001f7e7",
+	"yellow_square":               "\U0001f7e8",
+	"green_square":                "\U0001f7e9",
+	"blue_square":                 "\U0001f7e6",
+	"new":                         "\U0001f195",
+	"ok":                          "\U0001f197",
+	"up":                          "\U0001f199",
+	"cool":                        "\U0001f192",
+	"free":                        "\U0001f193",
+	"100":                         "\U0001f4af",
+	"heavy_plus_sign":             "➕",
+	"heavy_minus_sign":            "➖",
+	"arrow_up":                    "⬆️",
+	"arrow_down":                  "⬇️",
+	"arrow_left":                  "⬅️",
+	"arrow_right":                 "➡️",
+	"arrows_counterclockwise":     "\U0001f504",
+	"repeat":                      "\U0001f501",
+	"leftwards_arrow_with_hook":   "↩️",
+	"recycle":                     "♻️",
+	"hourglass":                   "⌛",
+	"hourglass_flowing_sand":      "⏳",
+	"stopwatch":                   "⏱️",
+	"alarm_clock":                 "⏰",
+	"calendar":                    "\U0001f4c6",
+	"date":                        "\U0001f4c5",
+
+	// 开发与运维
+	"rocket":                     "\U0001f680",
+	"tada":                       "\U0001f389",
+	"confetti_ball":              "\U0001f38a",
+	"sparkles":                   "✨",
+	"fire":                       "\U0001f525",
+	"boom":                       "\U0001f4a5",
+	"collision":                  "\U0001f4a5",
+	"zap":                        "⚡",
+	"bug":                        "\U0001f41b",
+	"beetle":                     "\U0001fab2",
+	"ant":                        "\U0001f41c",
+	"lady_beetle":                "\U0001f41e",
+	"wrench":                     "\U0001f527",
+	"hammer":                     "\U0001f528",
+	"hammer_and_wrench":          "\U0001f6e0️",
+	"gear":                       "⚙️",
+	"nut_and_bolt":               "\U0001f529",
+	"construction":               "\U0001f6a7",
+	"building_construction":      "\U0001f3d7️",
+	"package":                    "\U0001f4e6",
+	"lock":                       "\U0001f512",
+	"unlock":                     "\U0001f513",
+	"closed_lock_with_key":       "\U0001f510",
+	"key":                        "\U0001f511",
+	"shield":                     "\U0001f6e1️",
+	"lipstick":                   "\U0001f484",
+	"art":                        "\U0001f3a8",
+	"memo":                       "\U0001f4dd",
+	"pencil":                     "\U0001f4dd",
+	"pencil2":                    "✏️",
+	"books":                      "\U0001f4da",
+	"book":                       "\U0001f4d6",
+	"bookmark":                   "\U0001f516",
+	"label":                      "\U0001f3f7️",
+	"pushpin":                    "\U0001f4cc",
+	"round_pushpin":              "\U0001f4cd",
+	"paperclip":                  "\U0001f4ce",
+	"link":                       "\U0001f517",
+	"mag":                        "\U0001f50d",
+	"mag_right":                  "\U0001f50e",
+	"bulb":                       "\U0001f4a1",
+	"chart_with_upwards_trend":   "\U0001f4c8",
+	"chart_with_downwards_trend": "\U0001f4c9",
+	"bar_chart":                  "\U0001f4ca",
+	"clipboard":                  "\U0001f4cb",
+	"card_index":                 "\U0001f4c7",
+	"file_folder":                "\U0001f4c1",
+	"open_file_folder":           "\U0001f4c2",
+	"page_facing_up":             "\U0001f4c4",
+	"scroll":                     "\U0001f4dc",
+	"wastebasket":                "\U0001f5d1️",
+	"computer":                   "\U0001f4bb",
+	"desktop_computer":           "\U0001f5a5️",
+	"keyboard":                   "⌨️",
+	"floppy_disk":                "\U0001f4be",
+	"cd":                         "\U0001f4bf",
+	"minidisc":                   "\U0001f4bd",
+	"iphone":                     "\U0001f4f1",
+	"electric_plug":              "\U0001f50c",
+	"battery":                    "\U0001f50b",
+	"satellite":                  "\U0001f4e1",
+	"globe_with_meridians":       "\U0001f310",
+	"earth_asia":                 "\U0001f30f",
+	"earth_americas":             "\U0001f30e",
+	"cloud":                      "☁️",
+	"whale":                      "\U0001f433",
+	"whale2":                     "\U0001f40b",
+	"penguin":                    "\U0001f427",
+	"snake":                      "\U0001f40d",
+	"robot":                      "\U0001f916",
+	"ghost":                      "\U0001f47b",
+	"skull":                      "\U0001f480",
+	"skull_and_crossbones":       "☠️",
+	"alien":                      "\U0001f47d",
+	"test_tube":                  "\U0001f9ea",
+	"microscope":                 "\U0001f52c",
+	"telescope":                  "\U0001f52d",
+	"alembic":                    "⚗️",
+	"triangular_flag_on_post":    "\U0001f6a9",
+	"checkered_flag":             "\U0001f3c1",
+	"white_flag":                 "\U0001f3f3️",
+	"loudspeaker":                "\U0001f4e2",
+	"mega":                       "\U0001f4e3",
+	"bell":                       "\U0001f514",
+	"no_bell":                    "\U0001f515",
+	"mailbox":                    "\U0001f4eb",
+	"email":                      "\U0001f4e7",
+	"envelope":                   "✉️",
+	"inbox_tray":                 "\U0001f4e5",
+	"outbox_tray":                "\U0001f4e4",
+	"speech_balloon":             "\U0001f4ac",
+	"thought_balloon":            "\U0001f4ad",
+	"truck":                      "\U0001f69a",
+	"ambulance":                  "\U0001f691",
+	"police_car":                 "\U0001f693",
+	"fire_engine":                "\U0001f692",
+	"traffic_light":              "\U0001f6a5",
+	"vertical_traffic_light":     "\U0001f6a6",
+	"fast_forward":               "⏩",
+	"rewind":                     "⏪",
+	"arrow_forward":              "▶️",
+	"pause_button":               "⏸️",
+	"stop_button":                "⏹️",
+	"twisted_rightwards_arrows":  "\U0001f500",
+	"money_with_wings":           "\U0001f4b8",
+	"moneybag":                   "\U0001f4b0",
+	"dollar":                     "\U0001f4b5",
+	"credit_card":                "\U0001f4b3",
+	"gift":                       "\U0001f381",
+	"trophy":                     "\U0001f3c6",
+	"medal":                      "\U0001f3c5",
+	"star":                       "⭐",
+	"star2":                      "\U0001f31f",
+	"dizzy":                      "\U0001f4ab",
+	"sunny":                      "☀️",
+	"umbrella":                   "☔",
+	"snowflake":                  "❄️",
+	"zzz":                        "\U0001f4a4",
+	"sweat_drops":                "\U0001f4a6",
+	"droplet":                    "\U0001f4a7",
+	"coffee":                     "☕",
+	"beer":                       "\U0001f37a",
+	"beers":                      "\U0001f37b",
+	"pizza":                      "\U0001f355",
+	"cake":                       "\U0001f370",
+	"birthday":                   "\U0001f382",
+	"house":                      "\U0001f3e0",
+	"office":                     "\U0001f3e2",
+	"hospital":                   "\U0001f3e5",
+	"moon":                       "\U0001f314",
+	"rainbow":                    "\U0001f308",
+	"seedling":                   "\U0001f331",
+	"herb":                       "\U0001f33f",
+	"four_leaf_clover":           "\U0001f340",
+	"cactus":                     "\U0001f335",
+	"tulip":                      "\U0001f337",
+
+	// 表情与手势
+	"smile":                  "\U0001f604",
+	"smiley":                 "\U0001f603",
+	"grin":                   "\U0001f601",
+	"grinning":               "\U0001f600",
+	"laughing":               "\U0001f606",
+	"joy":                    "\U0001f602",
+	"rofl":                   "\U0001f923",
+	"wink":                   "\U0001f609",
+	"blush":                  "\U0001f60a",
+	"innocent":               "\U0001f607",
+	"heart_eyes":             "\U0001f60d",
+	"sunglasses":             "\U0001f60e",
+	"smirk":                  "\U0001f60f",
+	"thinking":               "\U0001f914",
+	"neutral_face":           "\U0001f610",
+	"expressionless":         "\U0001f611",
+	"unamused":               "\U0001f612",
+	"roll_eyes":              "\U0001f644",
+	"sweat_smile":            "\U0001f605",
+	"sweat":                  "\U0001f613",
+	"pensive":                "\U0001f614",
+	"confused":               "\U0001f615",
+	"worried":                "\U0001f61f",
+	"cry":                    "\U0001f622",
+	"sob":                    "\U0001f62d",
+	"scream":                 "\U0001f631",
+	"fearful":                "\U0001f628",
+	"cold_sweat":             "\U0001f630",
+	"flushed":                "\U0001f633",
+	"dizzy_face":             "\U0001f635",
+	"exploding_head":         "\U0001f92f",
+	"angry":                  "\U0001f620",
+	"rage":                   "\U0001f621",
+	"triumph":                "\U0001f624",
+	"sleeping":               "\U0001f634",
+	"sleepy":                 "\U0001f62a",
+	"mask":                   "\U0001f637",
+	"face_with_thermometer":  "\U0001f912",
+	"nerd_face":              "\U0001f913",
+	"partying_face":          "\U0001f973",
+	"hugs":                   "\U0001f917",
+	"upside_down_face":       "\U0001f643",
+	"slightly_smiling_face":  "\U0001f642",
+	"slightly_frowning_face": "\U0001f641",
+	"facepalm":               "\U0001f926",
+	"shrug":                  "\U0001f937",
+	"eyes":                   "\U0001f440",
+	"+1":                     "\U0001f44d",
+	"thumbsup":               "\U0001f44d",
+	"-1":                     "\U0001f44e",
+	"thumbsdown":             "\U0001f44e",
+	"ok_hand":                "\U0001f44c",
+	"clap":                   "\U0001f44f",
+	"raised_hands":           "\U0001f64c",
+	"pray":                   "\U0001f64f",
+	"muscle":                 "\U0001f4aa",
+	"wave":                   "\U0001f44b",
+	"point_right":            "\U0001f449",
+	"point_left":             "\U0001f448",
+	"point_up":               "☝️",
+	"point_down":             "\U0001f447",
+	"v":                      "✌️",
+	"crossed_fingers":        "\U0001f91e",
+	"handshake":              "\U0001f91d",
+	"fist":                   "✊",
+	"punch":                  "\U0001f44a",
+	"raised_hand":            "✋",
+	"writing_hand":           "✍️",
+	"heart":                  "❤️",
+	"broken_heart":           "\U0001f494",
+	"green_heart":            "\U0001f49a",
+	"blue_heart":             "\U0001f499",
+	"yellow_heart":           "\U0001f49b",
+	"purple_heart":           "\U0001f49c",
+	"black_heart":            "\U0001f5a4",
+	"poop":                   "\U0001f4a9",
+	"hankey":                 "\U0001f4a9",
+}
+
+// ExpandEmoji 将 s 中 :rocket: 形式的短代码替换为 Unicode emoji, 未知的短代码保持原样
+func ExpandEmoji(s string) string {
+	if !strings.Contains(s, ":") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, ':')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], ':')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		name := s[start+1 : end]
+		if emoji, ok := Emoji[name]; ok && isShortcode(name) {
+			b.WriteString(s[:start])
+			b.WriteString(emoji)
+			s = s[end+1:]
+			continue
+		}
+		// 结尾的冒号可能是下一个短代码的开头
+		b.WriteString(s[:end])
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+func isShortcode(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '+', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandEmoji 展开 Title, Subtitle, Body 和 Markdown 中的 emoji 短代码; 返回 o 以便链式调用
+func (o *Options) ExpandEmoji() *Options {
+	o.Title = ExpandEmoji(o.Title)
+	o.Subtitle = ExpandEmoji(o.Subtitle)
+	o.Body = ExpandEmoji(o.Body)
+	o.Markdown = ExpandEmoji(o.Markdown)
+	return o
+}
+
+// WithEmojiShortcodes 推送前自动展开标题, 副标题, 正文和 Markdown 中的 emoji 短代码 (如 :rocket:)
+// 不修改调用方传入的 Options
+func WithEmojiShortcodes() ClientOption {
+	return func(c *Client) {
+		c.emoji = true
+	}
+}
@@ -0,0 +1,113 @@
+package bark
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 环境变量前缀
+const EnvPrefix = "BARK_"
+
+// FromEnv 根据环境变量构造客户端和默认推送参数:
+//
+//	BARK_SERVER_URL                服务器地址, 默认 DefaultURL
+//	BARK_TIMEOUT                   请求超时, 如 5s 或秒数 5
+//	BARK_DEVICE_KEY(S)             设备 Key, BARK_DEVICE_KEYS 以逗号分隔
+//	BARK_ENC_MODE                  加密模式 (CBC, ECB, GCM), 设置后启用客户端默认加密
+//	BARK_ENC_KEY / _KEY_HEX / _KEY_BASE64 / _KEY_FILE / _PASSPHRASE / _SALT / _KDF
+//	BARK_ENC_IV / _ENCODING
+//	BARK_ENC_ALLOW_INSECURE_ECB    为 true 时允许 ECB 模式
+//	BARK_<参数名>                  其余推送参数, 如 BARK_GROUP, BARK_SOUND, BARK_AUTO_COPY
+//
+// 返回的 Options 作为模板使用, 推送前通过 Clone 复制并填写内容
+func FromEnv() (*Client, *Options, error) {
+	var opts []ClientOption
+
+	enc, err := encFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if enc != nil {
+		opts = append(opts, WithEncryption(enc))
+	}
+
+	client := New(os.Getenv(EnvPrefix+"SERVER_URL"), opts...)
+
+	if v := os.Getenv(EnvPrefix + "TIMEOUT"); v != "" {
+		timeout, err := parseTimeout(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%sTIMEOUT: %w", EnvPrefix, err)
+		}
+		client.HTTPClient.Timeout = timeout
+	}
+
+	defaults := &Options{}
+	for _, name := range OptionNames() {
+		key := EnvPrefix + envName(name)
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			if err := defaults.Set(name, v); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	return client, defaults, nil
+}
+
+func encFromEnv() (*EncOpt, error) {
+	get := func(name string) string {
+		return os.Getenv(EnvPrefix + "ENC_" + name)
+	}
+
+	mode := get("MODE")
+	if mode == "" {
+		return nil, nil
+	}
+
+	enc := &EncOpt{
+		Mode:       EncMode(strings.ToUpper(mode)),
+		Key:        get("KEY"),
+		KeyHex:     get("KEY_HEX"),
+		KeyBase64:  get("KEY_BASE64"),
+		KeyFile:    get("KEY_FILE"),
+		Passphrase: get("PASSPHRASE"),
+		Salt:       get("SALT"),
+		KDF:        KDF(get("KDF")),
+		Iv:         get("IV"),
+		Encoding:   CipherEncoding(get("ENCODING")),
+	}
+	if v := get("ALLOW_INSECURE_ECB"); v != "" {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%sENC_ALLOW_INSECURE_ECB: %w", EnvPrefix, err)
+		}
+		enc.AllowInsecureECB = allow
+	}
+	if err := enc.validate(); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// parseTimeout 解析超时时间, 支持 Go duration 格式或整数秒
+func parseTimeout(v string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// envName 将参数名转换为环境变量名, 如 autoCopy -> AUTO_COPY, device_keys -> DEVICE_KEYS
+func envName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
@@ -0,0 +1,140 @@
+package bark
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrDeviceKeyNotFound 设备 Key 未在服务器注册 (或 App 已卸载), 不应再向该设备推送
+// 服务器的失败响应通过 ResponseError.Is 识别, 可以用 errors.Is 判断, 包括批量推送返回的 MultiError
+var ErrDeviceKeyNotFound = errors.New("bark: device key not found")
+
+// maxErrorSnippet ResponseError.Error 中显示的响应体最大长度, 完整内容见 ResponseError.Body
+const maxErrorSnippet = 256
+
+// ResponseError 服务器返回的失败响应, 包括 Bark 错误码和无法解析的响应 (如反向代理的 HTML 错误页)
+// 可通过 errors.As 获取完整的状态码, 响应头和响应体, 便于记录和排查;
+// 客户端返回的 ResponseError 中, Message, Header 和 Body 里本次推送的设备 Key, 密钥和口令已脱敏
+type ResponseError struct {
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Code Bark 响应中的 code, 响应不是 Bark 的 JSON 格式时为 0
+	Code int
+	// Message Bark 响应中的 message
+	Message string
+	// Header 响应头
+	Header http.Header
+	// Body 完整的响应体
+	Body []byte
+}
+
+// newResponseError 由失败响应构造 ResponseError, 隐藏服务器回显的推送 o 的敏感值
+func newResponseError(resp *http.Response, body []byte, code int, message string, o *Options) *ResponseError {
+	secrets := o.secrets()
+	header := resp.Header.Clone()
+	for k, values := range header {
+		for i, v := range values {
+			header[k][i] = redactSecrets(v, secrets)
+		}
+	}
+	return &ResponseError{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		Message:    redactSecrets(message, secrets),
+		Header:     header,
+		Body:       []byte(redactSecrets(string(body), secrets)),
+	}
+}
+
+func (e *ResponseError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("bark error (%d): %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, snippet(e.Body))
+}
+
+// Is 识别 bark-server 对未注册设备 Key 的响应 ("failed to get device token ...") 和内嵌服务端的 "device not registered"
+func (e *ResponseError) Is(target error) bool {
+	if target != ErrDeviceKeyNotFound || (e.StatusCode != http.StatusBadRequest && e.Code != http.StatusBadRequest) {
+		return false
+	}
+	return strings.Contains(e.Message, "device token") || strings.Contains(e.Message, "device not registered")
+}
+
+// snippet 截断过长的响应体, 不切断 UTF-8 字符
+func snippet(body []byte) string {
+	if len(body) <= maxErrorSnippet {
+		return string(body)
+	}
+	n := maxErrorSnippet
+	for n > 0 && !utf8.RuneStart(body[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:n], len(body))
+}
+
+// ItemError 批量操作中一项的失败
+type ItemError struct {
+	// Item 失败项, 如 "device abc***xyz" (脱敏后的设备 Key), 收件人名称; 多个设备合并为一次请求时为空
+	Item string
+	Err  error
+}
+
+func (e *ItemError) Error() string {
+	if e.Item == "" {
+		return e.Err.Error()
+	}
+	return e.Item + ": " + e.Err.Error()
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError 批量操作 (多设备推送, 分组推送, 告警桥接等) 的全部失败, 实现 Unwrap() []error,
+// 任一项满足 errors.Is / errors.As 时整体即满足, 如 errors.Is(err, bark.ErrDeviceKeyNotFound)
+//
+//	var multi *bark.MultiError
+//	if errors.As(err, &multi) {
+//		for _, e := range multi.Errors {
+//			log.Println(e.Item, e.Err)
+//		}
+//	}
+type MultiError struct {
+	Errors []*ItemError
+}
+
+// Add 记录一项失败, err 为 nil 时忽略
+func (e *MultiError) Add(item string, err error) {
+	if err != nil {
+		e.Errors = append(e.Errors, &ItemError{Item: item, Err: err})
+	}
+}
+
+// Err 没有失败时返回 nil, 否则返回 e
+func (e *MultiError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error 每项失败一行, 与 errors.Join 的格式一致
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
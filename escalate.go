@@ -0,0 +1,125 @@
+package bark
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEscalationInterval 第一次重发前的默认等待时间
+	DefaultEscalationInterval = time.Minute
+	// DefaultEscalationMaxInterval 重发间隔的默认上限
+	DefaultEscalationMaxInterval = 15 * time.Minute
+)
+
+// EscalationPolicy 升级重发的设置, 零值表示每 1, 2, 4 ... 分钟 (最长 15 分钟) 重发一次, 直到取消
+type EscalationPolicy struct {
+	// Interval 第一次重发前的等待时间, 默认 DefaultEscalationInterval
+	Interval time.Duration
+	// Factor 每次重发后间隔乘以 Factor, 默认 2, 为 1 时按固定间隔重发
+	Factor float64
+	// MaxInterval 重发间隔的上限, 默认 DefaultEscalationMaxInterval
+	MaxInterval time.Duration
+	// MaxRepeats 最多重发的次数, 0 表示不限, 直到 Cancel 或 ctx 结束
+	MaxRepeats int
+	// CallAfter 从第 CallAfter 次重发起设置 call=1 (持续响铃), 0 表示不升级
+	CallAfter int
+}
+
+// Escalation 进行中的升级重发, 由 Client.Escalate 返回, 可并发使用
+type Escalation struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	repeats int
+	err     error
+}
+
+// Escalate 推送 o, 之后按 policy 以递增的间隔重发, 直到调用 Cancel (如用户确认收到告警), ctx 结束或达到 MaxRepeats,
+// 在 Bark "发出即结束" 的模型上模拟需要确认的值班呼叫. 首次推送失败时直接返回错误;
+// 重发失败不会中止升级, 最后一次错误见 Escalation.Err
+//
+//	esc, err := client.Escalate(ctx, bark.Critical("数据库宕机", "主库无响应", 8), bark.EscalationPolicy{CallAfter: 2})
+//	...
+//	esc.Cancel() // 值班人员已确认
+func (c *Client) Escalate(ctx context.Context, o *Options, policy EscalationPolicy) (*Escalation, error) {
+	o = o.Clone()
+	if err := c.Push(ctx, o); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	e := &Escalation{cancel: cancel, done: make(chan struct{})}
+	go e.run(ctx, c, o, policy)
+	return e, nil
+}
+
+func (e *Escalation) run(ctx context.Context, c *Client, o *Options, policy EscalationPolicy) {
+	defer close(e.done)
+	defer e.cancel()
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = DefaultEscalationInterval
+	}
+	maxInterval := policy.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultEscalationMaxInterval
+	}
+	factor := policy.Factor
+	if factor < 1 {
+		factor = 2
+	}
+
+	for n := 1; policy.MaxRepeats <= 0 || n <= policy.MaxRepeats; n++ {
+		timer := c.clock().NewTimer(interval)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		repeat := o
+		if policy.CallAfter > 0 && n >= policy.CallAfter && o.Call != "1" {
+			repeat = o.Clone()
+			repeat.Call = "1"
+		}
+		err := c.Push(ctx, repeat)
+		if ctx.Err() != nil {
+			return
+		}
+		e.mu.Lock()
+		e.repeats = n
+		e.err = err
+		e.mu.Unlock()
+
+		if interval = time.Duration(float64(interval) * factor); interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// Cancel 停止重发, 可以多次调用; 正在进行的重发会被中止
+func (e *Escalation) Cancel() {
+	e.cancel()
+}
+
+// Done 升级结束 (取消, ctx 结束或达到 MaxRepeats) 时关闭
+func (e *Escalation) Done() <-chan struct{} {
+	return e.done
+}
+
+// Repeats 返回已重发的次数
+func (e *Escalation) Repeats() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.repeats
+}
+
+// Err 返回最近一次重发的错误, 成功时为 nil
+func (e *Escalation) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
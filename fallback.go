@@ -0,0 +1,58 @@
+package bark
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// Fallback 返回依次尝试各 Pusher 的 Pusher: primary 失败 (包括其自身的重试) 后交给 secondary, 以此类推,
+// 任一成功即返回 nil; 全部失败时返回汇总各次失败的 *MultiError. ctx 结束后不再尝试后续的 Pusher
+//
+//	p := bark.Fallback(primary, bark.New("https://backup.example.com"), smsPusher)
+func Fallback(primary, secondary Pusher, more ...Pusher) Pusher {
+	pushers := append([]Pusher{primary, secondary}, more...)
+	return PusherFunc(func(ctx context.Context, o *Options) error {
+		var errs MultiError
+		for i, p := range pushers {
+			err := p.Push(ctx, o)
+			if err == nil {
+				return nil
+			}
+			errs.Add(pusherName(i), err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return errs.Err()
+	})
+}
+
+// Fanout 返回同时推送到全部 Pusher 的 Pusher, 等待全部完成, 任一失败时返回汇总失败的 *MultiError
+// 每个 Pusher 收到的是推送参数的副本
+func Fanout(pushers ...Pusher) Pusher {
+	pushers = append([]Pusher(nil), pushers...)
+	return PusherFunc(func(ctx context.Context, o *Options) error {
+		results := make([]error, len(pushers))
+		var wg sync.WaitGroup
+		for i, p := range pushers {
+			wg.Add(1)
+			go func(i int, p Pusher) {
+				defer wg.Done()
+				results[i] = p.Push(ctx, o.Clone())
+			}(i, p)
+		}
+		wg.Wait()
+
+		var errs MultiError
+		for i, err := range results {
+			errs.Add(pusherName(i), err)
+		}
+		return errs.Err()
+	})
+}
+
+// pusherName 返回 MultiError 中第 i 个 Pusher 的名称
+func pusherName(i int) string {
+	return "pusher " + strconv.Itoa(i+1)
+}
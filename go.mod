@@ -1,3 +1,16 @@
 module github.com/gaoyaxuan/go-bark
 
-go 1.20
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/zalando/go-keyring v0.2.8
+	golang.org/x/crypto v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/danieljoos/wincred v1.2.3 // indirect
+	github.com/godbus/dbus/v5 v5.2.2 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
@@ -0,0 +1,364 @@
+// Package heartbeat 实现 dead man's switch: 定时任务定期上报心跳, 超过配置的间隔未收到心跳时推送告警
+//
+//	mon := heartbeat.NewMonitor(client, &bark.Options{DeviceKey: "YOUR_DEVICE_KEY"})
+//	mon.Register("nightly-backup", 24*time.Hour, heartbeat.Grace(time.Hour))
+//	go mon.Run(ctx)
+//	http.Handle("/heartbeat/", http.StripPrefix("/heartbeat", mon))
+//
+// 定时任务通过 mon.Beat, heartbeat.NewClient(url).Beat 或 curl -X POST .../heartbeat/nightly-backup 上报
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/bridge"
+)
+
+// ErrUnknownHeartbeat 心跳名称未注册
+var ErrUnknownHeartbeat = errors.New("heartbeat: unknown heartbeat")
+
+// Beater 上报心跳, Monitor 和 Client 均实现该接口
+type Beater interface {
+	Beat(ctx context.Context, name string) error
+}
+
+// Status 心跳状态
+type Status struct {
+	Name     string        `json:"name"`
+	Interval time.Duration `json:"interval"`
+	Grace    time.Duration `json:"grace"`
+	// LastBeat 最近一次心跳时间, 从未上报时为零值 (此时从注册时开始计时)
+	LastBeat time.Time `json:"last_beat"`
+	// Down 是否已超时并推送了告警
+	Down bool `json:"down"`
+}
+
+// Deadline 下一次心跳的截止时间
+func (s Status) Deadline() time.Time {
+	return s.LastBeat.Add(s.Interval + s.Grace)
+}
+
+// RegisterOption 心跳配置项
+type RegisterOption func(*entry)
+
+// Grace 设置超时前额外的宽限时间, 用于运行时长不固定的任务
+func Grace(d time.Duration) RegisterOption {
+	return func(e *entry) {
+		e.Grace = d
+	}
+}
+
+// WithOptions 设置该心跳告警的推送参数, 覆盖 Monitor 的默认参数
+func WithOptions(o *bark.Options) RegisterOption {
+	return func(e *entry) {
+		e.options = o
+	}
+}
+
+type entry struct {
+	Status
+	options *bark.Options
+	// registered 从状态文件恢复的心跳在重新 Register 之前不参与检查
+	registered bool
+}
+
+// Monitor 心跳监控
+//
+// 心跳超时时推送一次告警 ("<name> did not run"), 之后恢复上报时推送恢复通知;
+// 设置 StateFile 后最近心跳时间持久化到文件, 监控进程重启不会丢失状态
+type Monitor struct {
+	Pusher bark.Pusher
+	// Defaults 告警的默认推送参数, 如设备 Key
+	Defaults *bark.Options
+	// StateFile 可选, 状态文件
+	StateFile string
+	// CheckInterval 检查间隔, 默认 1 分钟
+	CheckInterval time.Duration
+	// Token 非空时 HTTP 上报要求携带 Authorization: Bearer <Token> 或 ?token=<Token>
+	Token string
+	// Logger 记录推送和状态文件错误, 默认 slog.Default()
+	Logger *slog.Logger
+	// Clock 时间来源, 默认 bark.SystemClock, 测试时可使用 barktest.Clock
+	Clock bark.Clock
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	loaded  bool
+}
+
+// NewMonitor 创建 Monitor
+func NewMonitor(p bark.Pusher, defaults *bark.Options) *Monitor {
+	return &Monitor{Pusher: p, Defaults: defaults}
+}
+
+func (m *Monitor) clock() bark.Clock {
+	if m.Clock != nil {
+		return m.Clock
+	}
+	return bark.SystemClock
+}
+
+func (m *Monitor) logger() *slog.Logger {
+	if m.Logger != nil {
+		return m.Logger
+	}
+	return slog.Default()
+}
+
+// Register 注册心跳, interval 为预期的上报间隔; 重复注册会更新配置并保留最近心跳时间
+func (m *Monitor) Register(name string, interval time.Duration, opts ...RegisterOption) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadLocked()
+
+	e, ok := m.entries[name]
+	if !ok {
+		e = &entry{Status: Status{Name: name, LastBeat: m.clock().Now()}}
+		m.entries[name] = e
+	}
+	e.Interval = interval
+	e.registered = true
+	for _, opt := range opts {
+		opt(e)
+	}
+}
+
+// Beat 记录心跳, 心跳处于超时状态时推送恢复通知
+func (m *Monitor) Beat(ctx context.Context, name string) error {
+	m.mu.Lock()
+	m.loadLocked()
+	e, ok := m.entries[name]
+	if !ok || !e.registered {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownHeartbeat, name)
+	}
+	wasDown := e.Down
+	now := m.clock().Now()
+	e.LastBeat = now
+	e.Down = false
+	m.saveLocked()
+	o := m.options(e)
+	m.mu.Unlock()
+
+	if !wasDown {
+		return nil
+	}
+	o.Title = fmt.Sprintf("%s is back", name)
+	o.Body = fmt.Sprintf("heartbeat received again at %s", now.Format(time.RFC3339))
+	o.Level = "active"
+	return m.Pusher.Push(ctx, o)
+}
+
+// Statuses 返回全部心跳的状态, 按名称排序
+func (m *Monitor) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadLocked()
+	list := make([]Status, 0, len(m.entries))
+	for _, e := range m.entries {
+		if e.registered {
+			list = append(list, e.Status)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Run 定期检查心跳, 直到 ctx 结束
+func (m *Monitor) Run(ctx context.Context) error {
+	interval := m.CheckInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	t := m.clock().NewTimer(interval)
+	defer t.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C():
+			t.Reset(interval)
+		}
+	}
+}
+
+// Check 检查一次全部心跳, 为新超时的心跳推送告警
+func (m *Monitor) Check(ctx context.Context) {
+	now := m.clock().Now()
+	var alerts []*bark.Options
+
+	m.mu.Lock()
+	m.loadLocked()
+	for _, e := range m.entries {
+		if !e.registered || e.Down || e.Interval <= 0 || now.Before(e.Deadline()) {
+			continue
+		}
+		e.Down = true
+		o := m.options(e)
+		o.Title = fmt.Sprintf("%s did not run", e.Name)
+		o.Body = fmt.Sprintf("no heartbeat since %s (expected every %s)", e.LastBeat.Format(time.RFC3339), e.Interval)
+		if o.Level == "" {
+			o.Level = "timeSensitive"
+		}
+		alerts = append(alerts, o)
+	}
+	if len(alerts) > 0 {
+		m.saveLocked()
+	}
+	m.mu.Unlock()
+
+	for _, o := range alerts {
+		if err := m.Pusher.Push(ctx, o); err != nil {
+			m.logger().Error("bark heartbeat: push failed", "title", o.Title, "err", err)
+		}
+	}
+}
+
+// options 返回心跳告警的推送参数, 调用方需持有锁
+func (m *Monitor) options(e *entry) *bark.Options {
+	src := e.options
+	if src == nil {
+		src = m.Defaults
+	}
+	o := &bark.Options{}
+	if src != nil {
+		o = src.Clone()
+	}
+	if o.Group == "" {
+		o.Group = "heartbeat"
+	}
+	return o
+}
+
+// loadLocked 首次使用时从状态文件恢复, 调用方需持有锁
+func (m *Monitor) loadLocked() {
+	if m.loaded {
+		return
+	}
+	m.loaded = true
+	m.entries = make(map[string]*entry)
+	if m.StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(m.StateFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			m.logger().Warn("bark heartbeat: read state failed", "file", m.StateFile, "err", err)
+		}
+		return
+	}
+	var list []Status
+	if err := json.Unmarshal(data, &list); err != nil {
+		m.logger().Warn("bark heartbeat: invalid state file", "file", m.StateFile, "err", err)
+		return
+	}
+	for _, s := range list {
+		m.entries[s.Name] = &entry{Status: s}
+	}
+}
+
+// saveLocked 写入状态文件, 调用方需持有锁
+func (m *Monitor) saveLocked() {
+	if m.StateFile == "" {
+		return
+	}
+	list := make([]Status, 0, len(m.entries))
+	for _, e := range m.entries {
+		list = append(list, e.Status)
+	}
+	data, err := json.Marshal(list)
+	if err == nil {
+		tmp := m.StateFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, m.StateFile)
+		}
+	}
+	if err != nil {
+		m.logger().Warn("bark heartbeat: save state failed", "file", m.StateFile, "err", err)
+	}
+}
+
+// ServeHTTP POST /<name> 上报心跳, GET / 返回全部心跳状态
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !bridge.CheckToken(r, m.Token) {
+		bridge.Respond(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	name := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(m.Statuses())
+	case (r.Method == http.MethodPost || r.Method == http.MethodPut) && name != "":
+		err := m.Beat(r.Context(), name)
+		switch {
+		case errors.Is(err, ErrUnknownHeartbeat):
+			bridge.Respond(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			// 心跳已记录, 只是恢复通知推送失败
+			bridge.Respond(w, http.StatusOK, "recorded, notification failed: "+err.Error())
+		default:
+			bridge.Respond(w, http.StatusOK, "success")
+		}
+	default:
+		bridge.Respond(w, http.StatusMethodNotAllowed, bridge.ErrMethodNotAllowed.Error())
+	}
+}
+
+// Client 通过 HTTP 向远程 Monitor 上报心跳
+type Client struct {
+	// URL Monitor 的地址前缀, 如 http://monitor:8080/heartbeat
+	URL string
+	// Token 可选, 以 Authorization: Bearer 发送
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient 创建 Client
+func NewClient(url string) *Client {
+	return &Client{URL: url}
+}
+
+// Beat 上报心跳
+func (c *Client) Beat(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.URL, "/")+"/"+name, nil)
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrUnknownHeartbeat, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("heartbeat: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+var (
+	_ Beater = (*Monitor)(nil)
+	_ Beater = (*Client)(nil)
+)
@@ -0,0 +1,178 @@
+package bark
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHistorySize 推送历史默认保留的条数
+const DefaultHistorySize = 1000
+
+// historySummaryLen 推送历史中正文摘要的最大字符数
+const historySummaryLen = 100
+
+// HistoryEntry 一次推送的记录, 设备 Key 已脱敏, 不包含加密参数
+type HistoryEntry struct {
+	// Time 开始推送的时间
+	Time time.Time `json:"time"`
+	// Duration 推送耗时
+	Duration time.Duration `json:"duration"`
+	// DeviceKeys 目标设备 (脱敏后)
+	DeviceKeys []string `json:"device_keys,omitempty"`
+	// Recipients 按别名指定的收件人
+	Recipients []string `json:"recipients,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	// Summary 正文 (或 Markdown) 的摘要
+	Summary   string `json:"summary,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+	// Error 失败时的错误信息, 成功时为空
+	Error string `json:"error,omitempty"`
+}
+
+// OK 推送是否成功
+func (e HistoryEntry) OK() bool {
+	return e.Error == ""
+}
+
+func newHistoryEntry(o *Options, start, end time.Time, err error) HistoryEntry {
+	e := HistoryEntry{
+		Time:      start,
+		Duration:  end.Sub(start),
+		Title:     o.Title,
+		Summary:   summarize(o.Body, historySummaryLen),
+		Group:     o.Group,
+		Level:     o.Level,
+		Encrypted: o.Enc != nil || len(o.DeviceEnc) > 0,
+	}
+	if e.Summary == "" {
+		e.Summary = summarize(o.Markdown, historySummaryLen)
+	}
+	for _, key := range o.routingKeys() {
+		e.DeviceKeys = append(e.DeviceKeys, Redact(key))
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// summarize 截取前 n 个字符
+func summarize(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for j := range s {
+		if i == n {
+			return s[:j] + "…"
+		}
+		i++
+	}
+	return s
+}
+
+// History 内存中的推送历史, 以环形缓冲区保留最近的记录, 可并发使用
+// 用于回答 "凌晨 3 点的告警到底发出去没有" 这类问题, 进程重启后丢失; 需要持久化时使用审计日志
+type History struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+// NewHistory 创建保留最近 size 条记录的推送历史, size 小于等于 0 时使用 DefaultHistorySize
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{entries: make([]HistoryEntry, size)}
+}
+
+// WithHistory 记录该客户端的每次推送 (包括校验失败的推送), 通过 Client.History 查询
+// 多个客户端可以共享同一个 History
+func WithHistory(h *History) ClientOption {
+	return func(c *Client) {
+		c.history = h
+	}
+}
+
+// History 返回客户端的推送历史, 未设置 WithHistory 时返回 nil
+func (c *Client) History() *History {
+	return c.history
+}
+
+func (h *History) add(e HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[h.next] = e
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Entries 按时间顺序返回全部记录
+func (h *History) Entries() []HistoryEntry {
+	return h.Query(HistoryQuery{})
+}
+
+// Len 返回记录条数
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.full {
+		return len(h.entries)
+	}
+	return h.next
+}
+
+// HistoryQuery 推送历史的查询条件, 零值字段不作限制
+type HistoryQuery struct {
+	// Since, Until 推送开始时间的范围 [Since, Until)
+	Since time.Time
+	Until time.Time
+	// DeviceKey 目标设备, 按脱敏后的值匹配
+	DeviceKey string
+	// Group 分组
+	Group string
+	// FailedOnly 只返回失败的推送
+	FailedOnly bool
+}
+
+// Query 按时间顺序返回符合条件的记录
+func (h *History) Query(q HistoryQuery) []HistoryEntry {
+	h.mu.Lock()
+	var all []HistoryEntry
+	if h.full {
+		all = append(all, h.entries[h.next:]...)
+	}
+	all = append(all, h.entries[:h.next]...)
+	h.mu.Unlock()
+
+	key := Redact(q.DeviceKey)
+	out := all[:0]
+	for _, e := range all {
+		switch {
+		case !q.Since.IsZero() && e.Time.Before(q.Since):
+		case !q.Until.IsZero() && !e.Time.Before(q.Until):
+		case q.Group != "" && e.Group != q.Group:
+		case q.FailedOnly && e.OK():
+		case key != "" && !containsString(e.DeviceKeys, key):
+		default:
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package bark
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// journalCompactRecords 全部条目确认后, 文件记录数超过该值时清空文件
+const journalCompactRecords = 1024
+
+// Journal 队列的持久化日志, 以 JSON Lines 格式追加记录入队和确认, 进程崩溃后由 NewQueue 重新投递未确认的推送
+//
+// 不持久化 Enc, DeviceEnc 等密钥 (重放时使用客户端 WithEncryption 的默认加密); 每条记录写入后不调用 fsync,
+// 能保证进程崩溃不丢失, 操作系统崩溃时可能丢失最近的记录
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	seq     uint64
+	records int
+	pending map[uint64]journalEntry
+}
+
+type journalEntry struct {
+	seq      uint64
+	o        *Options
+	priority Priority
+}
+
+// journalRecord 日志中的一行, Op 为 add 或 ack
+type journalRecord struct {
+	Op      string          `json:"op"`
+	Seq     uint64          `json:"seq"`
+	Options *journalOptions `json:"options,omitempty"`
+	// Priority 入队时的优先级, 旧版本写入的记录没有该字段, 重放时按 PriorityOf 计算
+	Priority *Priority `json:"priority,omitempty"`
+}
+
+// journalOptions 持久化的推送参数, 包括 Options 中不参与序列化的非密钥字段
+type journalOptions struct {
+	*Options
+	Recipients        []string `json:"recipients,omitempty"`
+	DisableEnc        bool     `json:"disable_enc,omitempty"`
+	Archive           *bool    `json:"archive,omitempty"`
+	AutoCopyEnabled   *bool    `json:"auto_copy_enabled,omitempty"`
+	AllowCustomScheme bool     `json:"allow_custom_scheme,omitempty"`
+}
+
+func addRecord(e journalEntry) journalRecord {
+	o := e.o
+	return journalRecord{Op: "add", Seq: e.seq, Priority: &e.priority, Options: &journalOptions{
+		Options:           o,
+		Recipients:        o.Recipients,
+		DisableEnc:        o.DisableEnc,
+		Archive:           o.Archive,
+		AutoCopyEnabled:   o.AutoCopyEnabled,
+		AllowCustomScheme: o.AllowCustomScheme,
+	}}
+}
+
+func (j *journalOptions) options() *Options {
+	o := j.Options
+	if o == nil {
+		o = &Options{}
+	}
+	o.Recipients = j.Recipients
+	o.DisableEnc = j.DisableEnc
+	o.Archive = j.Archive
+	o.AutoCopyEnabled = j.AutoCopyEnabled
+	o.AllowCustomScheme = j.AllowCustomScheme
+	return o
+}
+
+// OpenJournal 打开 (不存在时创建, 权限 0600) 队列日志, 读取未确认的推送并压缩文件
+// 无法解析的行 (如崩溃时写了一半的最后一行) 会被忽略
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{path: path, pending: make(map[uint64]journalEntry)}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var r journalRecord
+		if json.Unmarshal(sc.Bytes(), &r) != nil {
+			continue
+		}
+		if r.Seq > j.seq {
+			j.seq = r.Seq
+		}
+		switch {
+		case r.Op == "add" && r.Options != nil:
+			e := journalEntry{seq: r.Seq, o: r.Options.options()}
+			if r.Priority != nil {
+				e.priority = *r.Priority
+			} else {
+				e.priority = PriorityOf(e.o)
+			}
+			j.pending[r.Seq] = e
+		case r.Op == "ack":
+			delete(j.pending, r.Seq)
+		}
+	}
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Pending 按入队顺序返回未确认的推送
+// 设置了 ID 的推送按 ID 去重, 只保留最后一条 (Bark 中相同 ID 的通知会被替换)
+func (j *Journal) Pending() []*Options {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var out []*Options
+	for _, e := range j.dedup() {
+		out = append(out, e.o.Clone())
+	}
+	return out
+}
+
+// dedup 按入队顺序返回去重后的未确认条目
+func (j *Journal) dedup() []journalEntry {
+	entries := make([]journalEntry, 0, len(j.pending))
+	for _, e := range j.pending {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].seq < entries[b].seq })
+
+	latest := make(map[string]uint64)
+	for _, e := range entries {
+		if e.o.ID != "" {
+			latest[e.o.ID] = e.seq
+		}
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if e.o.ID == "" || latest[e.o.ID] == e.seq {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// add 记录入队的推送, 返回用于确认的序号
+func (j *Journal) add(o *Options, p Priority) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	e := journalEntry{seq: j.seq, o: o, priority: p}
+	if err := j.write(addRecord(e)); err != nil {
+		return 0, err
+	}
+	j.pending[j.seq] = e
+	return j.seq, nil
+}
+
+// ack 确认推送已处理, 不再重放
+func (j *Journal) ack(seq uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.pending[seq]; !ok {
+		return nil
+	}
+	if err := j.write(journalRecord{Op: "ack", Seq: seq}); err != nil {
+		return err
+	}
+	delete(j.pending, seq)
+	if len(j.pending) == 0 && j.records > journalCompactRecords {
+		return j.compact()
+	}
+	return nil
+}
+
+func (j *Journal) write(rec journalRecord) error {
+	if j.f == nil {
+		return ErrQueueClosed
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, rec); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	if _, err := j.f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	j.records++
+	return nil
+}
+
+// compact 只保留未确认 (去重后) 的条目重写文件, 写入临时文件后重命名
+// 已被相同 ID 的新推送替换的条目在这里丢弃
+func (j *Journal) compact() error {
+	entries := j.dedup()
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if err := encodeJSON(&buf, addRecord(e)); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if j.f != nil {
+		j.f.Close()
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	j.f = f
+	j.records = len(entries)
+	j.pending = make(map[uint64]journalEntry, len(entries))
+	for _, e := range entries {
+		j.pending[e.seq] = e
+	}
+	return nil
+}
+
+// Close 关闭日志文件, 未确认的推送保留在文件中, 下次打开时重放
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
@@ -0,0 +1,175 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// collector 记录推送正文的 Pusher
+type collector struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (c *collector) Push(_ context.Context, o *Options) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bodies = append(c.bodies, o.Body)
+	return nil
+}
+
+func (c *collector) got() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.bodies...)
+}
+
+func writeJournal(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenJournalReplay(t *testing.T) {
+	path := writeJournal(t,
+		// 旧版本的记录没有 priority, 按 Level 计算
+		`{"op":"add","seq":1,"options":{"device_key":"k","body":"a","level":"passive"}}`,
+		`{"op":"add","seq":2,"options":{"device_key":"k","body":"b"},"priority":3}`,
+		`{"op":"add","seq":3,"options":{"device_key":"k","body":"c"},"priority":1}`,
+		`{"op":"ack","seq":3}`,
+		`{"op":"add","seq":4,"options":{"device_key":"k","body":"d","id":"x"},"priority":1}`,
+		`{"op":"add","seq":5,"options":{"device_key":"k","body":"e","id":"x","group":"g","recipients":["ops"],"disable_enc":true},"priority":1}`,
+		// 崩溃时写了一半的最后一行
+		`{"op":"add","seq":6,"options":{"dev`,
+	)
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	entries := j.dedup()
+	want := []struct {
+		seq      uint64
+		body     string
+		priority Priority
+	}{
+		{1, "a", PriorityLow},
+		{2, "b", PriorityCritical},
+		{5, "e", PriorityNormal},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("pending entries %+v", entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.seq != w.seq || e.o.Body != w.body || e.priority != w.priority {
+			t.Errorf("entry %d = seq %d body %q priority %v, want %+v", i, e.seq, e.o.Body, e.priority, w)
+		}
+	}
+	if e := entries[2].o; e.Group != "g" || len(e.Recipients) != 1 || e.Recipients[0] != "ops" || !e.DisableEnc {
+		t.Errorf("non-serialized fields not restored: %+v", e)
+	}
+
+	// 新的记录接在已读取的最大序号之后
+	seq, err := j.add(&Options{DeviceKey: "k", Body: "f"}, PriorityHigh)
+	if err != nil || seq != 6 {
+		t.Fatalf("add() = %d, %v", seq, err)
+	}
+
+	// 打开时已压缩, 文件中只剩下未确认的条目
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 4 {
+		t.Errorf("journal has %d lines after compaction, want 4:\n%s", n, data)
+	}
+}
+
+func TestQueueReplaysJournal(t *testing.T) {
+	path := writeJournal(t,
+		`{"op":"add","seq":1,"options":{"device_key":"k","body":"a"},"priority":1}`,
+		`{"op":"add","seq":2,"options":{"device_key":"k","body":"b"},"priority":1}`,
+	)
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &collector{}
+	q := NewQueue(c, WithQueueJournal(j), WithQueueWorkers(1))
+	if err := q.Push(context.Background(), &Options{DeviceKey: "k", Body: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	if got := c.got(); strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("delivered %v, want a,b,c", got)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if pending := j.Pending(); len(pending) != 0 {
+		t.Errorf("delivered pushes should be acknowledged, pending %v", pending)
+	}
+}
+
+// TestQueueJournalKeepsUndelivered 关闭超时中止的推送和未发送的推送保留优先级, 下次打开时重放
+func TestQueueJournalKeepsUndelivered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{}, 1)
+	blocking := PusherFunc(func(ctx context.Context, o *Options) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q := NewQueue(blocking, WithQueueJournal(j), WithQueueWorkers(1))
+	ctx := context.Background()
+	if err := q.Push(ctx, &Options{DeviceKey: "k", Body: "in flight"}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := q.PushPriority(ctx, &Options{DeviceKey: "k", Body: "urgent"}, PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	var drainErr *DrainError
+	if err := q.CloseContext(cancelled); !errors.As(err, &drainErr) || drainErr.Dropped != 2 {
+		t.Fatalf("CloseContext() = %v, want a DrainError with 2 dropped", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	entries := j.dedup()
+	if len(entries) != 2 || entries[0].o.Body != "in flight" || entries[1].o.Body != "urgent" {
+		t.Fatalf("pending entries %+v", entries)
+	}
+	if entries[0].priority != PriorityNormal || entries[1].priority != PriorityHigh {
+		t.Errorf("priorities = %v, %v; want the values given at enqueue time", entries[0].priority, entries[1].priority)
+	}
+}
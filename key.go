@@ -0,0 +1,273 @@
+package bark
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF 密钥派生算法
+type KDF string
+
+const (
+	KDFPBKDF2 KDF = "PBKDF2"
+	KDFScrypt KDF = "SCRYPT"
+)
+
+const (
+	// DefaultPBKDF2Iterations PBKDF2-SHA256 默认迭代次数
+	DefaultPBKDF2Iterations = 100000
+	// DefaultScryptN scrypt 默认 CPU/内存开销参数 N
+	DefaultScryptN = 1 << 15
+	// DefaultKeySize 派生密钥的默认长度 (AES-256)
+	DefaultKeySize = 32
+)
+
+// KeyFormat 密钥文本格式, 用于解码从外部读取的密钥
+type KeyFormat string
+
+const (
+	KeyFormatRaw    KeyFormat = "raw"
+	KeyFormatHex    KeyFormat = "hex"
+	KeyFormatBase64 KeyFormat = "base64"
+)
+
+// DecodeKey 按指定格式解码密钥文本, format 为空时视为原始字符串
+func DecodeKey(s string, format KeyFormat) ([]byte, error) {
+	switch format {
+	case "", KeyFormatRaw:
+		return []byte(s), nil
+	case KeyFormatHex:
+		key, err := hex.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex key: %w", err)
+		}
+		return key, nil
+	case KeyFormatBase64:
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key format: %s (supported: raw, hex, base64)", format)
+	}
+}
+
+// keyAlphabet 随机密钥/IV 使用的字符集, 保证生成的字符串可以直接填写到 Bark App 中
+const keyAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// validateKey 校验密钥来源及长度
+func (e *EncOpt) validateKey() error {
+	sources := 0
+	for _, v := range []string{e.Key, e.KeyHex, e.KeyBase64, e.KeyFile, e.Passphrase} {
+		if v != "" {
+			sources++
+		}
+	}
+	if e.KeyProvider != nil {
+		sources++
+	}
+	if sources > 1 {
+		return errors.New("only one of Key, KeyHex, KeyBase64, KeyFile, KeyProvider and Passphrase may be set")
+	}
+
+	// 外部密钥在推送时获取, 届时再校验长度
+	if e.KeyProvider != nil {
+		return nil
+	}
+
+	if e.Passphrase != "" {
+		if e.Salt == "" {
+			return errors.New("passphrase requires a salt")
+		}
+		if e.Iterations < 0 {
+			return errors.New("kdf iterations must not be negative")
+		}
+		kdf, err := e.kdf()
+		if err != nil {
+			return err
+		}
+		// scrypt 要求 N 是大于 1 的 2 的幂, 提前校验以免推送时才返回 scrypt 的通用错误
+		if kdf == KDFScrypt && e.Iterations != 0 && (e.Iterations < 2 || e.Iterations&(e.Iterations-1) != 0) {
+			return fmt.Errorf("scrypt N (iterations) must be a power of two greater than 1, got %d", e.Iterations)
+		}
+		return checkKeySize(e.derivedKeySize())
+	}
+
+	// 密钥长度校验 (AES-128/192/256 必须是 16, 24, 32 字节)
+	key, err := e.keyBytes()
+	if err != nil {
+		return err
+	}
+	return checkKeySize(len(key))
+}
+
+// keyBytes 返回实际用于 AES 的密钥
+func (e *EncOpt) keyBytes() ([]byte, error) {
+	switch {
+	case e.KeyHex != "":
+		return DecodeKey(e.KeyHex, KeyFormatHex)
+	case e.KeyBase64 != "":
+		return DecodeKey(e.KeyBase64, KeyFormatBase64)
+	case e.KeyFile != "":
+		return readKeyFile(e.KeyFile)
+	case e.Passphrase != "":
+		return e.deriveKey()
+	default:
+		return []byte(e.Key), nil
+	}
+}
+
+// readKeyFile 读取密钥文件, 文件内容即为原始密钥;
+// 若长度不合法, 则去掉末尾空白 (如 echo 写入的换行) 后再使用
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	if checkKeySize(len(data)) != nil {
+		data = bytes.TrimRight(data, " \t\r\n")
+	}
+	return data, nil
+}
+
+// derivedKeyCacheSize 派生密钥缓存的最大条目数, 超出时清空重建
+const derivedKeyCacheSize = 64
+
+// derivedKeys 缓存口令派生的密钥; KDF 被设计为高开销, 避免每次推送都重新派生
+var derivedKeys struct {
+	sync.Mutex
+	m map[derivedKeyID][]byte
+}
+
+// derivedKeyID 决定派生结果的全部参数的 SHA-256 摘要, 缓存中不保留口令原文
+type derivedKeyID [sha256.Size]byte
+
+func newDerivedKeyID(kdf KDF, passphrase, salt string, iterations, size int) derivedKeyID {
+	h := sha256.New()
+	var n [8]byte
+	// 字符串带长度前缀, 避免不同参数拼接出相同的输入
+	for _, v := range []string{string(kdf), salt, passphrase} {
+		binary.BigEndian.PutUint64(n[:], uint64(len(v)))
+		h.Write(n[:])
+		h.Write([]byte(v))
+	}
+	for _, v := range []int{iterations, size} {
+		binary.BigEndian.PutUint64(n[:], uint64(v))
+		h.Write(n[:])
+	}
+	var id derivedKeyID
+	h.Sum(id[:0])
+	return id
+}
+
+// deriveKey 通过 KDF 从口令派生密钥, 相同参数的结果会被缓存
+func (e *EncOpt) deriveKey() ([]byte, error) {
+	kdf, err := e.kdf()
+	if err != nil {
+		return nil, err
+	}
+
+	size := e.derivedKeySize()
+	id := newDerivedKeyID(kdf, e.Passphrase, e.Salt, e.Iterations, size)
+	derivedKeys.Lock()
+	key, ok := derivedKeys.m[id]
+	derivedKeys.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	switch kdf {
+	case KDFScrypt:
+		n := e.Iterations
+		if n == 0 {
+			n = DefaultScryptN
+		}
+		key, err = scrypt.Key([]byte(e.Passphrase), []byte(e.Salt), n, 8, 1, size)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		iter := e.Iterations
+		if iter == 0 {
+			iter = DefaultPBKDF2Iterations
+		}
+		key = pbkdf2.Key([]byte(e.Passphrase), []byte(e.Salt), iter, size, sha256.New)
+	}
+
+	derivedKeys.Lock()
+	if derivedKeys.m == nil || len(derivedKeys.m) >= derivedKeyCacheSize {
+		derivedKeys.m = make(map[derivedKeyID][]byte)
+	}
+	derivedKeys.m[id] = key
+	derivedKeys.Unlock()
+	return key, nil
+}
+
+func (e *EncOpt) kdf() (KDF, error) {
+	kdf := KDF(strings.ToUpper(string(e.KDF)))
+	switch kdf {
+	case "", KDFPBKDF2:
+		return KDFPBKDF2, nil
+	case KDFScrypt:
+		return KDFScrypt, nil
+	default:
+		return "", fmt.Errorf("unsupported kdf: %s (supported: PBKDF2, SCRYPT)", e.KDF)
+	}
+}
+
+func (e *EncOpt) derivedKeySize() int {
+	if e.KeySize == 0 {
+		return DefaultKeySize
+	}
+	return e.KeySize
+}
+
+func checkKeySize(n int) error {
+	if n != 16 && n != 24 && n != 32 {
+		return errors.New("encryption key length must be 16 (AES-128), 24 (AES-192), or 32 (AES-256) bytes")
+	}
+	return nil
+}
+
+// GenerateKey 生成指定长度 (16, 24, 32) 的随机密钥, 仅包含字母和数字, 可直接填写到 Bark App
+func GenerateKey(size int) (string, error) {
+	if err := checkKeySize(size); err != nil {
+		return "", err
+	}
+	return randomString(size)
+}
+
+// GenerateIV 生成指定长度的随机 IV/Nonce (CBC 为 16 字节, GCM 为 12 字节)
+func GenerateIV(size int) (string, error) {
+	if size <= 0 {
+		return "", errors.New("iv length must be positive")
+	}
+	return randomString(size)
+}
+
+func randomString(n int) (string, error) {
+	max := big.NewInt(int64(len(keyAlphabet)))
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = keyAlphabet[idx.Int64()]
+	}
+	return string(b), nil
+}
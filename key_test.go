@@ -0,0 +1,52 @@
+package bark
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDerivedKeyID(t *testing.T) {
+	base := newDerivedKeyID(KDFPBKDF2, "passphrase", "salt", 1000, 32)
+	if base != newDerivedKeyID(KDFPBKDF2, "passphrase", "salt", 1000, 32) {
+		t.Fatal("same parameters should give the same id")
+	}
+	others := []derivedKeyID{
+		newDerivedKeyID(KDFScrypt, "passphrase", "salt", 1000, 32),
+		newDerivedKeyID(KDFPBKDF2, "passphrasE", "salt", 1000, 32),
+		newDerivedKeyID(KDFPBKDF2, "passphrase", "salt", 1001, 32),
+		newDerivedKeyID(KDFPBKDF2, "passphrase", "salt", 1000, 16),
+		// 字段边界移动后拼接结果相同, 摘要仍应不同
+		newDerivedKeyID(KDFPBKDF2, "phrase", "saltpass", 1000, 32),
+		newDerivedKeyID(KDFPBKDF2, "passphrasesalt", "", 1000, 32),
+	}
+	for i, id := range others {
+		if id == base {
+			t.Errorf("case %d: different parameters gave the same id", i)
+		}
+	}
+}
+
+func TestDeriveKeyCached(t *testing.T) {
+	enc := &EncOpt{Mode: EncModeGCM, Passphrase: "correct horse", Salt: "battery staple", Iterations: 1000}
+	first, err := enc.deriveKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := enc.deriveKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) || len(first) != DefaultKeySize {
+		t.Fatalf("cached key differs: %x, %x", first, second)
+	}
+
+	other := *enc
+	other.Passphrase = "wrong horse"
+	key, err := other.deriveKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key, first) {
+		t.Fatal("different passphrases derived the same key")
+	}
+}
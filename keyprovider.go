@@ -0,0 +1,186 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyProvider 外部密钥来源, 按设备 Key 返回对应的 AES 密钥
+// 参考实现见 keyprovider/vault 和 keyprovider/awskeys
+type KeyProvider interface {
+	GetKey(ctx context.Context, deviceKey string) ([]byte, error)
+}
+
+// KeyProviderFunc 将普通函数适配为 KeyProvider
+type KeyProviderFunc func(ctx context.Context, deviceKey string) ([]byte, error)
+
+func (f KeyProviderFunc) GetKey(ctx context.Context, deviceKey string) ([]byte, error) {
+	return f(ctx, deviceKey)
+}
+
+// resolveKey 通过 KeyProvider 获取密钥, 返回携带该密钥的 EncOpt 副本
+func (e *EncOpt) resolveKey(ctx context.Context, deviceKey string) (*EncOpt, error) {
+	key, err := e.KeyProvider.GetKey(ctx, deviceKey)
+	if err != nil {
+		return nil, fmt.Errorf("get encryption key: %w", err)
+	}
+	if err := checkKeySize(len(key)); err != nil {
+		return nil, err
+	}
+
+	resolved := *e
+	resolved.KeyProvider = nil
+	resolved.Key = string(key)
+	return &resolved, nil
+}
+
+// DefaultKeyCacheSize NewCachingKeyProvider 默认缓存的最大设备数
+const DefaultKeyCacheSize = 1024
+
+// KeyCacheOption NewCachingKeyProvider 的配置项
+type KeyCacheOption func(*cachingKeyProvider)
+
+// WithKeyCacheSize 设置最多缓存的设备数, 默认 DefaultKeyCacheSize; 超出时先淘汰最早过期的密钥
+func WithKeyCacheSize(n int) KeyCacheOption {
+	return func(p *cachingKeyProvider) {
+		if n > 0 {
+			p.size = n
+		}
+	}
+}
+
+// WithKeyCacheClock 设置判断密钥过期的时间来源, 默认 SystemClock
+func WithKeyCacheClock(clk Clock) KeyCacheOption {
+	return func(p *cachingKeyProvider) {
+		p.clk = clk
+	}
+}
+
+// NewCachingKeyProvider 为 KeyProvider 增加内存缓存, 避免每次推送都访问外部服务
+// 过期的密钥在读取时删除; 同一设备的并发查询合并为一次对 p 的调用
+func NewCachingKeyProvider(p KeyProvider, ttl time.Duration, opts ...KeyCacheOption) KeyProvider {
+	c := &cachingKeyProvider{
+		provider: p,
+		ttl:      ttl,
+		size:     DefaultKeyCacheSize,
+		entries:  make(map[string]cachedKey),
+		calls:    make(map[string]*keyCall),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type cachedKey struct {
+	key     []byte
+	expires time.Time
+}
+
+// keyCall 正在进行的查询, done 关闭后 key 和 err 可读
+type keyCall struct {
+	done chan struct{}
+	key  []byte
+	err  error
+}
+
+type cachingKeyProvider struct {
+	provider KeyProvider
+	ttl      time.Duration
+	size     int
+	clk      Clock
+
+	mu      sync.Mutex
+	entries map[string]cachedKey
+	calls   map[string]*keyCall
+	// sweepAt 缓存中最早的过期时间, 到达后写入时清理过期的密钥
+	sweepAt time.Time
+}
+
+func (p *cachingKeyProvider) GetKey(ctx context.Context, deviceKey string) ([]byte, error) {
+	for {
+		p.mu.Lock()
+		if entry, ok := p.entries[deviceKey]; ok {
+			if clockOrSystem(p.clk).Now().Before(entry.expires) {
+				p.mu.Unlock()
+				return entry.key, nil
+			}
+			delete(p.entries, deviceKey)
+		}
+		call, inflight := p.calls[deviceKey]
+		if !inflight {
+			call = &keyCall{done: make(chan struct{})}
+			p.calls[deviceKey] = call
+		}
+		p.mu.Unlock()
+
+		if !inflight {
+			return p.fetch(ctx, deviceKey, call)
+		}
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// 发起查询的调用方被取消时由当前调用方重新查询
+		if call.err != nil && isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.key, call.err
+	}
+}
+
+// fetch 查询密钥并写入缓存, 完成后唤醒等待同一设备的调用方
+func (p *cachingKeyProvider) fetch(ctx context.Context, deviceKey string, call *keyCall) ([]byte, error) {
+	call.key, call.err = p.provider.GetKey(ctx, deviceKey)
+
+	p.mu.Lock()
+	delete(p.calls, deviceKey)
+	if call.err == nil {
+		now := clockOrSystem(p.clk).Now()
+		if len(p.entries) >= p.size || (!p.sweepAt.IsZero() && !now.Before(p.sweepAt)) {
+			p.evict(now)
+		}
+		expires := now.Add(p.ttl)
+		p.entries[deviceKey] = cachedKey{key: call.key, expires: expires}
+		if p.sweepAt.IsZero() || expires.Before(p.sweepAt) {
+			p.sweepAt = expires
+		}
+	}
+	p.mu.Unlock()
+	close(call.done)
+	return call.key, call.err
+}
+
+// evict 删除过期的密钥, 仍然超出上限时淘汰最早过期的一个, 调用方负责加锁
+func (p *cachingKeyProvider) evict(now time.Time) {
+	var (
+		oldest  string
+		expires time.Time
+	)
+	for key, entry := range p.entries {
+		if !now.Before(entry.expires) {
+			delete(p.entries, key)
+			continue
+		}
+		if expires.IsZero() || entry.expires.Before(expires) {
+			oldest, expires = key, entry.expires
+		}
+	}
+	if len(p.entries) >= p.size {
+		delete(p.entries, oldest)
+	}
+	p.sweepAt = time.Time{}
+	for _, entry := range p.entries {
+		if p.sweepAt.IsZero() || entry.expires.Before(p.sweepAt) {
+			p.sweepAt = entry.expires
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,127 @@
+// Package awskeys 提供基于 AWS Secrets Manager 和 AWS KMS 的 bark.KeyProvider 实现
+package awskeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// DeviceKeyPlaceholder SecretID 中的设备 Key 占位符
+const DeviceKeyPlaceholder = "{device_key}"
+
+// SecretsManagerAPI *secretsmanager.Client 满足该接口
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManager 从 AWS Secrets Manager 读取密钥
+type SecretsManager struct {
+	Client SecretsManagerAPI
+	// SecretID secret 名称或 ARN, 可包含 {device_key} 占位符
+	SecretID string
+	// Field 若 secret 为 JSON 对象, 指定密钥所在字段; 为空时使用整个 secret
+	Field string
+	// Format 密钥文本格式, 默认原始字符串; SecretBinary 始终按原始字节处理
+	Format bark.KeyFormat
+}
+
+// NewSecretsManager 创建读取 secretID 的 Provider
+func NewSecretsManager(client SecretsManagerAPI, secretID string) *SecretsManager {
+	return &SecretsManager{Client: client, SecretID: secretID}
+}
+
+// GetKey 实现 bark.KeyProvider
+func (p *SecretsManager) GetKey(ctx context.Context, deviceKey string) ([]byte, error) {
+	if p.Client == nil {
+		return nil, errors.New("secrets manager client is required")
+	}
+	if strings.Contains(p.SecretID, DeviceKeyPlaceholder) {
+		if err := bark.ValidateDeviceKey(deviceKey); err != nil {
+			return nil, err
+		}
+	}
+	id := strings.ReplaceAll(p.SecretID, DeviceKeyPlaceholder, deviceKey)
+	out, err := p.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.SecretString == nil {
+		if len(out.SecretBinary) == 0 {
+			return nil, fmt.Errorf("secret %s is empty", id)
+		}
+		return out.SecretBinary, nil
+	}
+
+	value := *out.SecretString
+	if p.Field != "" {
+		var fields map[string]string
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return nil, fmt.Errorf("secret %s is not a JSON object: %w", id, err)
+		}
+		v, ok := fields[p.Field]
+		if !ok {
+			return nil, fmt.Errorf("secret %s has no field %q", id, p.Field)
+		}
+		value = v
+	}
+	return bark.DecodeKey(value, p.Format)
+}
+
+// KMSAPI *kms.Client 满足该接口
+type KMSAPI interface {
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMS 使用信封加密: 配置中只保存经 KMS 加密后的数据密钥, 推送时调用 KMS 解密
+type KMS struct {
+	Client KMSAPI
+	// EncryptedKeys 按设备 Key 保存的加密数据密钥 (CiphertextBlob)
+	EncryptedKeys map[string][]byte
+	// DefaultEncryptedKey 设备未出现在 EncryptedKeys 中时使用的加密数据密钥
+	DefaultEncryptedKey []byte
+	// KeyID 可选, 限定用于解密的 KMS 密钥
+	KeyID string
+	// EncryptionContext 加密时使用的加密上下文
+	EncryptionContext map[string]string
+}
+
+// NewKMS 创建所有设备共用同一个加密数据密钥的 Provider
+func NewKMS(client KMSAPI, encryptedKey []byte) *KMS {
+	return &KMS{Client: client, DefaultEncryptedKey: encryptedKey}
+}
+
+// GetKey 实现 bark.KeyProvider
+func (p *KMS) GetKey(ctx context.Context, deviceKey string) ([]byte, error) {
+	if p.Client == nil {
+		return nil, errors.New("kms client is required")
+	}
+	blob, ok := p.EncryptedKeys[deviceKey]
+	if !ok {
+		blob = p.DefaultEncryptedKey
+	}
+	if len(blob) == 0 {
+		return nil, errors.New("no encrypted data key for device")
+	}
+
+	in := &kms.DecryptInput{
+		CiphertextBlob:    blob,
+		EncryptionContext: p.EncryptionContext,
+	}
+	if p.KeyID != "" {
+		in.KeyId = &p.KeyID
+	}
+	out, err := p.Client.Decrypt(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
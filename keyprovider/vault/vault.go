@@ -0,0 +1,159 @@
+// Package vault 提供从 HashiCorp Vault KV 引擎读取加密密钥的 bark.KeyProvider 实现
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// DeviceKeyPlaceholder Path 中的设备 Key 占位符
+const DeviceKeyPlaceholder = "{device_key}"
+
+// Provider 从 Vault KV 引擎读取密钥
+type Provider struct {
+	// Address Vault 地址, 为空时读取 VAULT_ADDR
+	Address string
+	// Token 访问令牌, 为空时读取 VAULT_TOKEN
+	Token string
+	// Namespace Vault Enterprise 命名空间, 可选
+	Namespace string
+	// Mount KV 引擎挂载路径, 默认 secret
+	Mount string
+	// Path 密钥所在路径, 可包含 {device_key} 占位符以便每台设备使用不同的密钥
+	Path string
+	// Field 密钥所在字段, 默认 key
+	Field string
+	// Format 字段值的格式, 默认原始字符串
+	Format bark.KeyFormat
+	// KVVersion KV 引擎版本 (1 或 2), 默认 2
+	KVVersion int
+
+	HTTPClient *http.Client
+}
+
+// New 创建读取 KV v2 引擎 secret 挂载点下 path 的 Provider
+func New(address, token, path string) *Provider {
+	return &Provider{
+		Address: address,
+		Token:   token,
+		Path:    path,
+	}
+}
+
+// GetKey 实现 bark.KeyProvider
+func (p *Provider) GetKey(ctx context.Context, deviceKey string) ([]byte, error) {
+	address := p.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	token := p.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if p.Path == "" {
+		return nil, errors.New("vault secret path is required")
+	}
+	// GetKey 可能被直接调用, 拼入路径前校验设备 Key, 避免 ../ 之类的值读取其他路径
+	if strings.Contains(p.Path, DeviceKeyPlaceholder) {
+		if err := bark.ValidateDeviceKey(deviceKey); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(address, deviceKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault status: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("decode vault response: %w", err)
+	}
+
+	data := secret.Data
+	if p.kvVersion() == 2 {
+		// KV v2 的实际数据嵌套在 data.data 中
+		data = nil
+		if err := json.Unmarshal(secret.Data["data"], &data); err != nil {
+			return nil, fmt.Errorf("decode vault kv v2 data: %w", err)
+		}
+	}
+
+	field := p.Field
+	if field == "" {
+		field = "key"
+	}
+	raw, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("vault secret has no field %q", field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("vault field %q is not a string", field)
+	}
+
+	return bark.DecodeKey(value, p.Format)
+}
+
+func (p *Provider) url(address, deviceKey string) string {
+	mount := strings.Trim(p.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	path := strings.Trim(strings.ReplaceAll(p.Path, DeviceKeyPlaceholder, url.PathEscape(deviceKey)), "/")
+
+	address = strings.TrimSuffix(address, "/")
+	if p.kvVersion() == 2 {
+		return address + "/v1/" + mount + "/data/" + path
+	}
+	return address + "/v1/" + mount + "/" + path
+}
+
+func (p *Provider) kvVersion() int {
+	if p.KVVersion == 1 {
+		return 1
+	}
+	return 2
+}
+
+func (p *Provider) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
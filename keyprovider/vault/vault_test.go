@@ -0,0 +1,84 @@
+package vault_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/keyprovider/vault"
+)
+
+const testKey = "0123456789abcdef"
+
+// fakeVault 只响应 paths 中的路径 (转义前), 其余返回 404
+func fakeVault(t *testing.T, paths map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		body, ok := paths[r.URL.EscapedPath()]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetKey(t *testing.T) {
+	srv := fakeVault(t, map[string]string{
+		"/v1/secret/data/bark/alice": `{"data":{"data":{"key":"` + testKey + `"}}}`,
+		"/v1/kv/bark/shared":         `{"data":{"aes":"` + testKey + `"}}`,
+		"/v1/secret/data/bark/bob":   `{"data":{"data":{"other":"x"}}}`,
+	})
+
+	tests := []struct {
+		name      string
+		provider  *vault.Provider
+		deviceKey string
+		wantErr   bool
+	}{
+		{"kv v2 per device", vault.New(srv.URL, "token", "bark/{device_key}"), "alice", false},
+		{"kv v1 shared", &vault.Provider{Address: srv.URL, Token: "token", Mount: "kv", Path: "bark/shared", Field: "aes", KVVersion: 1}, "", false},
+		{"missing field", vault.New(srv.URL, "token", "bark/{device_key}"), "bob", true},
+		{"not found", vault.New(srv.URL, "token", "bark/{device_key}"), "carol", true},
+		{"wrong token", vault.New(srv.URL, "nope", "bark/{device_key}"), "alice", true},
+		{"no path", vault.New(srv.URL, "token", ""), "alice", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.provider.GetKey(context.Background(), tt.deviceKey)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetKey() = %q, want an error", key)
+				}
+				return
+			}
+			if err != nil || string(key) != testKey {
+				t.Fatalf("GetKey() = %q, %v", key, err)
+			}
+		})
+	}
+}
+
+// TestGetKeyRejectsPathTraversal 直接调用 GetKey 时, 设备 Key 不能改变读取的路径
+func TestGetKeyRejectsPathTraversal(t *testing.T) {
+	srv := fakeVault(t, map[string]string{
+		"/v1/secret/data/admin": `{"data":{"data":{"key":"` + testKey + `"}}}`,
+	})
+	p := vault.New(srv.URL, "token", "bark/{device_key}")
+	for _, deviceKey := range []string{"../admin", "../../data/admin", "a/b", "a?b", ""} {
+		key, err := p.GetKey(context.Background(), deviceKey)
+		if !errors.Is(err, bark.ErrInvalidDeviceKey) {
+			t.Errorf("GetKey(%q) = %q, %v; want ErrInvalidDeviceKey", deviceKey, key, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package md 提供安全构造 Bark Markdown 推送内容的工具函数,
+// 避免日志输出, 用户输入等原始文本中的 Markdown 符号破坏 Bark App 中的渲染
+//
+//	o.Markdown = md.Bold(md.Escape(title)) + "\n\n" + md.CodeBlock("", logTail)
+package md
+
+import (
+	"strings"
+)
+
+// escaper 转义 CommonMark 中具有特殊含义的 ASCII 标点
+var escaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`,
+	`-`, `\-`, `.`, `\.`, `!`, `\!`, `|`, `\|`, `<`, `\<`, `>`, `\>`,
+	`~`, `\~`, `&`, `\&`,
+)
+
+// Escape 转义 s 中的 Markdown 符号, 使其按原样显示
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Bold 返回加粗文本, s 不会被转义
+func Bold(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "**" + s + "**"
+}
+
+// Code 将 s 包装为行内代码, 内容中的反引号不会提前结束代码
+func Code(s string) string {
+	fence := fenceFor(s, "`")
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// CodeBlock 将 text 包装为代码块, lang 为语言标识, 可以为空
+// 围栏长度大于 text 中最长的连续反引号, 因此任意文本 (包括含有 ``` 的日志) 都不会提前结束代码块
+func CodeBlock(lang, text string) string {
+	fence := fenceFor(text, "```")
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
+
+// fenceFor 返回不少于 min 且长于 s 中最长连续反引号的围栏
+func fenceFor(s, min string) string {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < len(min) {
+		return min
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// urlEscaper 转义会提前结束链接目标的字符
+var urlEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// Link 返回链接, text 会被转义; text 为空时使用 url
+func Link(text, url string) string {
+	if text == "" {
+		text = url
+	}
+	return "[" + Escape(text) + "](" + urlEscaper.Replace(url) + ")"
+}
+
+// Table 返回表格, 第一行为表头; 单元格内容会被转义, 换行替换为空格, 较短的行以空单元格补齐
+func Table(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i := 0; i < cols; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = Escape(strings.Join(strings.Fields(row[i]), " "))
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// List 返回无序列表, 每一项都会被转义
+func List(items ...string) string {
+	var b strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("- " + Escape(strings.Join(strings.Fields(item), " ")))
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package bark
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Clone 返回 Options 的深拷贝, 常用于基于模板构造推送
+func (o *Options) Clone() *Options {
+	c := *o
+	c.DeviceKeys = slices.Clone(o.DeviceKeys)
+	c.Recipients = slices.Clone(o.Recipients)
+	c.Badge = clonePtr(o.Badge)
+	c.IsArchive = clonePtr(o.IsArchive)
+	c.Volume = clonePtr(o.Volume)
+	c.Archive = clonePtr(o.Archive)
+	c.AutoCopyEnabled = clonePtr(o.AutoCopyEnabled)
+	if o.DeviceEnc != nil {
+		c.DeviceEnc = make(map[string]*EncOpt, len(o.DeviceEnc))
+		for k, v := range o.DeviceEnc {
+			c.DeviceEnc[k] = v
+		}
+	}
+	return &c
+}
+
+// SetArchive 设置是否保存到历史记录, 返回 o 以便链式调用
+func (o *Options) SetArchive(archive bool) *Options {
+	o.Archive = &archive
+	return o
+}
+
+// SetAutoCopy 设置是否自动复制 (复制 Copy, 未设置时复制 Body), 返回 o 以便链式调用
+func (o *Options) SetAutoCopy(autoCopy bool) *Options {
+	o.AutoCopyEnabled = &autoCopy
+	return o
+}
+
+// withBoolFields 将布尔字段转换为 Bark 需要的 isArchive 和 autoCopy, 需要转换时返回副本
+func (o *Options) withBoolFields() *Options {
+	archive := o.Archive != nil && o.IsArchive == nil
+	autoCopy := o.AutoCopyEnabled != nil && o.AutoCopy == ""
+	if !archive && !autoCopy {
+		return o
+	}
+	c := *o
+	if archive {
+		n := 0
+		if *o.Archive {
+			n = 1
+		}
+		c.IsArchive = &n
+	}
+	if autoCopy {
+		c.AutoCopy = "0"
+		if *o.AutoCopyEnabled {
+			c.AutoCopy = "1"
+		}
+	}
+	return &c
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// ErrUnknownOption Set 的参数名不是 Bark 推送参数, 可以用 errors.Is 判断
+var ErrUnknownOption = errors.New("unknown option")
+
+// Set 按参数名设置字段值, name 为 Bark 请求参数名 (如 sound, autoCopy, device_keys),
+// 不区分大小写并忽略 _ 和 -, 因此 AUTO_COPY 与 auto-copy 均可匹配 autoCopy
+// device_keys 接受逗号分隔的列表, badge/isArchive/volume 必须是整数
+func (o *Options) Set(name, value string) error {
+	field, ok := optionField(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownOption, name)
+	}
+
+	v := reflect.ValueOf(o).Elem().FieldByIndex(field.Index)
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(value)
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("option %s must be an integer: %w", name, err)
+		}
+		v.Set(reflect.ValueOf(&n))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("option %s cannot be set from text", name)
+	}
+	return nil
+}
+
+// OptionNames 返回所有可通过 Set 设置的参数名
+func OptionNames() []string {
+	fields := optionFields()
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, jsonName(f))
+	}
+	return names
+}
+
+func optionField(name string) (reflect.StructField, bool) {
+	want := normalizeOptionName(name)
+	for _, f := range optionFields() {
+		if normalizeOptionName(jsonName(f)) == want {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// optionFields 返回 Options 中参与 JSON 序列化的字段
+func optionFields() []reflect.StructField {
+	t := reflect.TypeOf(Options{})
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name := jsonName(f); name != "" && name != "-" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+func normalizeOptionName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "_", "")
+	return strings.ReplaceAll(name, "-", "")
+}
@@ -0,0 +1,196 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultPoolIdleTimeout 客户端池中空闲客户端的默认淘汰时间
+const DefaultPoolIdleTimeout = 10 * time.Minute
+
+// ErrRateLimited 推送被客户端池的租户限流拒绝
+var ErrRateLimited = errors.New("bark: rate limit exceeded")
+
+// ClientPool 按服务器地址缓存客户端的连接池, 用于代表大量租户 (各自的 bark-server) 发送推送
+//
+// 所有客户端共享同一个 http.Transport, 连接按主机复用; 超过空闲时间未使用的客户端被淘汰,
+// 其空闲连接随 Transport 的 IdleConnTimeout 关闭. ClientPool 可并发使用
+type ClientPool struct {
+	transport   *http.Transport
+	timeout     time.Duration
+	opts        []ClientOption
+	limit       int
+	per         time.Duration
+	idleTimeout time.Duration
+	clk         Clock
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type poolEntry struct {
+	client   *Client
+	limiter  *RateLimiter
+	lastUsed time.Time
+}
+
+// PoolOption 客户端池配置项
+type PoolOption func(*ClientPool)
+
+// WithPoolClientOptions 设置池中每个客户端创建时使用的配置项
+func WithPoolClientOptions(opts ...ClientOption) PoolOption {
+	return func(p *ClientPool) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// WithPoolRateLimit 限制每个服务器地址 (租户) 每个 per 周期最多推送 n 次, 超出时返回 ErrRateLimited
+func WithPoolRateLimit(n int, per time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.limit = n
+		p.per = per
+	}
+}
+
+// WithPoolIdleTimeout 设置空闲客户端的淘汰时间, 默认 DefaultPoolIdleTimeout, 小于等于 0 时不淘汰
+func WithPoolIdleTimeout(d time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.idleTimeout = d
+	}
+}
+
+// WithPoolTransport 设置共享的 http.Transport, 默认基于 http.DefaultTransport 创建
+func WithPoolTransport(t *http.Transport) PoolOption {
+	return func(p *ClientPool) {
+		if t != nil {
+			p.transport = t
+		}
+	}
+}
+
+// WithPoolClock 设置空闲淘汰和租户限流的时间来源, 默认 SystemClock
+func WithPoolClock(clk Clock) PoolOption {
+	return func(p *ClientPool) {
+		p.clk = clk
+	}
+}
+
+// WithPoolTimeout 设置每个请求的超时时间, 默认 10 秒
+func WithPoolTimeout(d time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		if d > 0 {
+			p.timeout = d
+		}
+	}
+}
+
+// NewClientPool 创建客户端池, 设置了空闲淘汰时间时启动后台淘汰协程, 不再使用时需调用 Close
+func NewClientPool(opts ...PoolOption) *ClientPool {
+	p := &ClientPool{
+		timeout:     10 * time.Second,
+		idleTimeout: DefaultPoolIdleTimeout,
+		entries:     make(map[string]*poolEntry),
+		stop:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.transport == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.MaxIdleConnsPerHost = 16
+		if p.idleTimeout > 0 {
+			t.IdleConnTimeout = p.idleTimeout
+		}
+		p.transport = t
+	}
+	if p.idleTimeout > 0 {
+		go p.janitor()
+	}
+	return p
+}
+
+// Client 返回服务器地址对应的客户端, 不存在时创建, 地址的规范化规则与 New 相同
+func (p *ClientPool) Client(serverURL string) *Client {
+	return p.entry(serverURL).client
+}
+
+// Push 通过服务器地址对应的客户端推送, 超出该地址的限流时返回 ErrRateLimited
+func (p *ClientPool) Push(ctx context.Context, serverURL string, o *Options) error {
+	e := p.entry(serverURL)
+	if !e.limiter.Allow() {
+		return ErrRateLimited
+	}
+	return e.client.Push(ctx, o)
+}
+
+// Len 返回池中缓存的客户端数量
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Evict 移除服务器地址对应的客户端
+func (p *ClientPool) Evict(serverURL string) {
+	p.mu.Lock()
+	delete(p.entries, normalizeServerURL(serverURL))
+	p.mu.Unlock()
+}
+
+// Close 停止淘汰协程并关闭共享 Transport 的空闲连接, 之后仍可调用 Client 和 Push 但不再淘汰空闲客户端
+func (p *ClientPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		p.transport.CloseIdleConnections()
+	})
+}
+
+func (p *ClientPool) entry(serverURL string) *poolEntry {
+	key := normalizeServerURL(serverURL)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[key]
+	if !ok {
+		hc := &http.Client{Transport: p.transport, Timeout: p.timeout}
+		opts := append([]ClientOption{WithHTTPClient(hc)}, p.opts...)
+		e = &poolEntry{client: New(key, opts...)}
+		if p.limit > 0 && p.per > 0 {
+			e.limiter = NewRateLimiter(p.limit, p.per).WithClock(p.clk)
+		}
+		p.entries[key] = e
+	}
+	e.lastUsed = clockOrSystem(p.clk).Now()
+	return e
+}
+
+// janitor 定期淘汰空闲客户端
+func (p *ClientPool) janitor() {
+	clk := clockOrSystem(p.clk)
+	timer := clk.NewTimer(p.idleTimeout / 2)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			p.evictIdle(clk.Now())
+			timer.Reset(p.idleTimeout / 2)
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *ClientPool) evictIdle(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, e := range p.entries {
+		if now.Sub(e.lastUsed) >= p.idleTimeout {
+			delete(p.entries, key)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bark
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Preset 预设的通知样式, 集中定义图标, 铃声, 级别和分组, 使各工具发出的通知保持一致
+type Preset struct {
+	Icon   string
+	Sound  string
+	Level  string
+	Group  string
+	Volume *int
+	Call   string
+}
+
+// 内置预设, 图标使用 Twemoji 的 PNG 图片
+var (
+	PresetSuccess = Preset{
+		Icon:  "https://cdn.jsdelivr.net/gh/twitter/twemoji@14.0.2/assets/72x72/2705.png",
+		Sound: "paymentsuccess",
+		Level: "passive",
+		Group: "success",
+	}
+	PresetWarning = Preset{
+		Icon:  "https://cdn.jsdelivr.net/gh/twitter/twemoji@14.0.2/assets/72x72/26a0.png",
+		Sound: "bell",
+		Level: "active",
+		Group: "warning",
+	}
+	PresetError = Preset{
+		Icon:  "https://cdn.jsdelivr.net/gh/twitter/twemoji@14.0.2/assets/72x72/274c.png",
+		Sound: "alarm",
+		Level: "timeSensitive",
+		Group: "error",
+	}
+	PresetCritical = Preset{
+		Icon:   "https://cdn.jsdelivr.net/gh/twitter/twemoji@14.0.2/assets/72x72/1f6a8.png",
+		Sound:  "alarm",
+		Level:  "critical",
+		Group:  "critical",
+		Volume: IntPtr(10),
+		Call:   "1",
+	}
+)
+
+// Presets 预设名称到预设的映射, 供 PresetByName 和命令行 --preset 使用, 可以注册自定义预设
+var Presets = map[string]Preset{
+	"success":  PresetSuccess,
+	"warning":  PresetWarning,
+	"error":    PresetError,
+	"critical": PresetCritical,
+}
+
+// PresetByName 按名称 (不区分大小写) 查找预设
+func PresetByName(name string) (Preset, error) {
+	p, ok := Presets[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(Presets))
+		for n := range Presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Preset{}, fmt.Errorf("unknown preset: %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// ApplyPreset 将预设写入 o 中尚未设置的字段, 已设置的字段保持不变; 返回 o 以便链式调用
+func (o *Options) ApplyPreset(p Preset) *Options {
+	if o.Icon == "" {
+		o.Icon = p.Icon
+	}
+	if o.Sound == "" {
+		o.Sound = p.Sound
+	}
+	if o.Level == "" {
+		o.Level = p.Level
+	}
+	if o.Group == "" {
+		o.Group = p.Group
+	}
+	if o.Volume == nil {
+		o.Volume = clonePtr(p.Volume)
+	}
+	if o.Call == "" {
+		o.Call = p.Call
+	}
+	return o
+}
@@ -0,0 +1,102 @@
+package bark
+
+import "time"
+
+// DefaultQueueMaxWait 队列中低优先级推送的默认最长等待时间, 见 WithQueueMaxWait
+const DefaultQueueMaxWait = 30 * time.Second
+
+// Priority 推送在异步队列中的优先级, 队列积压时优先级高的推送先发送
+type Priority int
+
+const (
+	// PriorityLow passive 级别的推送, 如摘要, 日报
+	PriorityLow Priority = iota
+	// PriorityNormal active 级别或未设置级别的推送
+	PriorityNormal
+	// PriorityHigh timeSensitive 级别的推送
+	PriorityHigh
+	// PriorityCritical critical 级别的推送
+	PriorityCritical
+
+	numPriorities = int(PriorityCritical) + 1
+)
+
+// PriorityOf 按 Level 返回推送的默认优先级
+func PriorityOf(o *Options) Priority {
+	switch o.Level {
+	case "critical":
+		return PriorityCritical
+	case "timeSensitive":
+		return PriorityHigh
+	case "passive":
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// WithQueueMaxWait 设置低优先级推送的最长等待时间, 默认 DefaultQueueMaxWait
+// 等待超过 d 的推送按入队顺序先于其他推送发送, 避免持续的高优先级推送使其一直得不到发送; d 小于 0 时严格按优先级发送
+func WithQueueMaxWait(d time.Duration) QueueOption {
+	return func(q *Queue) {
+		if d != 0 {
+			q.pending.maxWait = d
+		}
+	}
+}
+
+// priorityQueue 按优先级出队的待发送推送, 同一优先级先进先出, 调用方负责加锁
+type priorityQueue struct {
+	items   [numPriorities][]queueItem
+	n       int
+	maxWait time.Duration
+}
+
+func (pq *priorityQueue) push(item queueItem) {
+	p := clampPriority(item.priority)
+	pq.items[p] = append(pq.items[p], item)
+	pq.n++
+}
+
+// pop 取出下一条推送: 等待超时的推送中最早入队的一条, 没有时取优先级最高的一条; 队列为空时返回 false
+func (pq *priorityQueue) pop(now time.Time) (queueItem, bool) {
+	if pq.n == 0 {
+		return queueItem{}, false
+	}
+	next := -1
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(pq.items[p]) > 0 {
+			next = p
+			break
+		}
+	}
+	if pq.maxWait > 0 {
+		var oldest time.Time
+		top := next
+		for p := 0; p < top; p++ {
+			if len(pq.items[p]) == 0 {
+				continue
+			}
+			queued := pq.items[p][0].queued
+			if now.Sub(queued) >= pq.maxWait && (oldest.IsZero() || queued.Before(oldest)) {
+				next, oldest = p, queued
+			}
+		}
+	}
+
+	item := pq.items[next][0]
+	pq.items[next][0] = queueItem{}
+	pq.items[next] = pq.items[next][1:]
+	pq.n--
+	return item, true
+}
+
+func clampPriority(p Priority) Priority {
+	if p < PriorityLow {
+		return PriorityLow
+	}
+	if p > PriorityCritical {
+		return PriorityCritical
+	}
+	return p
+}
@@ -0,0 +1,117 @@
+package bark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityOf(t *testing.T) {
+	tests := map[string]Priority{
+		"":              PriorityNormal,
+		"active":        PriorityNormal,
+		"passive":       PriorityLow,
+		"timeSensitive": PriorityHigh,
+		"critical":      PriorityCritical,
+		"unknown":       PriorityNormal,
+	}
+	for level, want := range tests {
+		if got := PriorityOf(&Options{Level: level}); got != want {
+			t.Errorf("PriorityOf(%q) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+// queued 描述一条入队的推送: 名称, 优先级和入队时间 (相对 t0 的秒数)
+type queued struct {
+	name     string
+	priority Priority
+	at       int
+}
+
+func TestPriorityQueuePop(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		maxWait time.Duration
+		items   []queued
+		// now 出队时间, 相对 t0 的秒数
+		now  int
+		want []string
+	}{
+		{
+			name:    "by priority, fifo within a priority",
+			maxWait: time.Minute,
+			items:   []queued{{"low", PriorityLow, 0}, {"n1", PriorityNormal, 0}, {"crit", PriorityCritical, 0}, {"n2", PriorityNormal, 0}, {"high", PriorityHigh, 0}},
+			want:    []string{"crit", "high", "n1", "n2", "low"},
+		},
+		{
+			name:    "out of range priorities are clamped",
+			maxWait: time.Minute,
+			items:   []queued{{"below", -5, 0}, {"above", 99, 0}, {"normal", PriorityNormal, 0}},
+			want:    []string{"above", "normal", "below"},
+		},
+		{
+			name:    "overdue low goes before high",
+			maxWait: time.Minute,
+			items:   []queued{{"low", PriorityLow, 0}, {"high", PriorityHigh, 50}},
+			now:     60,
+			want:    []string{"low", "high"},
+		},
+		{
+			name:    "older overdue normal goes before newer overdue low",
+			maxWait: time.Minute,
+			items:   []queued{{"normal", PriorityNormal, 0}, {"low", PriorityLow, 10}, {"high", PriorityHigh, 70}},
+			now:     80,
+			want:    []string{"normal", "low", "high"},
+		},
+		{
+			name:    "older overdue low goes before newer overdue normal",
+			maxWait: time.Minute,
+			items:   []queued{{"low", PriorityLow, 0}, {"normal", PriorityNormal, 10}, {"high", PriorityHigh, 70}},
+			now:     80,
+			want:    []string{"low", "normal", "high"},
+		},
+		{
+			name:    "not yet overdue",
+			maxWait: time.Minute,
+			items:   []queued{{"low", PriorityLow, 0}, {"high", PriorityHigh, 0}},
+			now:     59,
+			want:    []string{"high", "low"},
+		},
+		{
+			name:    "negative max wait is strict",
+			maxWait: -1,
+			items:   []queued{{"low", PriorityLow, 0}, {"high", PriorityHigh, 0}},
+			now:     3600,
+			want:    []string{"high", "low"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pq := &priorityQueue{maxWait: tt.maxWait}
+			for _, it := range tt.items {
+				pq.push(queueItem{o: &Options{Body: it.name}, priority: it.priority, queued: t0.Add(time.Duration(it.at) * time.Second)})
+			}
+			now := t0.Add(time.Duration(tt.now) * time.Second)
+			var got []string
+			for {
+				item, ok := pq.pop(now)
+				if !ok {
+					break
+				}
+				got = append(got, item.o.Body)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("popped %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("popped %v, want %v", got, tt.want)
+				}
+			}
+			if pq.n != 0 {
+				t.Errorf("n = %d after draining", pq.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownProfile 未注册的 profile
+var ErrUnknownProfile = errors.New("bark: unknown profile")
+
+// ProfileClient 命名的客户端及其默认推送参数
+type ProfileClient struct {
+	Name     string
+	Client   *Client
+	Defaults *Options
+
+	err error
+}
+
+// Push 合并默认参数后推送, o 中已设置的字段优先
+func (p *ProfileClient) Push(ctx context.Context, o *Options) error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.Client.Push(ctx, mergeOptions(o, p.Defaults))
+}
+
+// Profiles 名称到 ProfileClient 的注册表, 可并发使用
+type Profiles struct {
+	mu       sync.RWMutex
+	profiles map[string]*ProfileClient
+}
+
+// DefaultProfiles 包级别默认注册表, 供 Profile / RegisterProfile / LoadProfiles 使用
+var DefaultProfiles = NewProfiles()
+
+// NewProfiles 创建空的注册表
+func NewProfiles() *Profiles {
+	return &Profiles{profiles: make(map[string]*ProfileClient)}
+}
+
+// Register 注册或替换名为 name 的 profile
+func (r *Profiles) Register(name string, client *Client, defaults *Options) *ProfileClient {
+	if defaults == nil {
+		defaults = &Options{}
+	}
+	p := &ProfileClient{Name: name, Client: client, Defaults: defaults}
+	r.mu.Lock()
+	r.profiles[name] = p
+	r.mu.Unlock()
+	return p
+}
+
+// Get 查找名为 name 的 profile
+func (r *Profiles) Get(name string) (*ProfileClient, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.profiles[name]
+	return p, ok
+}
+
+// Profile 查找名为 name 的 profile, 未找到时返回的 ProfileClient 推送总是返回 ErrUnknownProfile
+func (r *Profiles) Profile(name string) *ProfileClient {
+	if p, ok := r.Get(name); ok {
+		return p
+	}
+	return &ProfileClient{Name: name, err: fmt.Errorf("%w: %s", ErrUnknownProfile, name)}
+}
+
+// Names 返回排序后的 profile 名称
+func (r *Profiles) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load 注册配置中的全部 profile; 配置了 default 时额外注册为空名称的 profile
+func (r *Profiles) Load(cfg *Config) error {
+	for _, name := range cfg.ProfileNames() {
+		client, defaults, err := cfg.Client(name)
+		if err != nil {
+			return err
+		}
+		p := r.Register(name, client, defaults)
+		if name == cfg.Default {
+			r.mu.Lock()
+			r.profiles[""] = p
+			r.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Profile 在 DefaultProfiles 中查找名为 name 的 profile
+func Profile(name string) *ProfileClient {
+	return DefaultProfiles.Profile(name)
+}
+
+// RegisterProfile 在 DefaultProfiles 中注册 profile
+func RegisterProfile(name string, client *Client, defaults *Options) *ProfileClient {
+	return DefaultProfiles.Register(name, client, defaults)
+}
+
+// LoadProfiles 读取配置文件并将其中的 profile 注册到 DefaultProfiles
+func LoadProfiles(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return DefaultProfiles.Load(cfg)
+}
+
+// mergeOptions 返回 o 的副本, 其中未设置的字段由 defaults 填充
+// 收件人作为整体处理: o 未指定任何设备或别名时才使用默认收件人
+func mergeOptions(o, defaults *Options) *Options {
+	merged := o.Clone()
+	if defaults == nil {
+		return merged
+	}
+
+	dst := reflect.ValueOf(merged).Elem()
+	src := reflect.ValueOf(defaults.Clone()).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		switch dst.Type().Field(i).Name {
+		case "DeviceKey", "DeviceKeys", "Recipients":
+			continue
+		}
+		if f := dst.Field(i); f.IsZero() {
+			f.Set(src.Field(i))
+		}
+	}
+
+	if merged.DeviceKey == "" && len(merged.DeviceKeys) == 0 && len(merged.Recipients) == 0 {
+		merged.DeviceKey = defaults.DeviceKey
+		merged.DeviceKeys = slices.Clone(defaults.DeviceKeys)
+		merged.Recipients = slices.Clone(defaults.Recipients)
+	}
+	return merged
+}
@@ -0,0 +1,30 @@
+package bark
+
+import "context"
+
+// Pusher 推送接口, *Client 和 *ProfileClient 均实现该接口
+// 业务代码依赖该接口即可方便地替换为 mock, 多后端分发或装饰器
+type Pusher interface {
+	Push(ctx context.Context, o *Options) error
+}
+
+// PusherFunc 将普通函数适配为 Pusher
+type PusherFunc func(ctx context.Context, o *Options) error
+
+func (f PusherFunc) Push(ctx context.Context, o *Options) error {
+	return f(ctx, o)
+}
+
+var (
+	_ Pusher = (*Client)(nil)
+	_ Pusher = (*ProfileClient)(nil)
+	_ Pusher = (*Queue)(nil)
+	_ Pusher = NopPusher{}
+)
+
+// NopPusher 不发送任何请求的 Pusher, 适用于演示和无需通知的环境
+type NopPusher struct{}
+
+func (NopPusher) Push(context.Context, *Options) error {
+	return nil
+}
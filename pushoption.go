@@ -0,0 +1,72 @@
+package bark
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PushOption 单次推送的配置项, 见 PushWithOptions
+type PushOption func(*pushSettings)
+
+// pushSettings 单次推送的配置, 通过 ctx 传递到发送请求的位置
+type pushSettings struct {
+	timeout        time.Duration
+	skipValidation bool
+	actor          string
+}
+
+type pushSettingsKey struct{}
+
+// WithTimeout 设置本次推送的超时时间, 替代客户端 HTTPClient 的超时 (默认 10 秒), 可以更短也可以更长
+// 超时包含获取外部密钥, 按设备拆分的全部请求; ctx 自身的截止时间更早时以 ctx 为准
+func WithTimeout(d time.Duration) PushOption {
+	return func(s *pushSettings) {
+		s.timeout = d
+	}
+}
+
+// SkipValidation 本次推送跳过 Options.Validate 及严格模式的全部校验, 见 WithSkipValidation
+func SkipValidation() PushOption {
+	return func(s *pushSettings) {
+		s.skipValidation = true
+	}
+}
+
+// PushWithOptions 按单次配置发送推送, 如健康检查使用更短的超时, 大批量加密推送使用更长的超时
+//
+//	err := client.PushWithOptions(ctx, o, bark.WithTimeout(2*time.Second))
+func (c *Client) PushWithOptions(ctx context.Context, o *Options, opts ...PushOption) error {
+	var s pushSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	if s != (pushSettings{}) {
+		ctx = context.WithValue(ctx, pushSettingsKey{}, &s)
+	}
+	return c.Push(ctx, o)
+}
+
+// settings 返回 ctx 携带的单次推送配置, 没有时返回零值
+func settings(ctx context.Context) pushSettings {
+	if s, ok := ctx.Value(pushSettingsKey{}).(*pushSettings); ok {
+		return *s
+	}
+	return pushSettings{}
+}
+
+// httpClient 返回本次请求使用的 HTTP 客户端
+// 单次推送设置了超时时由 ctx 控制超时, 使用不带 Timeout 的副本 (共享 Transport)
+func (c *Client) httpClient(ctx context.Context) *http.Client {
+	if settings(ctx).timeout <= 0 || c.HTTPClient.Timeout == 0 {
+		return c.HTTPClient
+	}
+	hc := *c.HTTPClient
+	hc.Timeout = 0
+	return &hc
+}
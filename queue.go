@@ -0,0 +1,478 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultQueueSize 异步队列的默认容量
+	DefaultQueueSize = 256
+	// DefaultQueueWorkers 异步队列默认的发送协程数
+	DefaultQueueWorkers = 4
+)
+
+var (
+	// ErrQueueFull 队列已满, 由 Queue.TryPush 返回
+	ErrQueueFull = errors.New("bark: queue is full")
+	// ErrQueueClosed 队列已关闭
+	ErrQueueClosed = errors.New("bark: queue is closed")
+)
+
+// Queue 有界异步推送队列, 由固定数量的发送协程消费
+//
+// Queue 本身实现 Pusher: Push 入队后立即返回, 队列已满时阻塞直到有空位或 ctx 结束,
+// 从而把背压传递给生产者 (如消息队列桥接会暂停消费). 发送失败通过 ErrorHandler 报告
+//
+// 队列积压时按优先级发送 (见 Priority, PushPriority), critical 告警先于 passive 摘要发送
+type Queue struct {
+	p            Pusher
+	size         int
+	workers      int
+	errorHandler func(ctx context.Context, o *Options, err error)
+	journal      *Journal
+	clk          Clock
+
+	// slots 容量为队列大小, 入队时占用, 发送协程取出时释放
+	slots chan struct{}
+	// ready 每条入队的推送对应一个信号, 由发送协程消费
+	ready chan struct{}
+	// pmu 保护 pending
+	pmu     sync.Mutex
+	pending priorityQueue
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+	// done 开始关闭时关闭, 使等待空位的 Push 立即返回
+	done      chan struct{}
+	closeOnce sync.Once
+	// stop 关闭超时时取消, 发送协程丢弃剩余的推送并中止正在发送的推送
+	stop    context.Context
+	abort   context.CancelFunc
+	dropped atomic.Int64
+}
+
+// DrainError 关闭时 ctx 已结束, 仍有推送未发送, 见 Queue.CloseContext, Client.Close
+// 设置了 WithQueueJournal 时被丢弃的推送保留在日志中, 下次启动时重放
+type DrainError struct {
+	// Dropped 被丢弃 (包括发送中被中止) 的推送数量
+	Dropped int
+	// Err ctx.Err()
+	Err error
+}
+
+func (e *DrainError) Error() string {
+	return fmt.Sprintf("bark: %d notifications dropped on close: %v", e.Dropped, e.Err)
+}
+
+func (e *DrainError) Unwrap() error {
+	return e.Err
+}
+
+type queueItem struct {
+	ctx context.Context
+	o   *Options
+	// seq 日志序号, 未设置日志时为 0
+	seq      uint64
+	priority Priority
+	queued   time.Time
+}
+
+// QueueOption 队列配置项
+type QueueOption func(*Queue)
+
+// WithQueueSize 设置队列容量, 默认 DefaultQueueSize
+func WithQueueSize(n int) QueueOption {
+	return func(q *Queue) {
+		if n > 0 {
+			q.size = n
+		}
+	}
+}
+
+// WithQueueWorkers 设置发送协程数, 默认 DefaultQueueWorkers
+func WithQueueWorkers(n int) QueueOption {
+	return func(q *Queue) {
+		if n > 0 {
+			q.workers = n
+		}
+	}
+}
+
+// WithQueueErrorHandler 设置发送失败的回调, 默认通过 slog.Default() 记录错误
+func WithQueueErrorHandler(fn func(ctx context.Context, o *Options, err error)) QueueOption {
+	return func(q *Queue) {
+		q.errorHandler = fn
+	}
+}
+
+// WithQueueJournal 将入队的推送记录到日志, 发送后 (无论成功或失败) 确认
+// NewQueue 会先重新投递日志中未确认的推送, 使进程崩溃时已入队的推送不会丢失, 见 OpenJournal
+func WithQueueJournal(j *Journal) QueueOption {
+	return func(q *Queue) {
+		q.journal = j
+	}
+}
+
+// WithQueueClock 设置队列的时间来源 (用于 WithQueueMaxWait), 默认 SystemClock
+func WithQueueClock(clk Clock) QueueOption {
+	return func(q *Queue) {
+		q.clk = clk
+	}
+}
+
+// NewQueue 创建队列并启动发送协程
+// 设置了 WithQueueJournal 时, 返回前将日志中未确认的推送按入队顺序加入队列 (队列已满时等待)
+func NewQueue(p Pusher, opts ...QueueOption) *Queue {
+	q := &Queue{p: p, size: DefaultQueueSize, workers: DefaultQueueWorkers}
+	q.pending.maxWait = DefaultQueueMaxWait
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.slots = make(chan struct{}, q.size)
+	q.ready = make(chan struct{}, q.size)
+	q.done = make(chan struct{})
+	q.stop, q.abort = context.WithCancel(context.Background())
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	if q.journal != nil {
+		q.journal.mu.Lock()
+		pending := q.journal.dedup()
+		q.journal.mu.Unlock()
+		for _, e := range pending {
+			q.slots <- struct{}{}
+			q.enqueue(queueItem{ctx: context.Background(), o: e.o.Clone(), seq: e.seq, priority: e.priority})
+		}
+	}
+	return q
+}
+
+// Push 将推送加入队列, 队列已满时阻塞直到有空位或 ctx 结束, 优先级由 PriorityOf 决定
+// 发送时使用 ctx 携带的值, 但不受 ctx 取消的影响
+func (q *Queue) Push(ctx context.Context, o *Options) error {
+	return q.PushPriority(ctx, o, PriorityOf(o))
+}
+
+// PushPriority 以指定的优先级将推送加入队列, 如让某条 active 级别的推送先于其他推送发送
+func (q *Queue) PushPriority(ctx context.Context, o *Options, p Priority) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.isClosing() {
+		return ErrQueueClosed
+	}
+	item, err := q.newItem(ctx, o, p)
+	if err != nil {
+		return err
+	}
+	select {
+	case q.slots <- struct{}{}:
+		q.enqueue(item)
+		return nil
+	case <-ctx.Done():
+		q.ack(item)
+		return ctx.Err()
+	case <-q.done:
+		q.ack(item)
+		return ErrQueueClosed
+	}
+}
+
+// TryPush 将推送加入队列, 队列已满时立即返回 ErrQueueFull
+func (q *Queue) TryPush(ctx context.Context, o *Options) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.isClosing() {
+		return ErrQueueClosed
+	}
+	item, err := q.newItem(ctx, o, PriorityOf(o))
+	if err != nil {
+		return err
+	}
+	select {
+	case q.slots <- struct{}{}:
+		q.enqueue(item)
+		return nil
+	default:
+		q.ack(item)
+		return ErrQueueFull
+	}
+}
+
+// newItem 复制推送参数, 设置了日志时先写入日志
+func (q *Queue) newItem(ctx context.Context, o *Options, p Priority) (queueItem, error) {
+	item := queueItem{ctx: context.WithoutCancel(ctx), o: o.Clone(), priority: p}
+	if q.journal != nil {
+		seq, err := q.journal.add(item.o, p)
+		if err != nil {
+			return queueItem{}, fmt.Errorf("bark: write queue journal: %w", err)
+		}
+		item.seq = seq
+	}
+	return item, nil
+}
+
+// enqueue 将已占用空位的推送加入待发送列表
+func (q *Queue) enqueue(item queueItem) {
+	item.queued = clockOrSystem(q.clk).Now()
+	q.pmu.Lock()
+	q.pending.push(item)
+	q.pmu.Unlock()
+	q.ready <- struct{}{}
+}
+
+// ack 在日志中确认推送已处理
+func (q *Queue) ack(item queueItem) {
+	if q.journal == nil || item.seq == 0 {
+		return
+	}
+	if err := q.journal.ack(item.seq); err != nil {
+		slog.Default().ErrorContext(item.ctx, "bark: write queue journal failed", "err", err)
+	}
+}
+
+// Len 返回队列中等待发送的推送数量
+func (q *Queue) Len() int {
+	q.pmu.Lock()
+	defer q.pmu.Unlock()
+	return q.pending.n
+}
+
+// Close 停止接收新的推送, 等待队列中已有的推送发送完毕
+func (q *Queue) Close() {
+	_ = q.CloseContext(context.Background())
+}
+
+// CloseContext 停止接收新的推送, 在 ctx 结束前等待队列中已有的推送发送完毕
+// ctx 结束时丢弃剩余的推送并中止正在发送的推送, 返回记录丢弃数量的 *DrainError
+func (q *Queue) CloseContext(ctx context.Context) error {
+	q.closeOnce.Do(func() { close(q.done) })
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ready)
+	}
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+	q.abort()
+	<-drained
+	if n := q.dropped.Load(); n > 0 {
+		return &DrainError{Dropped: int(n), Err: ctx.Err()}
+	}
+	return nil
+}
+
+func (q *Queue) isClosing() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for range q.ready {
+		q.pmu.Lock()
+		item, _ := q.pending.pop(clockOrSystem(q.clk).Now())
+		q.pmu.Unlock()
+		<-q.slots
+		if q.stop.Err() != nil {
+			q.dropped.Add(1)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(item.ctx)
+		stop := context.AfterFunc(q.stop, cancel)
+		err := q.p.Push(ctx, item.o)
+		stop()
+		cancel()
+		if err != nil && q.stop.Err() != nil {
+			// 关闭超时中止的推送不确认, 保留在日志中
+			q.dropped.Add(1)
+			q.handleError(item.ctx, item.o, err)
+			continue
+		}
+		if err != nil {
+			q.handleError(item.ctx, item.o, err)
+		}
+		q.ack(item)
+	}
+}
+
+func (q *Queue) handleError(ctx context.Context, o *Options, err error) {
+	if q.errorHandler != nil {
+		q.errorHandler(ctx, o, err)
+		return
+	}
+	slog.Default().ErrorContext(ctx, "bark: async push failed", "err", err)
+}
+
+// Channel 返回一个推送通道, 写入的推送由队列的发送协程异步推送, 便于接入基于 channel 的流水线
+// 队列已满时写入阻塞 (背压); 关闭通道后等待已写入的推送发送完毕. opts 为队列配置, 如 WithQueueWorkers
+// ctx 结束后写入的推送不再发送, 以 ctx.Err() 报告给 ErrorHandler; Client.Close 后以 ErrQueueClosed 报告.
+// 通道始终需要由写入方关闭
+//
+//	ch := client.Channel(ctx, bark.WithQueueWorkers(8))
+//	defer close(ch)
+//	for event := range events {
+//		ch <- toOptions(event)
+//	}
+func (c *Client) Channel(ctx context.Context, opts ...QueueOption) chan<- *Options {
+	ch := make(chan *Options)
+	cq := &channelQueue{q: NewQueue(c, opts...), stop: make(chan struct{}), done: make(chan struct{})}
+	cq.ctx, cq.cancel = context.WithCancel(ctx)
+	c.state.async.add(cq)
+	go func() {
+		defer close(cq.done)
+		defer c.state.async.remove(cq)
+		defer cq.cancel()
+		cq.forward(ch)
+	}()
+	return ch
+}
+
+// channelQueue Channel 返回的通道及其队列
+type channelQueue struct {
+	q *Queue
+	// ctx 转发到队列时使用, Client.Close 的 ctx 结束时取消
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stop 由 Client.Close 关闭, 关闭前设置 closeCtx
+	stop     chan struct{}
+	closeCtx context.Context
+	// done 转发协程结束 (队列已关闭) 时关闭
+	done chan struct{}
+	// err 关闭队列的结果, dropped 关闭期间未能入队的推送数
+	err     error
+	dropped int
+}
+
+// forward 将通道中的推送转发到队列, 直到通道关闭或 Client.Close
+func (cq *channelQueue) forward(ch <-chan *Options) {
+	for {
+		select {
+		case <-cq.stop:
+			cq.err = cq.q.CloseContext(cq.closeCtx)
+			go cq.discard(ch)
+			return
+		default:
+		}
+		select {
+		case o, ok := <-ch:
+			if !ok {
+				cq.q.Close()
+				return
+			}
+			err := cq.ctx.Err()
+			if err == nil {
+				err = cq.q.Push(cq.ctx, o)
+			}
+			if err != nil {
+				if cq.closing() {
+					cq.dropped++
+				}
+				cq.q.handleError(cq.ctx, o, err)
+			}
+		case <-cq.stop:
+		}
+	}
+}
+
+// discard 客户端关闭后继续读取通道, 避免写入方阻塞, 推送以 ErrQueueClosed 报告
+func (cq *channelQueue) discard(ch <-chan *Options) {
+	for o := range ch {
+		cq.q.handleError(cq.ctx, o, ErrQueueClosed)
+	}
+}
+
+func (cq *channelQueue) closing() bool {
+	select {
+	case <-cq.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// asyncQueues 客户端通过 Channel 创建的队列, 见 Client.Close
+type asyncQueues struct {
+	mu     sync.Mutex
+	closed bool
+	queues map[*channelQueue]struct{}
+}
+
+// add 登记队列, 客户端已关闭时立即停止转发
+func (a *asyncQueues) add(cq *channelQueue) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		cq.closeCtx = context.Background()
+		close(cq.stop)
+		return
+	}
+	if a.queues == nil {
+		a.queues = make(map[*channelQueue]struct{})
+	}
+	a.queues[cq] = struct{}{}
+}
+
+func (a *asyncQueues) remove(cq *channelQueue) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.queues, cq)
+}
+
+// Close 停止接收新的异步推送 (Channel 创建的队列), 在 ctx 结束前等待已入队和正在发送的推送完成
+// ctx 结束时丢弃剩余的推送, 返回记录丢弃数量的 *DrainError. 同步的 Push 不受影响
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//		log.Println(err) // bark: 3 notifications dropped on close: context deadline exceeded
+//	}
+func (c *Client) Close(ctx context.Context) error {
+	c.state.async.mu.Lock()
+	c.state.async.closed = true
+	queues := make([]*channelQueue, 0, len(c.state.async.queues))
+	for cq := range c.state.async.queues {
+		cq.closeCtx = ctx
+		close(cq.stop)
+		queues = append(queues, cq)
+	}
+	c.state.async.mu.Unlock()
+
+	dropped := 0
+	for _, cq := range queues {
+		stop := context.AfterFunc(ctx, cq.cancel)
+		<-cq.done
+		stop()
+		var drainErr *DrainError
+		if errors.As(cq.err, &drainErr) {
+			dropped += drainErr.Dropped
+		}
+		dropped += cq.dropped
+	}
+	if dropped > 0 {
+		return &DrainError{Dropped: dropped, Err: ctx.Err()}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package bark_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// gate 第一条推送阻塞到 release 关闭, 记录发送顺序
+type gate struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newGate() *gate {
+	return &gate{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gate) Push(ctx context.Context, o *bark.Options) error {
+	first := false
+	g.once.Do(func() { first = true })
+	if first {
+		close(g.started)
+		select {
+		case <-g.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.bodies = append(g.bodies, o.Body)
+	return nil
+}
+
+func (g *gate) got() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return strings.Join(g.bodies, ",")
+}
+
+func TestQueuePriorityOrder(t *testing.T) {
+	g := newGate()
+	q := bark.NewQueue(g, bark.WithQueueWorkers(1))
+	ctx := context.Background()
+	if err := q.Push(ctx, &bark.Options{Body: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	<-g.started
+
+	pushes := []struct {
+		body  string
+		level string
+	}{
+		{"low", "passive"},
+		{"normal", "active"},
+		{"critical", "critical"},
+		{"high", "timeSensitive"},
+	}
+	for _, p := range pushes {
+		if err := q.Push(ctx, &bark.Options{Body: p.body, Level: p.level}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.PushPriority(ctx, &bark.Options{Body: "promoted", Level: "passive"}, bark.PriorityCritical); err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Len(); n != 5 {
+		t.Errorf("Len() = %d, want 5", n)
+	}
+	close(g.release)
+	q.Close()
+
+	if got, want := g.got(), "first,critical,promoted,high,normal,low"; got != want {
+		t.Errorf("delivered %s, want %s", got, want)
+	}
+}
+
+func TestQueueTryPush(t *testing.T) {
+	g := newGate()
+	q := bark.NewQueue(g, bark.WithQueueWorkers(1), bark.WithQueueSize(1))
+	ctx := context.Background()
+	if err := q.TryPush(ctx, &bark.Options{Body: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	<-g.started
+	if err := q.TryPush(ctx, &bark.Options{Body: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryPush(ctx, &bark.Options{Body: "c"}); !errors.Is(err, bark.ErrQueueFull) {
+		t.Errorf("TryPush() on a full queue = %v, want ErrQueueFull", err)
+	}
+
+	// 队列已满时 Push 阻塞到 ctx 结束
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := q.Push(timeout, &bark.Options{Body: "d"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Push() on a full queue = %v, want DeadlineExceeded", err)
+	}
+
+	close(g.release)
+	q.Close()
+	if got := g.got(); got != "a,b" {
+		t.Errorf("delivered %s, want a,b", got)
+	}
+}
+
+func TestQueueClosed(t *testing.T) {
+	q := bark.NewQueue(bark.NopPusher{})
+	q.Close()
+	ctx := context.Background()
+	if err := q.Push(ctx, &bark.Options{}); !errors.Is(err, bark.ErrQueueClosed) {
+		t.Errorf("Push() = %v, want ErrQueueClosed", err)
+	}
+	if err := q.TryPush(ctx, &bark.Options{}); !errors.Is(err, bark.ErrQueueClosed) {
+		t.Errorf("TryPush() = %v, want ErrQueueClosed", err)
+	}
+	// 重复关闭不报错
+	if err := q.CloseContext(ctx); err != nil {
+		t.Errorf("second CloseContext() = %v", err)
+	}
+}
+
+func TestQueueErrorHandler(t *testing.T) {
+	failure := errors.New("down")
+	var (
+		mu     sync.Mutex
+		failed []string
+	)
+	q := bark.NewQueue(
+		bark.PusherFunc(func(ctx context.Context, o *bark.Options) error {
+			if o.Body == "bad" {
+				return failure
+			}
+			return nil
+		}),
+		bark.WithQueueErrorHandler(func(ctx context.Context, o *bark.Options, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, failure) {
+				failed = append(failed, o.Body)
+			}
+		}),
+	)
+	ctx := context.Background()
+	for _, body := range []string{"ok", "bad", "ok"} {
+		if err := q.Push(ctx, &bark.Options{Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Close()
+	if len(failed) != 1 || failed[0] != "bad" {
+		t.Errorf("error handler got %v, want [bad]", failed)
+	}
+}
+
+func TestQueueCloseContextDrops(t *testing.T) {
+	g := newGate()
+	q := bark.NewQueue(g, bark.WithQueueWorkers(1), bark.WithQueueErrorHandler(func(context.Context, *bark.Options, error) {}))
+	ctx := context.Background()
+	for _, body := range []string{"a", "b", "c"} {
+		if err := q.Push(ctx, &bark.Options{Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-g.started
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	var drainErr *bark.DrainError
+	err := q.CloseContext(timeout)
+	if !errors.As(err, &drainErr) || drainErr.Dropped != 3 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CloseContext() = %v, want a DrainError with 3 dropped", err)
+	}
+	if got := g.got(); got != "" {
+		t.Errorf("delivered %q after the drain timed out", got)
+	}
+}
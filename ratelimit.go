@@ -0,0 +1,62 @@
+package bark
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 令牌桶限流器, 用于防止错误循环等场景产生大量通知
+// 零值和 nil 不限流
+type RateLimiter struct {
+	burst  int
+	per    time.Duration
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// dropped 上次放行以来被限流的次数
+	dropped int
+	clk     Clock
+}
+
+// NewRateLimiter 创建限流器, 每个 per 周期最多放行 n 次, 允许最多 n 次突发
+func NewRateLimiter(n int, per time.Duration) *RateLimiter {
+	return &RateLimiter{burst: n, per: per, tokens: float64(n)}
+}
+
+// WithClock 设置限流器的时间来源, 默认 SystemClock, 返回 l 以便链式调用
+func (l *RateLimiter) WithClock(clk Clock) *RateLimiter {
+	l.clk = clk
+	return l
+}
+
+// Allow 判断本次是否放行
+func (l *RateLimiter) Allow() bool {
+	ok, _ := l.Take()
+	return ok
+}
+
+// Take 判断本次是否放行, 放行时同时返回上次放行以来被限流的次数
+func (l *RateLimiter) Take() (bool, int) {
+	if l == nil || l.burst <= 0 || l.per <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := clockOrSystem(l.clk).Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() / l.per.Seconds() * float64(l.burst)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		l.dropped++
+		return false, 0
+	}
+	l.tokens--
+	dropped := l.dropped
+	l.dropped = 0
+	return true, dropped
+}
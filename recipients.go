@@ -0,0 +1,162 @@
+package bark
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Recipients 批量导入的收件人, 字段与 WithAliases, WithGroups, Options.DeviceEnc 对应
+type Recipients struct {
+	// Aliases 别名 -> 设备 Key
+	Aliases map[string]string
+	// Groups 分组名 -> 成员别名, 按导入顺序
+	Groups map[string][]string
+	// DeviceEnc 设备 Key -> 该设备的加密参数, 只包含设置了加密的设备
+	DeviceEnc map[string]*EncOpt
+}
+
+// RecipientRecord JSON 格式中的一条收件人记录
+type RecipientRecord struct {
+	Name       string     `json:"name"`
+	Key        string     `json:"key"`
+	Groups     []string   `json:"groups,omitempty"`
+	Encryption *EncConfig `json:"encryption,omitempty"`
+}
+
+// LoadRecipients 从 CSV 或 JSON 导出中读取收件人, format 为 csv 或 json
+//
+// CSV 第一行为表头, 必须包含 name 和 key 列, 可选 groups (多个分组以 ; 分隔), enc_mode, enc_key, enc_iv 列:
+//
+//	name,key,groups,enc_mode,enc_key,enc_iv
+//	dad,DEVICE_KEY_OF_DAD,family;ops,GCM,16byteskey123456,12bytesnonce
+//	mum,DEVICE_KEY_OF_MUM,family,,,
+//
+// JSON 为 RecipientRecord 数组, encryption 与配置文件中的加密参数格式相同:
+//
+//	[{"name": "dad", "key": "DEVICE_KEY_OF_DAD", "groups": ["family"], "encryption": {"mode": "GCM", "key": "...", "iv": "..."}}]
+//
+// 设备 Key 按 ValidateDeviceKey 校验, 同一别名出现多次时返回错误
+func LoadRecipients(r io.Reader, format string) (*Recipients, error) {
+	var (
+		records []RecipientRecord
+		err     error
+	)
+	switch strings.ToLower(format) {
+	case "csv":
+		records, err = readRecipientsCSV(r)
+	case "json":
+		err = json.NewDecoder(r).Decode(&records)
+	default:
+		return nil, fmt.Errorf("unsupported recipients format: %q (supported: csv, json)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &Recipients{
+		Aliases:   make(map[string]string, len(records)),
+		Groups:    make(map[string][]string),
+		DeviceEnc: make(map[string]*EncOpt),
+	}
+	for i, rec := range records {
+		if err := rs.add(rec); err != nil {
+			return nil, fmt.Errorf("recipient %d: %w", i+1, err)
+		}
+	}
+	return rs, nil
+}
+
+func (rs *Recipients) add(rec RecipientRecord) error {
+	if rec.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := ValidateDeviceKey(rec.Key); err != nil {
+		return fmt.Errorf("%s: %w", rec.Name, err)
+	}
+	if _, ok := rs.Aliases[rec.Name]; ok {
+		return fmt.Errorf("duplicate name %q", rec.Name)
+	}
+	rs.Aliases[rec.Name] = rec.Key
+	for _, group := range rec.Groups {
+		if group != "" && !slices.Contains(rs.Groups[group], rec.Name) {
+			rs.Groups[group] = append(rs.Groups[group], rec.Name)
+		}
+	}
+	if rec.Encryption != nil {
+		enc := rec.Encryption.EncOpt()
+		if err := enc.validate(); err != nil {
+			return fmt.Errorf("%s: encryption: %w", rec.Name, err)
+		}
+		rs.DeviceEnc[rec.Key] = enc
+	}
+	return nil
+}
+
+// ClientOptions 返回设置地址簿和分组的客户端配置项, 可与其他配置项一起传给 New
+//
+//	client := bark.New(server, rs.ClientOptions()...)
+func (rs *Recipients) ClientOptions() []ClientOption {
+	return []ClientOption{WithAliases(rs.Aliases), WithGroups(rs.Groups)}
+}
+
+// readRecipientsCSV 按表头读取 CSV, 忽略 UTF-8 BOM (Excel 导出) 和 # 开头的注释行
+func readRecipientsCSV(r io.Reader) ([]RecipientRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "key"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %q", required)
+		}
+	}
+
+	var records []RecipientRecord
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		rec := RecipientRecord{Name: field("name"), Key: field("key")}
+		for _, group := range strings.Split(field("groups"), ";") {
+			if group = strings.TrimSpace(group); group != "" {
+				rec.Groups = append(rec.Groups, group)
+			}
+		}
+		if field("enc_key") != "" {
+			rec.Encryption = &EncConfig{Mode: EncMode(field("enc_mode")), Key: field("enc_key"), Iv: field("enc_iv")}
+		}
+		records = append(records, rec)
+	}
+}
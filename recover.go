@@ -0,0 +1,114 @@
+package bark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// DefaultMaxStackBytes 通知中堆栈的默认最大长度
+const DefaultMaxStackBytes = 2048
+
+// RecoverOptions RecoverMiddleware 配置
+type RecoverOptions struct {
+	// Options 推送的模板参数, 如设备 Key, 分组
+	Options *Options
+	// RePanic 为 true 时推送后重新 panic (交给外层的 recover 或 net/http 处理),
+	// 否则返回 500 Internal Server Error
+	RePanic bool
+	// MaxStackBytes 堆栈的最大长度, 默认 DefaultMaxStackBytes
+	MaxStackBytes int
+	// Limiter 推送限流, 为 nil 时不限流
+	Limiter *RateLimiter
+	// Timeout 推送超时, 默认 10 秒
+	Timeout time.Duration
+}
+
+// RecoverMiddleware 返回捕获 panic 并推送通知的 net/http 中间件
+// 通知包含请求方法, 路径和截断后的堆栈; http.ErrAbortHandler 不会推送
+func RecoverMiddleware(p Pusher, opts *RecoverOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &RecoverOptions{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				stack := debug.Stack()
+				if opts.Limiter.Allow() {
+					notifyPanic(r, p, opts, v, stack)
+				}
+				if opts.RePanic {
+					panic(v)
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func notifyPanic(r *http.Request, p Pusher, opts *RecoverOptions, v interface{}, stack []byte) {
+	o := &Options{}
+	if opts.Options != nil {
+		o = opts.Options.Clone()
+	}
+	if o.Title == "" {
+		o.Title = fmt.Sprintf("panic: %v", v)
+	} else {
+		o.Subtitle = fmt.Sprintf("panic: %v", v)
+	}
+	o.Body = fmt.Sprintf("%s %s\nremote: %s\n\n%s", r.Method, r.URL.RequestURI(), r.RemoteAddr, truncateStack(stack, opts.MaxStackBytes))
+	if o.Level == "" {
+		o.Level = "timeSensitive"
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), timeout)
+	defer cancel()
+	_ = p.Push(ctx, o)
+}
+
+// truncateStack 去掉 recover 和 runtime 的帧后截断堆栈, 保留开头 (panic 位置所在的帧)
+func truncateStack(stack []byte, max int) string {
+	if max <= 0 {
+		max = DefaultMaxStackBytes
+	}
+	// goroutine 1 [running]:\n ... panic({...})\n\t.../runtime/panic.go:NNN +0x..\n<panic 位置>
+	if i := bytes.Index(stack, []byte("\npanic(")); i >= 0 {
+		rest := stack[i+1:]
+		if j := nthIndex(rest, '\n', 2); j >= 0 {
+			header, _, _ := bytes.Cut(stack, []byte("\n"))
+			stack = append(append(header, '\n'), rest[j+1:]...)
+		}
+	}
+	if len(stack) <= max {
+		return string(stack)
+	}
+	return string(stack[:max]) + "\n..."
+}
+
+// nthIndex 返回 b 中第 n 个 c 的位置
+func nthIndex(b []byte, c byte, n int) int {
+	for i, x := range b {
+		if x == c {
+			n--
+			if n == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package bark
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// minRedactLen 替换错误信息中的敏感值时忽略更短的值, 避免误伤普通文本
+const minRedactLen = 8
+
+// Redact 脱敏设备 Key, 令牌等敏感值, 只保留首尾各 3 个字符, 不超过 8 个字符时完全隐藏
+//
+//	Redact("NFYHa6AeRUeMRTc2kXyhU4") == "NFY***hU4"
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= 8 {
+		return "***"
+	}
+	return s[:3] + "***" + s[len(s)-3:]
+}
+
+// Redacted 返回用于日志和调试输出的副本: 设备 Key 已脱敏, 不包含加密参数
+func (o *Options) Redacted() *Options {
+	r := o.Clone()
+	r.DeviceKey = Redact(r.DeviceKey)
+	for i, key := range r.DeviceKeys {
+		r.DeviceKeys[i] = Redact(key)
+	}
+	r.Enc = nil
+	r.DeviceEnc = nil
+	return r
+}
+
+// redactedOptions 没有 LogValue 和 String 方法的 Options, 避免递归
+type redactedOptions Options
+
+// LogValue 实现 slog.LogValuer, 使用 slog 记录推送参数时自动脱敏
+func (o *Options) LogValue() slog.Value {
+	if o == nil {
+		return slog.AnyValue(nil)
+	}
+	return slog.AnyValue((*redactedOptions)(o.Redacted()))
+}
+
+// String 返回脱敏后的 JSON, 用于 fmt 输出推送参数
+func (o *Options) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	data, err := json.Marshal((*redactedOptions)(o.Redacted()))
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// String 输出加密模式和密钥来源, 不包含密钥和口令
+func (e *EncOpt) String() string {
+	if e == nil {
+		return "<nil>"
+	}
+	source := "key"
+	switch {
+	case e.Encrypter != nil:
+		source = "encrypter"
+	case e.KeyProvider != nil:
+		source = "key_provider"
+	case e.Passphrase != "":
+		source = "passphrase"
+	case e.KeyFile != "":
+		source = "key_file:" + e.KeyFile
+	}
+	return "EncOpt{mode: " + string(e.Mode) + ", source: " + source + "}"
+}
+
+// LogValue 实现 slog.LogValuer, 不输出密钥和口令
+func (e *EncOpt) LogValue() slog.Value {
+	return slog.StringValue(e.String())
+}
+
+// secrets 返回推送中需要在错误信息里隐藏的值: 设备 Key 以及密钥和口令
+func (o *Options) secrets() []string {
+	out := append([]string{o.DeviceKey}, o.DeviceKeys...)
+	encs := []*EncOpt{o.Enc}
+	for key, enc := range o.DeviceEnc {
+		out = append(out, key)
+		encs = append(encs, enc)
+	}
+	for _, e := range encs {
+		if e != nil {
+			out = append(out, e.Key, e.KeyHex, e.KeyBase64, e.Passphrase)
+		}
+	}
+	return out
+}
+
+// redactError 隐藏错误信息中推送 o 的敏感值, 保留原错误链供 errors.Is 和 errors.As 使用
+func redactError(err error, o *Options) error {
+	msg := err.Error()
+	redacted := redactSecrets(msg, o.secrets())
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{err: err, msg: redacted}
+}
+
+// redactSecrets 将 s 中不短于 minRedactLen 的敏感值替换为 Redact 的结果
+func redactSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) >= minRedactLen {
+			s = strings.ReplaceAll(s, secret, Redact(secret))
+		}
+	}
+	return s
+}
+
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,49 @@
+package bark_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/barktest"
+)
+
+func TestResponseErrorRedacted(t *testing.T) {
+	srv := barktest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	const key = "NFYHa6AeRUeMRTc2kXyhU4"
+	srv.FailWith(http.StatusBadRequest, "failed to get device token: "+key)
+	err := client.Push(context.Background(), &bark.Options{DeviceKey: key, Body: "hi"})
+
+	var respErr *bark.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("Push() = %v, want a ResponseError", err)
+	}
+	if !errors.Is(err, bark.ErrDeviceKeyNotFound) {
+		t.Errorf("redaction should keep ErrDeviceKeyNotFound matching: %v", err)
+	}
+	for name, s := range map[string]string{
+		"Error":   err.Error(),
+		"Message": respErr.Message,
+		"Body":    string(respErr.Body),
+	} {
+		if strings.Contains(s, key) {
+			t.Errorf("%s contains the device key: %s", name, s)
+		}
+		if !strings.Contains(s, bark.Redact(key)) {
+			t.Errorf("%s should contain the redacted key: %s", name, s)
+		}
+	}
+	for k, values := range respErr.Header {
+		for _, v := range values {
+			if strings.Contains(v, key) {
+				t.Errorf("header %s contains the device key", k)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package bark
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDedupWindow 相同错误默认的去重时间窗口
+const DefaultDedupWindow = 10 * time.Minute
+
+// Reporter 错误上报器, 推送错误及其堆栈, goroutine, 主机信息 (类似 Sentry)
+//
+// 相同的错误 (错误文本和发生位置相同) 在 DedupWindow 内只推送一次; 设置 StateFile 后去重状态
+// 持久化到文件, 进程反复崩溃重启时也不会重复推送
+type Reporter struct {
+	// Pusher 推送目标
+	Pusher Pusher
+	// Defaults 推送的模板参数, 如设备 Key, 分组
+	Defaults *Options
+	// SampleRate 上报的采样率 (0, 1], 为 0 时视为 1
+	SampleRate float64
+	// DedupWindow 去重时间窗口, 默认 DefaultDedupWindow, 小于 0 时不去重
+	DedupWindow time.Duration
+	// StateFile 可选, 去重状态文件
+	StateFile string
+	// MaxStackBytes 堆栈的最大长度, 默认 DefaultMaxStackBytes
+	MaxStackBytes int
+	// Timeout RecoverAndNotify 推送的超时时间, 默认 10 秒
+	Timeout time.Duration
+	// Clock 去重使用的时间来源, 默认 SystemClock
+	Clock Clock
+
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	loaded bool
+}
+
+// NewReporter 创建 Reporter
+func NewReporter(p Pusher, defaults *Options) *Reporter {
+	return &Reporter{Pusher: p, Defaults: defaults}
+}
+
+var defaultReporter atomic.Pointer[Reporter]
+
+// SetReporter 设置全局 Notify 和 RecoverAndNotify 使用的 Reporter
+func SetReporter(r *Reporter) {
+	defaultReporter.Store(r)
+}
+
+// Notify 通过全局 Reporter 上报错误, 未调用 SetReporter 时不做任何事
+// tags 为附加的标签, 如 "job=sync", "tenant=acme"
+func Notify(ctx context.Context, err error, tags ...string) error {
+	r := defaultReporter.Load()
+	if r == nil || err == nil {
+		return nil
+	}
+	return r.notify(ctx, err, tags, 3)
+}
+
+// RecoverAndNotify 捕获 panic, 通过全局 Reporter 上报后重新 panic, 需直接 defer 调用:
+//
+//	defer bark.RecoverAndNotify()
+func RecoverAndNotify() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if r := defaultReporter.Load(); r != nil {
+		r.reportPanic(v)
+	}
+	panic(v)
+}
+
+// Notify 上报错误
+func (r *Reporter) Notify(ctx context.Context, err error, tags ...string) error {
+	if err == nil {
+		return nil
+	}
+	return r.notify(ctx, err, tags, 3)
+}
+
+// Recover 捕获 panic, 上报后重新 panic, 需直接 defer 调用: defer r.Recover()
+func (r *Reporter) Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	r.reportPanic(v)
+	panic(v)
+}
+
+func (r *Reporter) reportPanic(v interface{}) {
+	err, ok := v.(error)
+	if !ok {
+		err = fmt.Errorf("%v", v)
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_ = r.notify(ctx, fmt.Errorf("panic: %w", err), nil, 4)
+}
+
+// notify skip 为调用者到 notify 之间的栈帧数, 用于确定错误发生的位置
+func (r *Reporter) notify(ctx context.Context, err error, tags []string, skip int) error {
+	if r.SampleRate > 0 && r.SampleRate < 1 && rand.Float64() >= r.SampleRate {
+		return nil
+	}
+
+	location := callerLocation(skip + 1)
+	if !r.firstSeen(err.Error() + "\x00" + location) {
+		return nil
+	}
+
+	buf := make([]byte, 64<<10)
+	stack := trimOwnFrames(buf[:runtime.Stack(buf, false)])
+	goroutine, _, _ := bytes.Cut(stack, []byte("\n"))
+
+	o := &Options{}
+	if r.Defaults != nil {
+		o = r.Defaults.Clone()
+	}
+	host, _ := os.Hostname()
+	if o.Title == "" {
+		o.Title = err.Error()
+	} else {
+		o.Subtitle = err.Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "host: %s (pid %d)\n", host, os.Getpid())
+	fmt.Fprintf(&b, "%s\n", strings.TrimSuffix(string(goroutine), ":"))
+	if location != "" {
+		fmt.Fprintf(&b, "at: %s\n", location)
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(&b, "tags: %s\n", strings.Join(tags, ", "))
+	}
+	b.WriteString("\n")
+	b.WriteString(truncateStack(stack, r.MaxStackBytes))
+	o.Body = b.String()
+	if o.Level == "" {
+		o.Level = "timeSensitive"
+	}
+	return r.Pusher.Push(ctx, o)
+}
+
+// trimOwnFrames 去掉堆栈开头属于本包的帧
+func trimOwnFrames(stack []byte) []byte {
+	header, rest, ok := bytes.Cut(stack, []byte("\n"))
+	if !ok {
+		return stack
+	}
+	for bytes.HasPrefix(rest, []byte("github.com/gaoyaxuan/go-bark.")) {
+		// 每帧两行: 函数名和文件位置
+		if i := nthIndex(rest, '\n', 2); i >= 0 {
+			rest = rest[i+1:]
+		} else {
+			break
+		}
+	}
+	return append(append(header, '\n'), rest...)
+}
+
+// callerLocation 返回调用位置, 跳过 runtime 的帧 (panic 时为 runtime.gopanic 等)
+func callerLocation(skip int) string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// firstSeen 判断错误在去重窗口内是否首次出现, 并记录本次时间
+func (r *Reporter) firstSeen(key string) bool {
+	window := r.DedupWindow
+	if window == 0 {
+		window = DefaultDedupWindow
+	}
+	if window < 0 {
+		return true
+	}
+	sum := sha256.Sum256([]byte(key))
+	fp := hex.EncodeToString(sum[:8])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		r.loaded = true
+		r.seen = make(map[string]time.Time)
+		if r.StateFile != "" {
+			if data, err := os.ReadFile(r.StateFile); err == nil {
+				_ = json.Unmarshal(data, &r.seen)
+			}
+		}
+	}
+
+	now := clockOrSystem(r.Clock).Now()
+	for k, t := range r.seen {
+		if now.Sub(t) >= window {
+			delete(r.seen, k)
+		}
+	}
+	if _, ok := r.seen[fp]; ok {
+		return false
+	}
+	r.seen[fp] = now
+	if r.StateFile != "" {
+		if data, err := json.Marshal(r.seen); err == nil {
+			_ = os.WriteFile(r.StateFile, data, 0o600)
+		}
+	}
+	return true
+}
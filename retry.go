@@ -0,0 +1,145 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryBackoff 第一次重试前的默认等待时间, 之后每次加倍
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// maxRetryDelay 单次重试等待时间的上限, 包括服务器 Retry-After 指定的时间
+	maxRetryDelay = 30 * time.Second
+)
+
+// RetryDecision 重试分类的结果
+type RetryDecision int
+
+const (
+	// RetryDefault 交给 DefaultRetryClassifier 判断
+	RetryDefault RetryDecision = iota
+	// RetryYes 重试
+	RetryYes
+	// RetryNo 不重试, 立即返回错误
+	RetryNo
+)
+
+// RetryClassifier 判断一次失败的请求是否重试
+// resp 为服务器的响应 (响应体仍可读取), 请求未完成或使用 Backend 时为 nil; err 为请求错误或 *ResponseError
+type RetryClassifier func(resp *http.Response, err error) RetryDecision
+
+// retryPolicy 客户端的重试设置, 零值不重试
+type retryPolicy struct {
+	retries    int
+	backoff    time.Duration
+	classifier RetryClassifier
+}
+
+// WithRetry 请求失败时最多重试 retries 次, 第 n 次重试前等待 backoff * 2^(n-1) (最长 30 秒),
+// 服务器返回 Retry-After 时以其为准. backoff 为 0 时使用 DefaultRetryBackoff; 默认不重试
+// 重试的等待受 ctx 控制, 加密推送每次重试发送相同的密文
+func WithRetry(retries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retry.retries = retries
+		c.retry.backoff = backoff
+	}
+}
+
+// WithRetryClassifier 自定义哪些失败需要重试, 返回 RetryDefault 时仍按 DefaultRetryClassifier 判断
+// 如某个反向代理在服务重启时返回 400:
+//
+//	bark.WithRetryClassifier(func(resp *http.Response, err error) bark.RetryDecision {
+//		if resp != nil && resp.StatusCode == 400 && resp.Header.Get("Server") == "buggy-proxy" {
+//			return bark.RetryYes
+//		}
+//		return bark.RetryDefault
+//	})
+func WithRetryClassifier(fn RetryClassifier) ClientOption {
+	return func(c *Client) {
+		c.retry.classifier = fn
+	}
+}
+
+// DefaultRetryClassifier 默认的重试规则: 网络错误, 429 和 5xx (501 除外) 重试; ctx 取消或超时, 其他状态码不重试
+func DefaultRetryClassifier(resp *http.Response, err error) RetryDecision {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return RetryNo
+	}
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	} else {
+		var respErr *ResponseError
+		if errors.As(err, &respErr) {
+			status = respErr.StatusCode
+		}
+	}
+	if status == 0 {
+		var netErr net.Error
+		if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return RetryYes
+		}
+		return RetryNo
+	}
+	if status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented) {
+		return RetryYes
+	}
+	return RetryNo
+}
+
+func (p *retryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	decision := RetryDefault
+	if p.classifier != nil {
+		decision = p.classifier(resp, err)
+	}
+	if decision == RetryDefault {
+		decision = DefaultRetryClassifier(resp, err)
+	}
+	return decision == RetryYes
+}
+
+// delay 第 n 次重试前的等待时间
+func (p *retryPolicy) delay(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			if d := time.Duration(secs) * time.Second; d < maxRetryDelay {
+				return d
+			}
+			return maxRetryDelay
+		}
+	}
+	d := p.backoff
+	if d <= 0 {
+		d = DefaultRetryBackoff
+	}
+	for i := 1; i < n && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
+
+// withRetry 执行 attempt, 失败时按重试设置重试, 返回最后一次的结果; 日志中的错误按 o 隐藏敏感值
+func (c *Client) withRetry(ctx context.Context, o *Options, attempt func() (*http.Response, error)) (*http.Response, error) {
+	for n := 1; ; n++ {
+		resp, err := attempt()
+		if err == nil || n > c.retry.retries || !c.retry.shouldRetry(resp, err) {
+			return resp, err
+		}
+		timer := c.clock().NewTimer(c.retry.delay(n, resp))
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+		c.log().Debug("bark: retrying push", "attempt", n+1, "err", redactError(err, o))
+	}
+}
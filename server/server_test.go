@@ -0,0 +1,417 @@
+package server_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gaoyaxuan/go-bark"
+	"github.com/gaoyaxuan/go-bark/apns"
+	"github.com/gaoyaxuan/go-bark/server"
+)
+
+// fakeSender 记录发送的通知, err 不为 nil 时返回该错误
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []*apns.Notification
+	err  error
+}
+
+func (f *fakeSender) Send(_ context.Context, n *apns.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, n)
+	return nil
+}
+
+// payloads 按发送顺序返回解析后的 payload
+func (f *fakeSender) payloads(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]map[string]interface{}, 0, len(f.sent))
+	for _, n := range f.sent {
+		var p map[string]interface{}
+		if err := json.Unmarshal(n.Payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func alert(p map[string]interface{}) map[string]interface{} {
+	aps, _ := p["aps"].(map[string]interface{})
+	a, _ := aps["alert"].(map[string]interface{})
+	return a
+}
+
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func newServer(t *testing.T) (*server.Server, *fakeSender) {
+	t.Helper()
+	sender := &fakeSender{}
+	s := server.New(server.NewMemoryStore(), sender)
+	for key, token := range map[string]string{"alice": "token-a", "bob": "token-b"} {
+		if _, err := s.Register(key, token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, sender
+}
+
+func do(t *testing.T, h http.Handler, req *http.Request) response {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var res response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body, err)
+	}
+	if res.Code != w.Code {
+		t.Errorf("body code %d does not match status %d", res.Code, w.Code)
+	}
+	return res
+}
+
+func postJSON(path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestPush(t *testing.T) {
+	gz := &bytes.Buffer{}
+	zw := gzip.NewWriter(gz)
+	_, _ = zw.Write([]byte(`{"device_key":"alice","title":"T","body":"B"}`))
+	_ = zw.Close()
+	gzReq := httptest.NewRequest(http.MethodPost, "/push", gz)
+	gzReq.Header.Set("Content-Encoding", "gzip")
+
+	formReq := httptest.NewRequest(http.MethodPost, "/push", strings.NewReader(url.Values{"device_key": {"alice"}, "title": {"T"}, "body": {"B"}}.Encode()))
+	formReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"json", postJSON("/push", `{"device_key":"alice","title":"T","body":"B"}`)},
+		{"form", formReq},
+		{"gzip", gzReq},
+		{"query", httptest.NewRequest(http.MethodGet, "/push?device_key=alice&title=T&body=B", nil)},
+		{"path", httptest.NewRequest(http.MethodGet, "/alice/T/B", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sender := newServer(t)
+			if res := do(t, s, tt.req); res.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", res.Code, res.Message)
+			}
+			if len(sender.sent) != 1 || sender.sent[0].DeviceToken != "token-a" {
+				t.Fatalf("sent %+v", sender.sent)
+			}
+			a := alert(sender.payloads(t)[0])
+			if a["title"] != "T" || a["body"] != "B" {
+				t.Errorf("alert = %v", a)
+			}
+		})
+	}
+}
+
+func TestPushPathSegments(t *testing.T) {
+	tests := []struct {
+		path                  string
+		title, subtitle, body string
+	}{
+		{"/alice", "", "", ""},
+		{"/alice/hello", "", "", "hello"},
+		{"/alice/title/hello", "title", "", "hello"},
+		{"/alice/title/sub/hello", "title", "sub", "hello"},
+		{"/alice/a%2Fb/c%20d", "a/b", "", "c d"},
+		{"/alice/%E4%BD%A0%E5%A5%BD?title=ignored", "ignored", "", "你好"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			s, sender := newServer(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			res := do(t, s, req)
+			if tt.body == "" && tt.title == "" {
+				if res.Code != http.StatusBadRequest {
+					t.Fatalf("empty push: status %d", res.Code)
+				}
+				return
+			}
+			if res.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", res.Code, res.Message)
+			}
+			a := alert(sender.payloads(t)[0])
+			if a["title"] != nilIfEmpty(tt.title) || a["subtitle"] != nilIfEmpty(tt.subtitle) || a["body"] != tt.body {
+				t.Errorf("alert = %v", a)
+			}
+		})
+	}
+}
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func TestPushErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"no device key", postJSON("/push", `{"body":"hi"}`), http.StatusBadRequest},
+		{"unregistered", postJSON("/push", `{"device_key":"carol","body":"hi"}`), http.StatusBadRequest},
+		{"no content", postJSON("/push", `{"device_key":"alice"}`), http.StatusBadRequest},
+		{"invalid json", postJSON("/push", `{"device_key":`), http.StatusBadRequest},
+		{"invalid integer", postJSON("/push", `{"device_key":"alice","body":"hi","badge":"many"}`), http.StatusBadRequest},
+		{"unsupported encoding", func() *http.Request {
+			r := postJSON("/push", `{}`)
+			r.Header.Set("Content-Encoding", "br")
+			return r
+		}(), http.StatusBadRequest},
+		{"too many segments", httptest.NewRequest(http.MethodGet, "/alice/a/b/c/d", nil), http.StatusNotFound},
+		{"root", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sender := newServer(t)
+			if res := do(t, s, tt.req); res.Code != tt.code {
+				t.Errorf("status %d (%s), want %d", res.Code, res.Message, tt.code)
+			}
+			if len(sender.sent) != 0 {
+				t.Errorf("sent %d notifications", len(sender.sent))
+			}
+		})
+	}
+}
+
+func TestPushTooManyKeys(t *testing.T) {
+	s, _ := newServer(t)
+	s.MaxDeviceKeys = 1
+	if res := do(t, s, postJSON("/push", `{"device_keys":["alice","bob"],"body":"hi"}`)); res.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", res.Code)
+	}
+}
+
+func TestPushBatch(t *testing.T) {
+	s, sender := newServer(t)
+	res := do(t, s, postJSON("/push", `{"device_keys":["alice","carol","bob","alice"],"body":"hi"}`))
+	if res.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Code, res.Message)
+	}
+	var results []struct {
+		Code      int    `json:"code"`
+		DeviceKey string `json:"device_key"`
+	}
+	if err := json.Unmarshal(res.Data, &results); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"alice": 200, "carol": 400, "bob": 200}
+	if len(results) != len(want) {
+		t.Fatalf("results %+v", results)
+	}
+	for _, r := range results {
+		if want[r.DeviceKey] != r.Code {
+			t.Errorf("%s: code %d, want %d", r.DeviceKey, r.Code, want[r.DeviceKey])
+		}
+	}
+	if len(sender.sent) != 2 {
+		t.Errorf("sent %d notifications, want 2", len(sender.sent))
+	}
+}
+
+func TestPushForwardsCustomFields(t *testing.T) {
+	s, sender := newServer(t)
+	res := do(t, s, postJSON("/push", `{"device_key":"alice","body":"hi","sound":"bell","x_custom":"42","ciphertext":"abc"}`))
+	if res.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Code, res.Message)
+	}
+	p := sender.payloads(t)[0]
+	if p["x_custom"] != "42" || p["ciphertext"] != "abc" {
+		t.Errorf("custom fields not forwarded: %v", p)
+	}
+	if aps := p["aps"].(map[string]interface{}); aps["sound"] != "bell.caf" {
+		t.Errorf("sound = %v", aps["sound"])
+	}
+}
+
+func TestPushSendError(t *testing.T) {
+	s, sender := newServer(t)
+	sender.err = &apns.Error{StatusCode: http.StatusGone, Reason: "Unregistered"}
+	if res := do(t, s, postJSON("/push", `{"device_key":"alice","body":"hi"}`)); res.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", res.Code)
+	}
+}
+
+func TestPushDecrypt(t *testing.T) {
+	enc := &bark.EncOpt{Mode: bark.EncModeGCM, Key: "0123456789abcdef", Iv: "0123456789ab"}
+	ciphertext, err := (&bark.Options{Title: "secret title", Body: "secret body", Enc: enc}).Ciphertext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]string{"device_key": "alice", "ciphertext": ciphertext})
+
+	t.Run("decrypts", func(t *testing.T) {
+		s, sender := newServer(t)
+		s.Decrypt = map[string]*bark.EncOpt{"": enc}
+		if res := do(t, s, postJSON("/push", string(body))); res.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Code, res.Message)
+		}
+		p := sender.payloads(t)[0]
+		if a := alert(p); a["title"] != "secret title" || a["body"] != "secret body" {
+			t.Errorf("alert = %v", a)
+		}
+		if _, ok := p["ciphertext"]; ok {
+			t.Error("ciphertext should not be forwarded after decryption")
+		}
+	})
+	t.Run("per device key", func(t *testing.T) {
+		s, sender := newServer(t)
+		s.Decrypt = map[string]*bark.EncOpt{"bob": enc}
+		if res := do(t, s, postJSON("/push", string(body))); res.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", res.Code, res.Message)
+		}
+		if p := sender.payloads(t)[0]; p["ciphertext"] != ciphertext {
+			t.Error("device without decryption settings should receive the ciphertext")
+		}
+	})
+	t.Run("wrong key", func(t *testing.T) {
+		s, sender := newServer(t)
+		s.Decrypt = map[string]*bark.EncOpt{"": {Mode: bark.EncModeGCM, Key: "fedcba9876543210", Iv: "0123456789ab"}}
+		if res := do(t, s, postJSON("/push", string(body))); res.Code != http.StatusBadRequest {
+			t.Errorf("status %d, want 400", res.Code)
+		}
+		if len(sender.sent) != 0 {
+			t.Error("undecryptable push should not be sent")
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	s, _ := newServer(t)
+
+	res := do(t, s, httptest.NewRequest(http.MethodGet, "/register?devicetoken=token-c", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", res.Code, res.Message)
+	}
+	var data map[string]string
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data["key"]) != 22 || data["device_key"] != data["key"] || data["device_token"] != "token-c" {
+		t.Fatalf("data = %v", data)
+	}
+	if res := do(t, s, httptest.NewRequest(http.MethodGet, "/register/"+data["key"], nil)); res.Code != http.StatusOK {
+		t.Errorf("registered key check: status %d", res.Code)
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"custom key", postJSON("/register", `{"device_token":"token-d","key":"dave"}`), http.StatusOK},
+		{"invalid key", postJSON("/register", `{"device_token":"token-e","key":"../eve"}`), http.StatusBadRequest},
+		{"no token", httptest.NewRequest(http.MethodGet, "/register?key=frank", nil), http.StatusBadRequest},
+		{"unknown check", httptest.NewRequest(http.MethodGet, "/register/carol", nil), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := do(t, s, tt.req); res.Code != tt.code {
+				t.Errorf("status %d (%s), want %d", res.Code, res.Message, tt.code)
+			}
+		})
+	}
+	if token, err := s.Store.DeviceToken("dave"); err != nil || token != "token-d" {
+		t.Errorf("custom key not saved: %q, %v", token, err)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	s, sender := newServer(t)
+	s.Username, s.Password = "user", "pass"
+
+	tests := []struct {
+		name       string
+		path       string
+		user, pass string
+		code       int
+	}{
+		{"ping is public", "/ping", "", "", http.StatusOK},
+		{"missing credentials", "/alice/hi", "", "", http.StatusUnauthorized},
+		{"wrong password", "/alice/hi", "user", "wrong", http.StatusUnauthorized},
+		{"valid", "/alice/hi", "user", "pass", http.StatusOK},
+		{"register requires auth", "/register?devicetoken=t", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d", w.Code, tt.code)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d notifications, want 1", len(sender.sent))
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("healthz = %d %q", w.Code, w.Body)
+	}
+}
+
+func TestServerPusher(t *testing.T) {
+	s, sender := newServer(t)
+	ctx := context.Background()
+	if err := s.Push(ctx, &bark.Options{DeviceKeys: []string{"alice", "bob"}, Body: "direct"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(sender.sent))
+	}
+
+	invalid := []*bark.Options{
+		{Body: "no key"},
+		{DeviceKey: "alice"},
+		(&bark.Options{Body: "alias"}).To("family"),
+	}
+	for _, o := range invalid {
+		if err := s.Push(ctx, o); !errors.Is(err, bark.ErrInvalidOptions) {
+			t.Errorf("Push(%+v) = %v, want ErrInvalidOptions", o, err)
+		}
+	}
+	if err := s.Push(ctx, &bark.Options{DeviceKey: "carol", Body: "hi"}); !errors.Is(err, bark.ErrDeviceKeyNotFound) {
+		t.Errorf("unregistered key: %v", err)
+	}
+}
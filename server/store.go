@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gaoyaxuan/go-bark"
+)
+
+// ErrDeviceNotFound 设备 Key 未注册, errors.Is 判断时也与 bark.ErrDeviceKeyNotFound 匹配
+var ErrDeviceNotFound error = deviceNotFoundError{}
+
+type deviceNotFoundError struct{}
+
+func (deviceNotFoundError) Error() string { return "device not registered" }
+
+func (deviceNotFoundError) Is(target error) bool { return target == bark.ErrDeviceKeyNotFound }
+
+// Store 设备 Key 到 APNs 设备令牌的存储, 实现需可并发使用
+type Store interface {
+	// DeviceToken 返回设备 Key 对应的设备令牌, 未注册时返回 ErrDeviceNotFound
+	DeviceToken(key string) (string, error)
+	// SaveDeviceToken 保存设备 Key 和设备令牌
+	SaveDeviceToken(key, token string) error
+	// DeleteDevice 删除设备 Key, 不存在时不返回错误
+	DeleteDevice(key string) error
+}
+
+// MemoryStore 内存中的存储, 进程退出后丢失
+type MemoryStore struct {
+	mu      sync.RWMutex
+	devices map[string]string
+}
+
+// NewMemoryStore 创建 MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{devices: make(map[string]string)}
+}
+
+func (s *MemoryStore) DeviceToken(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	token, ok := s.devices[key]
+	if !ok {
+		return "", ErrDeviceNotFound
+	}
+	return token, nil
+}
+
+func (s *MemoryStore) SaveDeviceToken(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices[key] = token
+	return nil
+}
+
+func (s *MemoryStore) DeleteDevice(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, key)
+	return nil
+}
+
+// FileStore 以 JSON 文件持久化的存储, 每次修改后原子地重写整个文件, 适合设备数量不多的自建服务
+type FileStore struct {
+	path string
+	mem  *MemoryStore
+	mu   sync.Mutex
+}
+
+// NewFileStore 打开 (不存在时创建) JSON 文件存储
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, mem: NewMemoryStore()}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.mem.devices); err != nil {
+			return nil, fmt.Errorf("parse device store %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *FileStore) DeviceToken(key string) (string, error) {
+	return s.mem.DeviceToken(key)
+}
+
+func (s *FileStore) SaveDeviceToken(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, err := s.mem.DeviceToken(key); err == nil && old == token {
+		return nil
+	}
+	_ = s.mem.SaveDeviceToken(key, token)
+	return s.flush()
+}
+
+func (s *FileStore) DeleteDevice(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.DeviceToken(key); err != nil {
+		return nil
+	}
+	_ = s.mem.DeleteDevice(key)
+	return s.flush()
+}
+
+// flush 写入临时文件后重命名, 避免写入中断导致文件损坏
+func (s *FileStore) flush() error {
+	s.mem.mu.RLock()
+	data, err := json.MarshalIndent(s.mem.devices, "", "  ")
+	s.mem.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+)
@@ -0,0 +1,146 @@
+package bark
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity 通用的日志严重级别, 用于将各类日志库和系统的级别统一映射为 Bark 参数
+type Severity int
+
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+var severityNames = [...]string{"debug", "info", "warn", "error", "fatal"}
+
+func (s Severity) String() string {
+	if s >= SeverityDebug && s <= SeverityFatal {
+		return severityNames[s]
+	}
+	return "severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText 实现 encoding.TextMarshaler, 使 SeverityMap 在配置文件中以级别名作为键
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < SeverityDebug || s > SeverityFatal {
+		return nil, fmt.Errorf("invalid severity: %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler, 接受 ParseSeverity 支持的所有写法
+func (s *Severity) UnmarshalText(text []byte) error {
+	v, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// ParseSeverity 解析级别名 (不区分大小写) 或 syslog 数字级别 (0-7)
+//
+// trace, debug 为 debug; info, notice, informational 为 info; warn, warning 为 warn;
+// error, err 为 error; fatal, panic, dpanic, crit, critical, alert, emerg, emergency 为 fatal
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 7 {
+			return 0, fmt.Errorf("syslog severity out of range: %d", n)
+		}
+		return SyslogSeverity(n), nil
+	}
+	switch s {
+	case "trace", "debug":
+		return SeverityDebug, nil
+	case "info", "informational", "notice":
+		return SeverityInfo, nil
+	case "warn", "warning":
+		return SeverityWarn, nil
+	case "error", "err":
+		return SeverityError, nil
+	case "fatal", "panic", "dpanic", "crit", "critical", "alert", "emerg", "emergency":
+		return SeverityFatal, nil
+	}
+	return 0, fmt.Errorf("unknown severity: %s", s)
+}
+
+// SyslogSeverity 将 syslog 数字级别 (0 emerg - 7 debug) 转换为 Severity
+func SyslogSeverity(n int) Severity {
+	switch {
+	case n <= 2:
+		return SeverityFatal
+	case n == 3:
+		return SeverityError
+	case n == 4:
+		return SeverityWarn
+	case n <= 6:
+		return SeverityInfo
+	default:
+		return SeverityDebug
+	}
+}
+
+// SeverityStyle 某一严重级别使用的 Bark 参数, 空字段表示不设置
+type SeverityStyle struct {
+	Level  string `json:"level,omitempty"`
+	Sound  string `json:"sound,omitempty"`
+	Volume *int   `json:"volume,omitempty"`
+	Call   string `json:"call,omitempty"`
+}
+
+// SeverityMap 严重级别到 Bark 参数的映射, 配置文件中以级别名为键:
+//
+//	severities:
+//	  error: {level: timeSensitive}
+//	  fatal: {level: critical, sound: alarm, volume: 8}
+type SeverityMap map[Severity]SeverityStyle
+
+// DefaultSeverityMap 默认的严重级别映射
+var DefaultSeverityMap = SeverityMap{
+	SeverityDebug: {Level: "passive"},
+	SeverityInfo:  {Level: "active"},
+	SeverityWarn:  {Level: "active"},
+	SeverityError: {Level: "timeSensitive"},
+	SeverityFatal: {Level: "critical", Sound: "alarm"},
+}
+
+// Style 返回级别 s 的参数; s 未配置时使用低于 s 的最近一个已配置级别
+func (m SeverityMap) Style(s Severity) (SeverityStyle, bool) {
+	if s > SeverityFatal {
+		s = SeverityFatal
+	}
+	for ; s >= SeverityDebug; s-- {
+		if style, ok := m[s]; ok {
+			return style, true
+		}
+	}
+	return SeverityStyle{}, false
+}
+
+// Apply 将级别 s 对应的参数写入 o 中尚未设置的字段; 返回 o 以便链式调用
+func (m SeverityMap) Apply(o *Options, s Severity) *Options {
+	style, ok := m.Style(s)
+	if !ok {
+		return o
+	}
+	if o.Level == "" {
+		o.Level = style.Level
+	}
+	if o.Sound == "" {
+		o.Sound = style.Sound
+	}
+	if o.Volume == nil {
+		o.Volume = clonePtr(style.Volume)
+	}
+	if o.Call == "" {
+		o.Call = style.Call
+	}
+	return o
+}
@@ -0,0 +1,192 @@
+package bark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrEmptyPayload webhook 消息中没有可推送的文本
+var ErrEmptyPayload = errors.New("bark: payload has no text")
+
+// FromSlackPayload 将 Slack incoming webhook 的 JSON 消息 (text, blocks, attachments) 转换为推送参数,
+// 转换规则见 SlackPayload.Apply; 消息中没有文本时返回 ErrEmptyPayload
+func FromSlackPayload(b []byte) (*Options, error) {
+	var p SlackPayload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("invalid slack payload: %w", err)
+	}
+	o := &Options{}
+	p.Apply(o)
+	if o.Title == "" && o.Body == "" {
+		return nil, ErrEmptyPayload
+	}
+	return o, nil
+}
+
+// SlackPayload Slack incoming webhook 请求体
+type SlackPayload struct {
+	Text        string            `json:"text"`
+	Blocks      []SlackBlock      `json:"blocks"`
+	Attachments []SlackAttachment `json:"attachments"`
+	Channel     string            `json:"channel"`
+	Username    string            `json:"username"`
+	IconURL     string            `json:"icon_url"`
+}
+
+// SlackBlock Block Kit 块, 只解析包含文本的 header, section, context 块
+type SlackBlock struct {
+	Type      string      `json:"type"`
+	Text      *SlackText  `json:"text"`
+	Fields    []SlackText `json:"fields"`
+	Elements  []SlackText `json:"elements"`
+	Accessory *struct {
+		URL string `json:"url"`
+	} `json:"accessory"`
+}
+
+// SlackText Block Kit 文本对象
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackAttachment 旧版消息附件
+type SlackAttachment struct {
+	Fallback  string       `json:"fallback"`
+	Pretext   string       `json:"pretext"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link"`
+	Text      string       `json:"text"`
+	Fields    []SlackField `json:"fields"`
+	Footer    string       `json:"footer"`
+}
+
+// SlackField 附件字段
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Apply 将 Slack 消息写入推送参数
+//
+// header 块或第一个附件标题作为标题, 其余文本合并为正文, 第一个链接作为 url,
+// channel 作为分组, icon_url 作为图标
+func (p *SlackPayload) Apply(o *Options) {
+	var title string
+	var lines []string
+	var link string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		if link == "" {
+			link = slackFirstLink(s)
+		}
+		lines = append(lines, FormatSlack(s))
+	}
+
+	add(p.Text)
+	for _, b := range p.Blocks {
+		if b.Type == "header" && b.Text != nil && title == "" {
+			title = FormatSlack(b.Text.Text)
+			continue
+		}
+		if b.Text != nil {
+			add(b.Text.Text)
+		}
+		for _, f := range b.Fields {
+			add(f.Text)
+		}
+		for _, e := range b.Elements {
+			add(e.Text)
+		}
+		if b.Accessory != nil && link == "" {
+			link = b.Accessory.URL
+		}
+	}
+	for _, a := range p.Attachments {
+		add(a.Pretext)
+		if a.Title != "" {
+			if title == "" {
+				title = FormatSlack(a.Title)
+			} else {
+				add(a.Title)
+			}
+		}
+		if a.TitleLink != "" && link == "" {
+			link = a.TitleLink
+		}
+		if a.Text != "" {
+			add(a.Text)
+		} else if len(a.Fields) == 0 {
+			add(a.Fallback)
+		}
+		for _, f := range a.Fields {
+			add(fmt.Sprintf("%s: %s", f.Title, f.Value))
+		}
+		add(a.Footer)
+	}
+
+	if title != "" {
+		o.Title = title
+	}
+	o.Body = strings.Join(lines, "\n")
+	if link != "" {
+		o.URL = link
+	}
+	if p.Username != "" && o.Subtitle == "" {
+		o.Subtitle = p.Username
+	}
+	if ch := strings.TrimPrefix(p.Channel, "#"); ch != "" && o.Group == "" {
+		o.Group = ch
+	}
+	if p.IconURL != "" && o.Icon == "" {
+		o.Icon = p.IconURL
+	}
+}
+
+var (
+	slackLinkPattern = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]+))?>`)
+	slackReplacer    = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+)
+
+// FormatSlack 将 Slack mrkdwn 转换为纯文本: <url|文字> 转为 "文字 (url)", <!here> 转为 @here,
+// 展开 :rocket: 形式的 emoji 短代码, 并还原 HTML 转义
+func FormatSlack(s string) string {
+	s = slackLinkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := slackLinkPattern.FindStringSubmatch(m)
+		target, label := sub[1], sub[2]
+		switch {
+		case strings.HasPrefix(target, "!"):
+			// <!here>, <!channel>, <!subteam^ID|@team>
+			if label != "" {
+				return label
+			}
+			return "@" + strings.TrimPrefix(target, "!")
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return target[:1] + strings.TrimLeft(label, "@#")
+			}
+			return target
+		case label != "":
+			return fmt.Sprintf("%s (%s)", label, target)
+		default:
+			return target
+		}
+	})
+	return slackReplacer.Replace(ExpandEmoji(s))
+}
+
+// slackFirstLink 返回 mrkdwn 文本中的第一个 http(s) 链接
+func slackFirstLink(s string) string {
+	for _, m := range slackLinkPattern.FindAllStringSubmatch(s, -1) {
+		if strings.HasPrefix(m[1], "http://") || strings.HasPrefix(m[1], "https://") {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package bark
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlogHandlerOptions SlogHandler 配置
+type SlogHandlerOptions struct {
+	// Level 推送的最低级别, 默认 slog.LevelError
+	Level slog.Leveler
+	// Limiter 推送限流, 默认每分钟最多 10 条; 被限流的记录数会附加在下一条推送中
+	Limiter *RateLimiter
+	// Next 可选, 全部记录同时交给该 Handler 处理 (如 slog.NewTextHandler), 便于替换 slog.Default()
+	Next slog.Handler
+	// AddSource 为 true 时在正文中附加调用位置
+	AddSource bool
+	// Severities 可选, 按记录级别设置 Bark 级别, 铃声等参数, 如 DefaultSeverityMap; 级别换算见 SlogSeverity
+	Severities SeverityMap
+}
+
+// SlogHandler 将达到指定级别的 slog 记录推送到 Bark 的 slog.Handler
+//
+// 标题为记录级别, 正文为日志消息和 key=value 形式的属性. 推送是同步的,
+// 不希望日志调用被网络请求阻塞时可以使用 Queue 作为 Pusher
+type SlogHandler struct {
+	p       Pusher
+	o       *Options
+	level   slog.Leveler
+	limiter *RateLimiter
+	next    slog.Handler
+	source  bool
+	sev     SeverityMap
+
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewSlogHandler 创建 SlogHandler, o 为推送的模板参数 (设备 Key, 分组等), opts 可以为 nil
+func NewSlogHandler(p Pusher, o *Options, opts *SlogHandlerOptions) *SlogHandler {
+	if o == nil {
+		o = &Options{}
+	}
+	if opts == nil {
+		opts = &SlogHandlerOptions{}
+	}
+	h := &SlogHandler{
+		p:       p,
+		o:       o.Clone(),
+		level:   opts.Level,
+		limiter: opts.Limiter,
+		next:    opts.Next,
+		source:  opts.AddSource,
+		sev:     opts.Severities,
+	}
+	if h.level == nil {
+		h.level = slog.LevelError
+	}
+	if h.limiter == nil {
+		h.limiter = NewRateLimiter(10, time.Minute)
+	}
+	return h
+}
+
+// Enabled 记录达到推送级别, 或 Next 处理该级别时返回 true
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() || (h.next != nil && h.next.Enabled(ctx, level))
+}
+
+// Handle 将记录交给 Next, 并在达到推送级别且未被限流时推送
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var nextErr error
+	if h.next != nil && h.next.Enabled(ctx, r.Level) {
+		nextErr = h.next.Handle(ctx, r)
+	}
+	if r.Level < h.level.Level() {
+		return nextErr
+	}
+	ok, dropped := h.limiter.Take()
+	if !ok {
+		return nextErr
+	}
+
+	o := h.o.Clone()
+	if o.Title == "" {
+		o.Title = r.Level.String()
+	}
+	h.sev.Apply(o, SlogSeverity(r.Level))
+	o.Body = h.body(r, dropped)
+	if err := h.p.Push(context.WithoutCancel(ctx), o); err != nil && nextErr == nil {
+		return fmt.Errorf("bark: push log record: %w", err)
+	}
+	return nextErr
+}
+
+// SlogSeverity 将 slog 级别转换为 Severity, 高于 slog.LevelError 4 级及以上 (常用作 fatal) 视为 SeverityFatal
+func SlogSeverity(l slog.Level) Severity {
+	switch {
+	case l < slog.LevelInfo:
+		return SeverityDebug
+	case l < slog.LevelWarn:
+		return SeverityInfo
+	case l < slog.LevelError:
+		return SeverityWarn
+	case l < slog.LevelError+4:
+		return SeverityError
+	default:
+		return SeverityFatal
+	}
+}
+
+func (h *SlogHandler) body(r slog.Record, dropped int) string {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	prefix := strings.Join(h.groups, ".")
+	for _, a := range h.attrs {
+		writeAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, prefix, a)
+		return true
+	})
+	if h.source {
+		if r.PC != 0 {
+			f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			fmt.Fprintf(&b, "\nsource=%s:%d", f.File, f.Line)
+		}
+	}
+	if !r.Time.IsZero() {
+		fmt.Fprintf(&b, "\ntime=%s", r.Time.Format(time.RFC3339))
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "\n(%d earlier records suppressed by rate limit)", dropped)
+	}
+	return b.String()
+}
+
+// writeAttr 以 key=value 写入一行, 分组属性的 key 以 . 连接
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	key := a.Key
+	if prefix != "" && key != "" {
+		key = prefix + "." + key
+	} else if key == "" {
+		key = prefix
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, key, ga)
+		}
+		return
+	}
+	fmt.Fprintf(b, "\n%s=%s", key, a.Value.String())
+}
+
+// WithAttrs 返回附加了属性的 Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	prefix := strings.Join(h.groups, ".")
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if prefix != "" {
+			a = slog.Group(prefix, a)
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+	if h.next != nil {
+		h2.next = h.next.WithAttrs(attrs)
+	}
+	return &h2
+}
+
+// WithGroup 返回属性位于分组下的 Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(append([]string(nil), h.groups...), name)
+	if h.next != nil {
+		h2.next = h.next.WithGroup(name)
+	}
+	return &h2
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
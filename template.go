@@ -0,0 +1,316 @@
+package bark
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gaoyaxuan/go-bark/md"
+)
+
+// ErrUnknownTemplate 未注册的消息模板
+var ErrUnknownTemplate = errors.New("bark: unknown template")
+
+// Templates 命名消息模板的注册表, 可并发使用
+//
+// 每个模板通过 {{define "title"}}...{{end}} 等子模板以参数名定义各字段 (title, subtitle, body, markdown, group, url ...);
+// 未定义任何参数子模板时整个模板作为 body. 模板可使用 TemplateFuncs 中的函数
+type Templates struct {
+	mu  sync.RWMutex
+	set map[string]*namedTemplate
+}
+
+type namedTemplate struct {
+	tmpl     *template.Template
+	defaults *Options
+}
+
+// NewTemplates 创建空的模板注册表
+func NewTemplates() *Templates {
+	return &Templates{set: make(map[string]*namedTemplate)}
+}
+
+// Add 解析并注册名为 name 的模板, 同名模板会被替换
+// defaults 为该模板的默认推送参数 (如设备 Key, 分组, 级别), 可以为 nil
+func (t *Templates) Add(name, text string, defaults *Options) error {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse template %s: %w", name, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.set[name] = &namedTemplate{tmpl: tmpl, defaults: defaults}
+	return nil
+}
+
+// AddFiles 读取并注册模板文件, 模板名为去掉扩展名的文件名, 如 deploy_done.tmpl 注册为 deploy_done
+func (t *Templates) AddFiles(paths ...string) error {
+	for _, path := range paths {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := t.Add(name, string(text), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names 返回已注册的模板名 (已排序)
+func (t *Templates) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.set))
+	for name := range t.set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render 以 data 渲染名为 name 的模板, 返回合并模板默认参数后的推送参数
+func (t *Templates) Render(name string, data interface{}) (*Options, error) {
+	t.mu.RLock()
+	nt, ok := t.set[name]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	o := &Options{}
+	if nt.defaults != nil {
+		o = nt.defaults.Clone()
+	}
+	defined, err := RenderOptions(nt.tmpl, data, o)
+	if err != nil {
+		return nil, fmt.Errorf("render template %s: %w", name, err)
+	}
+	if !defined {
+		var buf bytes.Buffer
+		if err := nt.tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("render template %s: %w", name, err)
+		}
+		o.Body = strings.TrimSpace(buf.String())
+	}
+	return o, nil
+}
+
+// WithTemplates 设置客户端的消息模板注册表, 供 PushTemplate 使用
+func WithTemplates(t *Templates) ClientOption {
+	return func(c *Client) {
+		c.templates = t
+	}
+}
+
+// PushTemplate 以 data 渲染名为 name 的模板并推送
+func (c *Client) PushTemplate(ctx context.Context, name string, data interface{}) error {
+	o, err := c.renderTemplate(name, data)
+	if err != nil {
+		return err
+	}
+	return c.Push(ctx, o)
+}
+
+// PushTemplate 以 data 渲染名为 name 的模板, 合并 profile 默认参数后推送
+func (p *ProfileClient) PushTemplate(ctx context.Context, name string, data interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	o, err := p.Client.renderTemplate(name, data)
+	if err != nil {
+		return err
+	}
+	return p.Push(ctx, o)
+}
+
+func (c *Client) renderTemplate(name string, data interface{}) (*Options, error) {
+	if c.templates == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	return c.templates.Render(name, data)
+}
+
+// RenderOptions 执行 tmpl 中以推送参数命名的子模板 (如 title, body, group, url), 并写入 o
+// 渲染结果会去掉首尾空白, 空结果不会覆盖 o 中已有的值; 返回是否定义了任何参数模板
+func RenderOptions(tmpl *template.Template, data interface{}, o *Options) (bool, error) {
+	if tmpl == nil {
+		return false, nil
+	}
+	defined := false
+	for _, name := range OptionNames() {
+		t := tmpl.Lookup(name)
+		if t == nil {
+			continue
+		}
+		defined = true
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return defined, fmt.Errorf("render %s: %w", name, err)
+		}
+		value := strings.TrimSpace(buf.String())
+		if value == "" {
+			continue
+		}
+		if err := o.Set(name, value); err != nil {
+			return defined, err
+		}
+	}
+	return defined, nil
+}
+
+// TemplateFuncs 返回消息模板可用的函数:
+//
+//	truncate n s          截断为最多 n 个字符, 超出部分以 … 结尾
+//	humanizeDuration d    时长转为 2h 5m 形式, 接受 time.Duration, 秒数或 "90s" 之类的文本
+//	humanizeBytes n       字节数转为 1.5 MiB 形式
+//	codeBlock lang s      包装为 Markdown 代码块, lang 可以为空
+//	code s                包装为 Markdown 行内代码
+//	escape s              转义 Markdown 符号, 见 md.Escape
+//	default def v         v 为空值时返回 def
+//	join sep list         以 sep 连接列表
+//	upper, lower, trim    同 strings.ToUpper, ToLower, TrimSpace
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"truncate":         truncate,
+		"humanizeDuration": humanizeDuration,
+		"humanizeBytes":    humanizeBytes,
+		"codeBlock":        md.CodeBlock,
+		"code":             md.Code,
+		"escape":           md.Escape,
+		"default":          defaultValue,
+		"join":             join,
+		"upper":            strings.ToUpper,
+		"lower":            strings.ToLower,
+		"trim":             strings.TrimSpace,
+	}
+}
+
+func truncate(n int, s string) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+func humanizeDuration(v interface{}) (string, error) {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	case string:
+		parsed, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return "", err
+		}
+		d = parsed
+	default:
+		secs, ok := toFloat(v)
+		if !ok {
+			return "", fmt.Errorf("humanizeDuration: unsupported type %T", v)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return sign + d.Round(time.Millisecond).String(), nil
+	}
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+	var parts []string
+	for _, u := range units {
+		if d >= u.size {
+			parts = append(parts, fmt.Sprintf("%d%s", d/u.size, u.name))
+			d %= u.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return sign + strings.Join(parts, " "), nil
+}
+
+func humanizeBytes(v interface{}) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("humanizeBytes: unsupported type %T", v)
+	}
+	if n < 1024 && n > -1024 {
+		return fmt.Sprintf("%d B", int64(n)), nil
+	}
+	units := []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := -1
+	for (n >= 1024 || n <= -1024) && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(fmt.Sprintf("%.1f", n), "0"), ".") + " " + units[i], nil
+}
+
+func defaultValue(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	if rv := reflect.ValueOf(v); rv.IsZero() {
+		return def
+	}
+	return v
+}
+
+func join(sep string, list interface{}) (string, error) {
+	switch list := list.(type) {
+	case []string:
+		return strings.Join(list, sep), nil
+	case nil:
+		return "", nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: unsupported type %T", list)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
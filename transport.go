@@ -0,0 +1,245 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithUnixSocket 通过 Unix domain socket 连接服务器, 适用于与 bark-server 部署在同一主机的场景
+//
+// ServerURL 仍用于构造请求 (路径和 Host 头), 建议使用 http://localhost 之类的地址:
+//
+//	bark.New("http://localhost", bark.WithUnixSocket("/run/bark/bark.sock"))
+func WithUnixSocket(path string) ClientOption {
+	return func(c *Client) {
+		c.setDial(func(d *dialConfig) {
+			d.unixSocket = path
+		})
+	}
+}
+
+// WithResolver 使用自定义的 DNS 解析器解析服务器域名, 如指定 DNS 服务器或走 DoH 的 net.Resolver
+func WithResolver(r *net.Resolver) ClientOption {
+	return func(c *Client) {
+		c.setDial(func(d *dialConfig) {
+			d.resolver = r
+			if d.cache != nil {
+				d.cache = newDNSCache(r, d.cache.ttl, d.cache.clk)
+			}
+		})
+	}
+}
+
+// WithDNSCache 在进程内缓存服务器域名的解析结果 ttl 时间, 减少突发推送时每次连接的解析延迟
+// 缓存过期后重新解析失败时继续使用过期的结果, 避免 DNS 偶发故障导致推送失败
+func WithDNSCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.setDial(func(d *dialConfig) {
+			d.cache = nil
+			if ttl > 0 {
+				d.cache = newDNSCache(d.resolver, ttl, c.clk)
+			}
+		})
+	}
+}
+
+// IPPreference 服务器域名解析出多个地址族时的连接顺序
+type IPPreference int
+
+const (
+	// IPDefault 使用标准库的默认行为 (双栈并发尝试)
+	IPDefault IPPreference = iota
+	// PreferIPv4 优先连接 IPv4 地址, 失败后再尝试 IPv6
+	PreferIPv4
+	// PreferIPv6 优先连接 IPv6 地址, 失败后再尝试 IPv4
+	PreferIPv6
+)
+
+// WithDialer 使用自定义的 net.Dialer 建立连接, 如调整超时, KeepAlive 或绑定本地地址
+// 与 WithResolver 同时使用时以 WithResolver 的解析器为准
+func WithDialer(d *net.Dialer) ClientOption {
+	return func(c *Client) {
+		c.setDial(func(dc *dialConfig) {
+			dc.base = d
+		})
+	}
+}
+
+// WithIPPreference 设置连接顺序, 用于双栈网络中某个地址族不通的场景, 避免连接卡在错误的地址族上
+func WithIPPreference(p IPPreference) ClientOption {
+	return func(c *Client) {
+		c.setDial(func(d *dialConfig) {
+			d.prefer = p
+		})
+	}
+}
+
+// dialConfig 自定义拨号设置, 由 WithUnixSocket, WithResolver, WithDNSCache, WithDialer, WithIPPreference 组合而成
+type dialConfig struct {
+	unixSocket string
+	resolver   *net.Resolver
+	cache      *dnsCache
+	base       *net.Dialer
+	prefer     IPPreference
+}
+
+// setDial 修改拨号设置的副本并安装到客户端的 Transport, 派生的客户端之间互不影响
+func (c *Client) setDial(fn func(d *dialConfig)) {
+	var d dialConfig
+	if c.dial != nil {
+		d = *c.dial
+	}
+	fn(&d)
+	c.dial = &d
+	withTransport(c, func(t *http.Transport) {
+		t.DialContext = d.dialContext
+		if d.unixSocket != "" {
+			// 本机连接不需要代理
+			t.Proxy = nil
+		}
+	})
+}
+
+func (d *dialConfig) dialer() *net.Dialer {
+	if d.base == nil {
+		// 与 http.DefaultTransport 的拨号参数一致
+		return &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: d.resolver}
+	}
+	dialer := *d.base
+	if d.resolver != nil {
+		dialer.Resolver = d.resolver
+	}
+	return &dialer
+}
+
+func (d *dialConfig) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := d.dialer()
+	if d.unixSocket != "" {
+		return dialer.DialContext(ctx, "unix", d.unixSocket)
+	}
+	if d.cache == nil && d.prefer == IPDefault {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	addrs, err := d.lookup(ctx, dialer, host)
+	if err != nil {
+		return nil, err
+	}
+	d.sortAddrs(addrs)
+	// 依次尝试解析出的地址, 返回第一个成功的连接
+	var errs []error
+	for _, ip := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (d *dialConfig) lookup(ctx context.Context, dialer *net.Dialer, host string) ([]string, error) {
+	if d.cache != nil {
+		addrs, err := d.cache.lookup(ctx, host)
+		// 返回副本, 排序不影响缓存
+		return append([]string(nil), addrs...), err
+	}
+	r := dialer.Resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return r.LookupHost(ctx, host)
+}
+
+// sortAddrs 按 IPPreference 把优先的地址族排在前面, 同一地址族内保持解析顺序
+func (d *dialConfig) sortAddrs(addrs []string) {
+	if d.prefer == IPDefault {
+		return
+	}
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return d.preferred(addrs[i]) && !d.preferred(addrs[j])
+	})
+}
+
+func (d *dialConfig) preferred(addr string) bool {
+	ip := net.ParseIP(addr)
+	isV4 := ip != nil && ip.To4() != nil
+	return isV4 == (d.prefer == PreferIPv4)
+}
+
+// dnsCache 进程内的域名解析缓存, 可并发使用
+type dnsCache struct {
+	resolver *net.Resolver
+	ttl      time.Duration
+	// clk 判断缓存过期的时间来源, 与客户端的 WithClock 一致
+	clk Clock
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(r *net.Resolver, ttl time.Duration, clk Clock) *dnsCache {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return &dnsCache{resolver: r, ttl: ttl, clk: clk, entries: make(map[string]dnsEntry)}
+}
+
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && clockOrSystem(c.clk).Now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := c.resolver.LookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return e.addrs, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: clockOrSystem(c.clk).Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// withTransport 复制客户端当前的 HTTPClient 和 Transport 后交给 fn 修改, 不影响调用方传入的 HTTPClient
+// 当前 Transport 不是 *http.Transport 时以 http.DefaultTransport 的副本为基础
+func withTransport(c *Client, fn func(t *http.Transport)) {
+	hc := *c.HTTPClient
+	var t *http.Transport
+	if base, ok := hc.Transport.(*http.Transport); ok {
+		t = base.Clone()
+	} else {
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	fn(t)
+	hc.Transport = t
+	c.HTTPClient = &hc
+}
@@ -0,0 +1,115 @@
+package bark
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseURL 解析 shoutrrr/Apprise 风格的服务 URL, 返回客户端和推送参数模板:
+//
+//	bark://devicekey@host[:port][/path]?sound=alarm&group=ci
+//	bark://:devicekey@host/path      (shoutrrr, Key 放在密码位置)
+//	bark://key1,key2@host            (多个设备)
+//	barks://host/key1/key2           (Apprise, 未在 userinfo 中指定 Key 时路径段视为设备 Key)
+//
+// bark:// 默认使用 https, 可以通过 scheme=http 查询参数改为 http; barks:// 始终使用 https
+// 查询参数 timeout 设置请求超时, 其余查询参数按 Options.Set 的规则设置推送参数
+func ParseURL(rawURL string) (*Client, *Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Error 包含完整的 URL, 其中可能有设备 Key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, fmt.Errorf("invalid bark url: %w", err)
+	}
+
+	scheme := "https"
+	switch strings.ToLower(u.Scheme) {
+	case "bark":
+	case "barks":
+	default:
+		return nil, nil, fmt.Errorf("unsupported url scheme: %s (supported: bark, barks)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, nil, errors.New("bark url requires a host")
+	}
+
+	o := &Options{}
+	var keys []string
+	if u.User != nil {
+		keys = splitKeys(u.User.Username())
+		if pass, ok := u.User.Password(); ok {
+			keys = append(keys, splitKeys(pass)...)
+		}
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if len(keys) == 0 && path != "" {
+		// Apprise 风格: 路径段均为设备 Key
+		keys = strings.Split(path, "/")
+		path = ""
+	}
+	setDeviceKeys(o, keys)
+
+	query := u.Query()
+	timeout := ""
+	for name, values := range query {
+		value := values[len(values)-1]
+		switch strings.ToLower(name) {
+		case "scheme":
+			if u.Scheme == "barks" && value != "https" {
+				return nil, nil, errors.New("barks url always uses https")
+			}
+			if value != "http" && value != "https" {
+				return nil, nil, fmt.Errorf("unsupported scheme parameter: %s", value)
+			}
+			scheme = value
+		case "timeout":
+			timeout = value
+		default:
+			if err := o.Set(name, value); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	serverURL := scheme + "://" + u.Host
+	if path != "" {
+		serverURL += "/" + path
+	}
+	client := New(serverURL)
+	if timeout != "" {
+		d, err := parseTimeout(timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("timeout: %w", err)
+		}
+		client.HTTPClient.Timeout = d
+	}
+
+	return client, o, nil
+}
+
+func splitKeys(s string) []string {
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// setDeviceKeys 单个设备写入 DeviceKey, 多个设备写入 DeviceKeys
+func setDeviceKeys(o *Options, keys []string) {
+	switch len(keys) {
+	case 0:
+	case 1:
+		o.DeviceKey = keys[0]
+	default:
+		o.DeviceKeys = keys
+	}
+}
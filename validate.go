@@ -0,0 +1,203 @@
+package bark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// MaxPayloadSize APNs 允许的通知 payload 上限 (字节), 严格模式下按序列化 (及加密) 后的请求体检查
+const MaxPayloadSize = 4096
+
+// validation 客户端的校验设置, 零值为 Options.Validate 的默认规则
+type validation struct {
+	// customSchemes 允许 URL 使用自定义 scheme, 见 WithCustomURLSchemes
+	customSchemes bool
+	// strict 检查内容约束, 见 WithStrictValidation
+	strict bool
+	// skip 跳过全部校验, 见 WithSkipValidation
+	skip bool
+}
+
+// WithSkipValidation 该客户端的推送跳过 Options.Validate 及严格模式的全部校验, 原样发送,
+// 用于对接包含非标准参数的 bark-server 分支版本. 单次推送可以使用 PushWithOptions 和 SkipValidation
+func WithSkipValidation() ClientOption {
+	return func(c *Client) {
+		c.validation.skip = true
+	}
+}
+
+// WithStrictValidation 推送前使用严格模式校验, 规则见 Options.ValidateStrict;
+// 请求体在序列化和加密后检查大小, 按设备拆分的推送逐个检查
+func WithStrictValidation() ClientOption {
+	return func(c *Client) {
+		c.validation.strict = true
+	}
+}
+
+// ValidateStrict 在 Validate 的基础上检查实际可用性相关的约束, 用于在开发和测试阶段发现推送不会按预期展示的问题:
+//
+//   - 送达设备的内容 (序列化及加密后, 不含 device_key 和 device_keys) 不超过 MaxPayloadSize, 使用 KeyProvider 的推送无法提前加密, 不检查大小
+//   - Body 和 Markdown 不同时设置 (设置 Markdown 时 Bark 不显示 Body)
+//   - Level 为 active, timeSensitive, passive, critical 之一
+//   - AutoCopy 只能为 0 或 1, 且有可复制的内容 (Copy 或 Body)
+//   - Call 只能为 1, 且不能与 passive 级别同时使用 (passive 通知不会响铃)
+//   - Volume 只用于 critical 级别 (0-10 的范围由 Validate 检查)
+func (o *Options) ValidateStrict() error {
+	o = o.withBoolFields()
+	if err := o.validate(validation{customSchemes: o.AllowCustomScheme, strict: true}); err != nil {
+		return err
+	}
+	if o.perDevice() {
+		return nil
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	size, err := writePayload(buf, o, nil)
+	if err != nil {
+		return err
+	}
+	return checkPayloadSize(size)
+}
+
+// validateContent 严格模式的内容约束
+func (o *Options) validateContent() error {
+	if o.Body != "" && o.Markdown != "" {
+		return errors.New("body and markdown are both set; bark shows only markdown, move the text into one of them")
+	}
+	switch o.Level {
+	case "", "active", "timeSensitive", "passive", "critical":
+	default:
+		return fmt.Errorf("unsupported level %q (supported: active, timeSensitive, passive, critical)", o.Level)
+	}
+	switch o.AutoCopy {
+	case "", "0":
+	case "1":
+		if o.Copy == "" && o.Body == "" {
+			return errors.New("autoCopy is set but there is nothing to copy; set copy or body")
+		}
+	default:
+		return fmt.Errorf("autoCopy must be 0 or 1, got %q", o.AutoCopy)
+	}
+	switch o.Call {
+	case "":
+	case "1":
+		if o.Level == "passive" {
+			return errors.New("call has no effect with the passive level")
+		}
+	default:
+		return fmt.Errorf("call must be 1, got %q", o.Call)
+	}
+	if o.Volume != nil && o.Level != "critical" {
+		return errors.New("volume only applies to the critical level")
+	}
+	return nil
+}
+
+// checkPayloadSize 检查请求体是否超过 APNs 的上限
+func checkPayloadSize(n int) error {
+	if n > MaxPayloadSize {
+		return InvalidOptions(fmt.Errorf("payload is %d bytes, exceeding the APNs limit of %d bytes; shorten the content", n, MaxPayloadSize))
+	}
+	return nil
+}
+
+// ErrInvalidOptions 推送参数未通过校验, Validate, ValidateStrict 和推送前校验返回的错误均可用 errors.Is 判断
+// 桥接等接收端据此区分请求方的错误 (400) 和推送失败 (502)
+var ErrInvalidOptions = errors.New("bark: invalid options")
+
+// InvalidOptions 将 err 标记为参数错误, 错误信息不变, errors.Is(err, ErrInvalidOptions) 为 true
+// 供其他 Pusher 实现 (如内嵌服务端) 报告参数错误
+func InvalidOptions(err error) error {
+	return &invalidOptionsError{err: err}
+}
+
+type invalidOptionsError struct {
+	err error
+}
+
+func (e *invalidOptionsError) Error() string        { return e.err.Error() }
+func (e *invalidOptionsError) Unwrap() error        { return e.err }
+func (e *invalidOptionsError) Is(target error) bool { return target == ErrInvalidOptions }
+
+// WithCustomURLSchemes 允许该客户端推送的 URL 使用 http(s) 以外的 scheme, 用于 App 深度链接,
+// 效果等同于为每次推送设置 Options.AllowCustomScheme
+func WithCustomURLSchemes() ClientOption {
+	return func(c *Client) {
+		c.validation.customSchemes = true
+	}
+}
+
+// skipValidation 判断本次推送是否跳过校验
+func (c *Client) skipValidation(ctx context.Context) bool {
+	return c.validation.skip || settings(ctx).skipValidation
+}
+
+// validateLink 检查 url, icon 等链接参数, Bark App 会静默忽略无法打开的链接
+// 空值合法; http(s) 链接必须包含主机名, customScheme 为 true 时也接受其他 scheme 的绝对 URL
+func validateLink(name, value string, customScheme bool) error {
+	if value == "" {
+		return nil
+	}
+	if strings.TrimSpace(value) != value {
+		return fmt.Errorf("invalid %s %q: leading or trailing whitespace", name, value)
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, errors.Unwrap(err))
+	}
+	if u.Scheme == "" {
+		if u.Host == "" && strings.HasPrefix(value, "/") {
+			return fmt.Errorf("invalid %s %q: must be an absolute http(s) URL, not a path", name, value)
+		}
+		return fmt.Errorf("invalid %s %q: must be an absolute http(s) URL, e.g. https://%s", name, value, strings.TrimPrefix(value, "//"))
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return fmt.Errorf("invalid %s %q: missing host", name, value)
+		}
+		return nil
+	}
+	if !customScheme {
+		if name == "url" {
+			return fmt.Errorf("invalid url %q: scheme %s is not http(s); set Options.AllowCustomScheme or use WithCustomURLSchemes for app deep links", value, u.Scheme)
+		}
+		return fmt.Errorf("invalid %s %q: scheme %s is not http(s)", name, value, u.Scheme)
+	}
+	return nil
+}
+
+// maxDeviceKeyLen 设备 Key 的最大长度, bark-server 生成的 Key 为 22 个字符, APNs 设备令牌为 64 个字符
+const maxDeviceKeyLen = 128
+
+// ErrInvalidDeviceKey 设备 Key 格式错误, 由 ValidateDeviceKey 和 Options.Validate 返回
+var ErrInvalidDeviceKey = errors.New("invalid device key")
+
+// ValidateDeviceKey 检查设备 Key 的格式, 在发送请求前发现复制粘贴错误,
+// 如首尾空白, 把整个推送 URL 当作 Key, 或包含 / ? # 等无法作为路径段的字符
+//
+// 允许的字符为字母, 数字, - 和 _, 兼容 bark-server 生成的 Key, 自定义 Key 和 APNs 设备令牌
+func ValidateDeviceKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidDeviceKey)
+	}
+	if strings.TrimSpace(key) != key {
+		return fmt.Errorf("%w %s: leading or trailing whitespace", ErrInvalidDeviceKey, Redact(strings.TrimSpace(key)))
+	}
+	if strings.Contains(key, "://") || strings.HasPrefix(key, "api.day.app/") {
+		return fmt.Errorf("%w %s: looks like a URL, use only the key part", ErrInvalidDeviceKey, Redact(key))
+	}
+	if len(key) > maxDeviceKeyLen {
+		return fmt.Errorf("%w %s: longer than %d characters", ErrInvalidDeviceKey, Redact(key), maxDeviceKeyLen)
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return fmt.Errorf("%w %s: contains invalid character %q", ErrInvalidDeviceKey, Redact(key), r)
+		}
+	}
+	return nil
+}
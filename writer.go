@@ -0,0 +1,253 @@
+package bark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultFlushInterval Writer 默认的合并推送间隔
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultWriterMaxLines Writer 单条推送默认最多包含的行数
+	DefaultWriterMaxLines = 50
+	// DefaultWriterMaxLineLength Writer 默认的最大行长度 (字节), 超出时强制拆分
+	DefaultWriterMaxLineLength = 4096
+)
+
+// Writer 将写入的日志按行缓存并合并推送的 io.Writer
+//
+// 第一行写入后开始计时, FlushInterval 后将期间的全部行合并为一条推送; 达到 MaxLines 时立即推送.
+// Write 从不返回错误, 推送失败交给 ErrorHandler, 因此可以安全地用于 io.MultiWriter:
+//
+//	log.SetOutput(io.MultiWriter(os.Stderr, bark.NewWriter(client, &bark.Options{Title: "myapp"})))
+type Writer struct {
+	p        Pusher
+	o        *Options
+	interval time.Duration
+	maxLines int
+	maxLine  int
+	syncLine func(line string) bool
+	onError  func(err error)
+	clk      Clock
+
+	mu      sync.Mutex
+	partial []byte
+	lines   []string
+	stop    func() bool
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// WriterOption Writer 配置项
+type WriterOption func(*Writer)
+
+// WithFlushInterval 设置合并推送的间隔, 默认 DefaultFlushInterval
+func WithFlushInterval(d time.Duration) WriterOption {
+	return func(w *Writer) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithMaxLines 设置单条推送最多包含的行数, 默认 DefaultWriterMaxLines
+func WithMaxLines(n int) WriterOption {
+	return func(w *Writer) {
+		if n > 0 {
+			w.maxLines = n
+		}
+	}
+}
+
+// WithMaxLineLength 设置最大行长度 (字节), 默认 DefaultWriterMaxLineLength
+// 没有换行的内容达到该长度时拆分为一行, 避免一直不换行的输入无限占用内存
+func WithMaxLineLength(n int) WriterOption {
+	return func(w *Writer) {
+		if n > 0 {
+			w.maxLine = n
+		}
+	}
+}
+
+// WithSyncFlush 写入匹配的行时在 Write 返回前同步推送缓存的全部行
+// 用于 log.Fatal 等写入后立即退出进程的场景, 例如:
+//
+//	bark.WithSyncFlush(func(line string) bool { return strings.Contains(line, "FATAL") })
+func WithSyncFlush(match func(line string) bool) WriterOption {
+	return func(w *Writer) {
+		w.syncLine = match
+	}
+}
+
+// WithWriterClock 设置 Writer 合并推送计时的时间来源, 默认 SystemClock
+func WithWriterClock(clk Clock) WriterOption {
+	return func(w *Writer) {
+		w.clk = clk
+	}
+}
+
+// WithWriterErrorHandler 设置推送失败的回调, 默认输出到标准错误
+// 默认不使用 log 或 slog, 避免 Writer 作为 log 输出时推送失败的日志再次写入 Writer
+func WithWriterErrorHandler(fn func(err error)) WriterOption {
+	return func(w *Writer) {
+		w.onError = fn
+	}
+}
+
+// NewWriter 创建 Writer, o 为每条推送的模板参数 (设备 Key, 标题等), 正文为合并后的日志行
+func NewWriter(p Pusher, o *Options, opts ...WriterOption) *Writer {
+	if o == nil {
+		o = &Options{}
+	}
+	w := &Writer{p: p, o: o.Clone(), interval: DefaultFlushInterval, maxLines: DefaultWriterMaxLines, maxLine: DefaultWriterMaxLineLength}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write 缓存写入的内容, 不完整的行保留到下一次写入
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return len(p), nil
+	}
+
+	w.partial = append(w.partial, p...)
+	syncFlush := false
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.partial[:i]), "\r")
+		w.partial = w.partial[i+1:]
+		if line == "" {
+			continue
+		}
+		w.lines = append(w.lines, line)
+		if w.syncLine != nil && w.syncLine(line) {
+			syncFlush = true
+		}
+	}
+	for len(w.partial) >= w.maxLine {
+		n := splitPoint(w.partial, w.maxLine)
+		w.lines = append(w.lines, string(w.partial[:n]))
+		w.partial = w.partial[n:]
+	}
+	if len(w.partial) == 0 {
+		w.partial = nil
+	}
+
+	switch {
+	case syncFlush:
+		lines := w.takeLocked()
+		w.mu.Unlock()
+		w.send(lines)
+		return len(p), nil
+	case len(w.lines) >= w.maxLines:
+		lines := w.takeLocked()
+		w.pending.Add(1)
+		go func() {
+			defer w.pending.Done()
+			w.send(lines)
+		}()
+	case len(w.lines) > 0 && w.stop == nil:
+		// 计时推送计入 pending, Close 会等待其完成; 计时被取消时由 takeLocked 释放
+		w.pending.Add(1)
+		w.stop = afterFunc(clockOrSystem(w.clk), w.interval, func() {
+			defer w.pending.Done()
+			_ = w.Flush()
+		})
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// takeLocked 取出缓存的行并停止计时, 调用方需持有锁
+func (w *Writer) takeLocked() []string {
+	if w.stop != nil {
+		if w.stop() {
+			w.pending.Done()
+		}
+		w.stop = nil
+	}
+	lines := w.lines
+	w.lines = nil
+	return lines
+}
+
+// Flush 立即推送缓存的完整行
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	lines := w.takeLocked()
+	w.mu.Unlock()
+	return w.send(lines)
+}
+
+// Close 推送缓存的全部内容 (包括不完整的行) 并等待进行中的推送完成, 之后的写入会被丢弃
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	if line := strings.TrimSpace(string(w.partial)); line != "" {
+		w.lines = append(w.lines, line)
+	}
+	w.partial = nil
+	lines := w.takeLocked()
+	w.mu.Unlock()
+
+	err := w.send(lines)
+	w.pending.Wait()
+	return err
+}
+
+// splitPoint 返回不超过 n 且不切断 UTF-8 字符的拆分位置
+func splitPoint(b []byte, n int) int {
+	if n >= len(b) {
+		return len(b)
+	}
+	i := n
+	for i > 0 && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	if i == 0 {
+		return n
+	}
+	return i
+}
+
+func (w *Writer) send(lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	o := w.o.Clone()
+	body := strings.Join(lines, "\n")
+	if o.Body != "" {
+		body = o.Body + "\n" + body
+	}
+	o.Body = body
+	if o.Title == "" {
+		o.Title = "log"
+	}
+
+	err := w.p.Push(context.Background(), o)
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		} else {
+			fmt.Fprintf(os.Stderr, "bark: writer push failed: %v\n", err)
+		}
+	}
+	return err
+}